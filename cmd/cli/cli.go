@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"backend/config"
+	"backend/helpers"
+	"backend/services/auth"
+	"backend/services/notifications"
+	"backend/services/push"
+	"backend/services/ranks"
+	"backend/services/user"
+	"backend/services/webhooks"
+	"backend/services/xp"
+	"backend/types"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Run executes an administrative command instead of starting the server, e.g.
+//
+//	go run cmd/main.go create-admin -username alice -password secret
+func Run(db *sql.DB, args []string) error {
+	switch args[0] {
+	case "create-admin":
+		return createAdmin(db, args[1:])
+	case "generate-vapid-keys":
+		return generateVAPIDKeys()
+	case "push-stub":
+		return runPushStub(args[1:])
+	case "recompute-xp":
+		return recomputeXP(db)
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// createAdmin promotes an existing user to admin, or creates a new admin account
+func createAdmin(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "username of the admin")
+	password := fs.String("password", "", "password, only used when the user does not exist yet")
+	fs.Parse(args)
+	if *username == "" {
+		return fmt.Errorf("-username is required")
+	}
+
+	store := user.NewUserRepoImpl(db)
+	existing, err := store.GetUserByUsername(*username)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if _, err := db.Exec("UPDATE users SET role = ? WHERE id = ?", auth.RoleAdmin, existing.Id); err != nil {
+			return err
+		}
+		log.Printf("user %s is now an admin", *username)
+		return nil
+	}
+
+	if *password == "" {
+		return fmt.Errorf("-password is required to create a new user")
+	}
+	if err := auth.ValidatePassword(*password); err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+	err = store.CreateUser(types.User{
+		Username:     *username,
+		PasswordHash: hash,
+		XP:           0,
+		RankId:       1,
+		Role:         auth.RoleAdmin,
+		Language:     helpers.DefaultLocale,
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("admin %s created", *username)
+	return nil
+}
+
+func generateVAPIDKeys() error {
+	keys, private, err := push.GenerateVAPIDKeys()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n# public key, served at /api/v1/push/vapid-public-key\n# %s\n", private, keys.PublicKey)
+	return nil
+}
+
+// runPushStub starts a local push service and prints a subscription to
+// register with POST /api/v1/me/push-subscriptions
+func runPushStub(args []string) error {
+	fs := flag.NewFlagSet("push-stub", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8090", "listen address")
+	fs.Parse(args)
+	stub, err := push.NewStub("http://" + *addr)
+	if err != nil {
+		return err
+	}
+	subscription, _ := json.Marshal(stub.Subscription("device-1"))
+	log.Printf("push service stub listening on %s, register this subscription:\n%s", *addr, subscription)
+	log.Printf("endpoints under http://%s/gone/ answer 410 Gone", *addr)
+	return http.ListenAndServe(*addr, stub)
+}
+
+// recomputeXP rebuilds every cached users.xp and rank_id from the XP ledger,
+// then announces the rank changes like the server does and waits until the
+// notifications and webhooks are out
+func recomputeXP(db *sql.DB) error {
+	xpRepo := xp.NewXPRepoImpl(db)
+	changed, changes, err := xpRepo.RecomputeAll()
+	if err != nil {
+		return err
+	}
+	log.Printf("recomputed XP from the ledger, %d users changed", changed)
+	if len(changes) == 0 {
+		return nil
+	}
+	vapidKeys, err := push.LoadOrGenerateVAPIDKeys(config.Envs.VAPIDPrivateKey)
+	if err != nil {
+		return err
+	}
+	// the queues have room for every announcement, a user may have several devices
+	pushSender := push.NewSender(push.NewPushRepoImpl(db), vapidKeys, config.Envs.VAPIDSubject, int(config.Envs.PushRetryAttempts), max(5*len(changes), 1000), config.Envs.PushAllowLocalEndpoints)
+	go pushSender.Run()
+	webhookSender := webhooks.NewSender(config.Envs.WebhookURL, config.Envs.WebhookSecret, int(config.Envs.WebhookRetryAttempts), max(len(changes), 256))
+	go webhookSender.Run()
+	notifier := push.NewNotifier(notifications.NewNotificationRepoImpl(db), pushSender)
+	log.Printf("announcing %d rank changes", len(changes))
+	ranks.NewLedger(xpRepo, notifier, webhookSender).Announce(changes)
+	pushSender.Wait()
+	webhookSender.Wait()
+	return nil
+}
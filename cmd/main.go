@@ -1,11 +1,14 @@
 package main
 
 import (
+	"backend/cmd/cli"
 	"backend/cmd/server"
 	"backend/config"
 	"backend/database"
+	"backend/services/auth"
 	"database/sql"
 	"log"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -38,6 +41,21 @@ func main() {
 	}
 	initStorage(db)
 
+	// administrative commands, e.g. `go run cmd/main.go create-admin -username alice -password secret`
+	if len(os.Args) > 1 {
+		if err := cli.Run(db, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := config.Envs.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	if err := auth.LoadKeys(); err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
+	}
+
 	server := server.NewServer("127.0.0.1:8000", db)
 	if err := server.Run(); err != nil {
 		log.Fatal(err)
@@ -1,14 +1,35 @@
 package server
 
 import (
+	"backend/config"
+	"backend/helpers"
 	"backend/middleware"
+	"backend/services/achievements"
+	"backend/services/admin"
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/services/digest"
+	"backend/services/emails"
+	"backend/services/leagues"
+	"backend/services/notifications"
 	"backend/services/pomodoros"
+	"backend/services/push"
+	"backend/services/quests"
 	"backend/services/ranking"
+	"backend/services/ranks"
+	"backend/services/seasons"
+	"backend/services/sessions"
 	"backend/services/stats"
+	"backend/services/streaks"
+	"backend/services/tokens"
 	"backend/services/user"
+	"backend/services/webhooks"
+	"backend/services/xp"
+	"backend/utils"
 	"database/sql"
 	"log"
 	"net/http"
+	"time"
 
 	_ "backend/docs"
 
@@ -30,6 +51,8 @@ func NewServer(addr string, db *sql.DB) *Server {
 
 func (s *Server) Run() error {
 	router := mux.NewRouter()
+	// public verification keys for other services (no auth required)
+	router.HandleFunc("/.well-known/jwks.json", auth.HandleJWKS).Methods(http.MethodGet)
 	subrouter := router.PathPrefix("/api/v1").Subrouter()
 	// Add Swagger (no auth required)
 	subrouter.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -39,28 +62,189 @@ func (s *Server) Run() error {
 	)).Methods(http.MethodGet)
 	// Create authenticated subrouter with JWT middleware
 	authSubrouter := subrouter.PathPrefix("").Subrouter()
-	authSubrouter.Use(middleware.JWTMiddleware)
+	tokenRepo := tokens.NewAccessTokenRepoImpl(s.db)
+	sessionRepo := sessions.NewSessionRepoImpl(s.db)
+	authSubrouter.Use(middleware.JWTMiddleware(tokenRepo, sessionRepo))
+	auditRepo := audit.NewAuditRepoImpl(s.db)
+
+	mailer, err := helpers.NewMailer(config.Envs)
+	if err != nil {
+		return err
+	}
+	// notifications are emitted by the other services and pushed to the user's devices
+	notificationRepo := notifications.NewNotificationRepoImpl(s.db)
+	vapidKeys, err := push.LoadOrGenerateVAPIDKeys(config.Envs.VAPIDPrivateKey)
+	if err != nil {
+		return err
+	}
+	pushRepo := push.NewPushRepoImpl(s.db)
+	pushSender := push.NewSender(pushRepo, vapidKeys, config.Envs.VAPIDSubject, int(config.Envs.PushRetryAttempts), 1000, config.Envs.PushAllowLocalEndpoints)
+	go pushSender.Run()
+	notifier := push.NewNotifier(notificationRepo, pushSender)
+	webhookSender := webhooks.NewSender(config.Envs.WebhookURL, config.Envs.WebhookSecret, int(config.Envs.WebhookRetryAttempts), 256)
+	go webhookSender.Run()
+	// every XP change goes through the ledger, which announces the rank changes
+	xpRepo := xp.NewXPRepoImpl(s.db)
+	ledger := ranks.NewLedger(xpRepo, notifier, webhookSender)
+	// focus sessions are scored with the XP rules, reloaded when their file changes
+	xpRules, err := xp.NewRules(config.Envs.XPRulesFile)
+	if err != nil {
+		return err
+	}
+	scorer := xp.NewScorer(xpRules, xpRepo, ledger, utils.SystemClock)
+	// the rank catalogue is read by every response showing rank progress
+	ranksRepo := ranks.NewRankRepoImpl(s.db)
+	// achievements are evaluated after sessions, streak ones by the streak engine
+	achievementRepo := achievements.NewAchievementRepoImpl(s.db)
+	achievementEvaluator := achievements.NewEvaluator(achievementRepo, ranksRepo, ledger, notifier)
+	// streaks are extended by focus sessions and settled every hour
+	streakRepo := streaks.NewStreakRepoImpl(s.db)
+	streakEngine := streaks.NewEngine(streakRepo, ledger, notifier, achievementEvaluator, utils.SystemClock)
+	// quests rotate daily and weekly and move forward with each pomodoro
+	questRepo := quests.NewQuestRepoImpl(s.db)
+	questService := quests.NewService(questRepo, ledger, notifier, utils.SystemClock)
 
 	// Register user routes (some may need auth, some may not)
 	userRepo := user.NewUserRepoImpl(s.db)
-	userHandler := user.NewHandler(userRepo)
+	userHandler := user.NewHandler(userRepo, sessionRepo, auditRepo, mailer, ranksRepo)
 	userHandler.RegisterRoutes(subrouter, authSubrouter)
 
 	// Register pomodoro routes (protected)
 	pomodoroRepo := pomodoros.NewPomodoroRepoImpl(s.db)
-	pomodoroHandler := pomodoros.NewHandler(pomodoroRepo)
+	pomodoroHandler := pomodoros.NewHandler(pomodoroRepo, scorer, notifier, achievementEvaluator, questService, streakEngine)
 	pomodoroHandler.RegisterRoutes(authSubrouter)
 
 	// Register stats routes (protected)
 	statsRepo := stats.NewStatsRepoImpl(s.db)
-	statsHandler := stats.NewHandler(statsRepo)
+	statsHandler := stats.NewHandler(statsRepo, xpRules)
 	statsHandler.RegisterRoutes(authSubrouter)
 
 	// Register ranking routes (protected)
 	rankRepo := ranking.NewRankingRepoImpl(s.db)
-	rankHandler := ranking.NewHandler(rankRepo)
+	rankHandler := ranking.NewHandler(rankRepo, ranksRepo)
 	rankHandler.RegisterRoutes(authSubrouter)
 
+	// Register rank catalogue, history and prestige routes (the catalogue is public, changes are admin only)
+	ranksHandler := ranks.NewHandler(ranksRepo, ledger, auditRepo)
+	ranksHandler.RegisterRoutes(subrouter, authSubrouter)
+
+	// Register personal access token routes (protected, login JWT only)
+	tokenHandler := tokens.NewHandler(tokenRepo, auditRepo)
+	tokenHandler.RegisterRoutes(authSubrouter)
+
+	// Register session routes (protected)
+	sessionHandler := sessions.NewHandler(sessionRepo, auditRepo)
+	sessionHandler.RegisterRoutes(authSubrouter)
+
+	// Register admin routes (protected, moderator or admin role)
+	adminRepo := admin.NewAdminRepoImpl(s.db)
+	adminHandler := admin.NewHandler(adminRepo, ledger, auditRepo, notifier, ranksRepo)
+	adminHandler.RegisterRoutes(authSubrouter)
+
+	// Register email preview routes (protected, moderator or admin role)
+	emailHandler := emails.NewHandler()
+	emailHandler.RegisterRoutes(authSubrouter)
+
+	// Register weekly digest routes (unsubscribe is public, rendering is for staff)
+	digestRepo := digest.NewDigestRepoImpl(s.db)
+	digester := digest.NewDigester(digestRepo, rankRepo, ranksRepo, mailer)
+	digestHandler := digest.NewHandler(digestRepo, digester)
+	digestHandler.RegisterRoutes(subrouter, authSubrouter)
+
+	// Register achievement routes (the catalogue is public)
+	achievementHandler := achievements.NewHandler(achievementRepo, achievementEvaluator)
+	achievementHandler.RegisterRoutes(subrouter, authSubrouter)
+
+	// Register season routes (protected)
+	seasonRepo := seasons.NewSeasonRepoImpl(s.db)
+	seasonService := seasons.NewService(seasonRepo, ledger, notifier, utils.SystemClock)
+	seasonHandler := seasons.NewHandler(seasonRepo, seasonService)
+	seasonHandler.RegisterRoutes(authSubrouter)
+
+	// Register league routes (protected)
+	leagueRepo := leagues.NewLeagueRepoImpl(s.db)
+	leagueService := leagues.NewService(leagueRepo, notifier, utils.SystemClock)
+	leagueHandler := leagues.NewHandler(leagueService)
+	leagueHandler.RegisterRoutes(authSubrouter)
+
+	// Register quest routes (protected)
+	questHandler := quests.NewHandler(questRepo, questService)
+	questHandler.RegisterRoutes(authSubrouter)
+
+	// Register streak routes (protected)
+	streakHandler := streaks.NewHandler(streakRepo, streakEngine)
+	streakHandler.RegisterRoutes(authSubrouter)
+
+	// Register XP rules and history routes (the rules are public)
+	xpHandler := xp.NewHandler(xpRepo, xpRules, scorer, auditRepo)
+	xpHandler.RegisterRoutes(subrouter, authSubrouter)
+
+	// Register notification center routes (protected)
+	notificationHandler := notifications.NewHandler(notificationRepo)
+	notificationHandler.RegisterRoutes(authSubrouter)
+
+	// Register Web Push routes (the VAPID public key is public)
+	pushHandler := push.NewHandler(pushRepo, pushSender, vapidKeys.PublicKey, config.Envs.PushAllowLocalEndpoints)
+	pushHandler.RegisterRoutes(subrouter, authSubrouter)
+
+	// Register audit log routes (protected, the query endpoints are admin only)
+	auditHandler := audit.NewHandler(auditRepo)
+	auditHandler.RegisterRoutes(authSubrouter)
+
+	// Background jobs
+	go utils.RunEvery(time.Hour, "purge deleted accounts", func() error {
+		purged, err := userRepo.PurgeDeletedUsers(time.Now())
+		if purged > 0 {
+			log.Printf("purged %d deleted accounts", purged)
+		}
+		return err
+	})
+	go utils.RunEvery(time.Hour, "purge inactive guests", func() error {
+		before := time.Now().AddDate(0, 0, -int(config.Envs.GuestInactiveDays))
+		purged, err := userRepo.PurgeInactiveGuests(before)
+		if purged > 0 {
+			log.Printf("purged %d inactive guest accounts", purged)
+		}
+		return err
+	})
+	go utils.RunEvery(time.Hour, "purge read notifications", func() error {
+		purged, err := notificationRepo.PurgeReadNotifications(time.Now().AddDate(0, 0, -90))
+		if purged > 0 {
+			log.Printf("purged %d read notifications", purged)
+		}
+		return err
+	})
+	pushScheduler := push.NewScheduler(pushRepo, pushSender, int(config.Envs.PushReminderHour))
+	go utils.RunEvery(time.Minute, "push timers", func() error {
+		return pushScheduler.SendDueTimers(time.Now())
+	})
+	go utils.RunEvery(time.Hour, "streak reminders", func() error {
+		return pushScheduler.SendStreakReminders(time.Now())
+	})
+	go utils.RunEvery(time.Hour, "purge expired push subscriptions", func() error {
+		purged, err := pushRepo.PurgeExpiredSubscriptions(time.Now())
+		if purged > 0 {
+			log.Printf("purged %d expired push subscriptions", purged)
+		}
+		return err
+	})
+	go utils.RunEvery(time.Hour, "settle streaks", streakEngine.SettleAll)
+	go utils.RunEvery(time.Hour, "purge expired quests", func() error {
+		purged, err := questService.PurgeExpired(30 * 24 * time.Hour)
+		if purged > 0 {
+			log.Printf("purged %d expired quests", purged)
+		}
+		return err
+	})
+	go utils.RunEvery(time.Hour, "close seasons", seasonService.CloseEnded)
+	go utils.RunEvery(time.Hour, "league rollover", leagueService.Run)
+	if config.Envs.XPRulesFile != "" && config.Envs.XPRulesReloadSeconds > 0 {
+		go utils.RunEvery(time.Duration(config.Envs.XPRulesReloadSeconds)*time.Second, "reload xp rules", xpRules.Reload)
+	}
+	go utils.RunEvery(time.Hour, "weekly digest", func() error {
+		return digester.SendDue(time.Now())
+	})
+
 	// --------------------------------------
 	log.Println("Listening on", s.addr)
 	return http.ListenAndServe(s.addr, middleware.EnableCORS(router))
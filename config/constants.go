@@ -1,6 +1,21 @@
 package config
 
+// streak freezes and repairs
 const (
-	GrowthFactor   float64 = 0.01
-	BaseMultiplier float64 = 1.0
+	// a freeze is earned at every multiple of this streak length
+	StreakFreezeEvery int = 7
+	MaxStreakFreezes  int = 2
+	StreakFreezeCost  int = 200
+	StreakRepairCost  int = 500
+	// a broken streak can be repaired this many hours after it broke
+	StreakRepairHours int = 24
+)
+
+// weekly leagues
+const (
+	// cohorts are filled up to this size, those made at rollover are balanced
+	LeagueCohortSize int = 30
+	// at most this many move up and down per cohort, never more than half of it
+	LeaguePromoted  int = 5
+	LeagueRelegated int = 5
 )
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -10,6 +11,9 @@ import (
 )
 
 type Config struct {
+	// production unless set, development settings are only accepted with dev
+	AppEnv string
+	// the URL users reach the server at, with the port if needed, e.g. https://api.example.com
 	PublicHost             string
 	Port                   string
 	DBUser                 string
@@ -18,25 +22,188 @@ type Config struct {
 	DBName                 string
 	JWTExpirationInSeconds int64
 	JWTSecret              string
-	GmailToken             string
+	JWTAlgorithm           string
+	JWTPrivateKeyFile      string
+	JWTVerificationKeys    []string
+	// smtp, file, log or memory, log and memory are only accepted in dev
+	MailDriver   string
+	MailFrom     string
+	SMTPHost     string
+	SMTPPort     int64
+	SMTPUsername string
+	SMTPPassword string
+	// starttls, tls (implicit, usually port 465) or none
+	SMTPTLSMode string
+	// maildir written by the file driver
+	MailDir string
+	// delivery attempts before an email is dropped
+	MailRetryAttempts int64
+	// optional copy of helpers/templates, read on every send to edit emails without a rebuild
+	EmailTemplatesDir string
+	// days between DELETE /me and the hard deletion of the account
+	AccountDeletionGraceDays int64
+	// algorithm for new password hashes: argon2id or bcrypt
+	PasswordHashAlgorithm string
+	Argon2MemoryKiB       int64
+	Argon2Iterations      int64
+	Argon2Parallelism     int64
+	// length of the derived argon2id key in bytes
+	Argon2KeyLength   int64
+	BcryptCost        int64
+	PasswordMinLength int64
+	PasswordMaxLength int64
+	// optional file of known breached passwords, one per line
+	BreachedPasswordsFile string
+	MagicLinkTTLMinutes   int64
+	// magic links that can be requested per address and hour
+	MagicLinkHourlyLimit int64
+	// days a user must wait between two username changes
+	UsernameChangeCooldownDays int64
+	// days an old username stays reserved for its previous owner
+	UsernameHoldDays int64
+	// optional file of extra reserved or forbidden usernames, one per line
+	ReservedUsernamesFile string
+	// guest accounts that can be created per IP and hour
+	GuestHourlyLimit int64
+	// unclaimed guest accounts unused for this many days are deleted
+	GuestInactiveDays int64
+	// base64url P-256 private key, see `go run cmd/main.go generate-vapid-keys`
+	VAPIDPrivateKey string
+	// mailto: or https: contact given to the push services
+	VAPIDSubject string
+	// delivery attempts before a push is dropped
+	PushRetryAttempts int64
+	// UTC hour from which streak reminders are pushed
+	PushReminderHour int64
+	// accept http and local push endpoints, for the push stub in development
+	PushAllowLocalEndpoints bool
+	// endpoint receiving event webhooks such as rank changes, none when empty
+	WebhookURL string
+	// key of the HMAC-SHA256 signature sent in X-Webhook-Signature
+	WebhookSecret string
+	// delivery attempts before a webhook is dropped
+	WebhookRetryAttempts int64
+	// optional JSON file of XP rules, the built-in rules apply when empty
+	XPRulesFile string
+	// how often the XP rules file is checked for changes
+	XPRulesReloadSeconds int64
+	// longest session accepted by POST /pomodoro, in minutes
+	MaxSessionMinutes int64
 }
 
+// only used for local development, the server refuses to start with it elsewhere
+const defaultJWTSecret = "astra"
+
 var Envs = initConfig()
 
 func initConfig() Config {
 	godotenv.Load(".env")
 	return Config{
-		PublicHost:             getEnv("PUBLIC_HOST", "http://localhost"),
-		Port:                   getEnv("PORT", "8000"),
-		DBUser:                 getEnv("DB_USER", ""),
-		DBPassword:             getEnv("DB_PASSWORD", ""),
-		DBAdress:               fmt.Sprintf("%s:%s", getEnv("DB_HOST", "localhost"), getEnv("DB_PORT", "3306")),
-		DBName:                 getEnv("DB_NAME", "ecom"),
-		JWTExpirationInSeconds: getEnvAsInt64("JWTExpirationInSeconds", 30*3600*24),
-		JWTSecret:              getEnv("JWTSecret", "astra"),
-		GmailToken:             getEnv("GMAIL_TOKEN", ""),
+		AppEnv:                     getEnv("APP_ENV", "production"),
+		PublicHost:                 getEnv("PUBLIC_HOST", "http://localhost:8000"),
+		Port:                       getEnv("PORT", "8000"),
+		DBUser:                     getEnv("DB_USER", ""),
+		DBPassword:                 getEnv("DB_PASSWORD", ""),
+		DBAdress:                   fmt.Sprintf("%s:%s", getEnv("DB_HOST", "localhost"), getEnv("DB_PORT", "3306")),
+		DBName:                     getEnv("DB_NAME", "ecom"),
+		JWTExpirationInSeconds:     getEnvAsInt64("JWTExpirationInSeconds", 30*3600*24),
+		JWTSecret:                  getEnv("JWTSecret", defaultJWTSecret),
+		JWTAlgorithm:               getEnv("JWT_ALGORITHM", "HS256"),
+		JWTPrivateKeyFile:          getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		JWTVerificationKeys:        getEnvAsList("JWT_VERIFICATION_KEY_FILES"),
+		MailDriver:                 getEnv("MAIL_DRIVER", "log"),
+		MailFrom:                   getEnv("MAIL_FROM", "XPomodoro <no-reply@localhost>"),
+		SMTPHost:                   getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:                   getEnvAsInt64("SMTP_PORT", 587),
+		SMTPUsername:               getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:               getEnv("SMTP_PASSWORD", ""),
+		SMTPTLSMode:                getEnv("SMTP_TLS_MODE", "starttls"),
+		MailDir:                    getEnv("MAIL_DIR", "mail"),
+		MailRetryAttempts:          getEnvAsInt64("MAIL_RETRY_ATTEMPTS", 5),
+		EmailTemplatesDir:          getEnv("EMAIL_TEMPLATES_DIR", ""),
+		AccountDeletionGraceDays:   getEnvAsInt64("ACCOUNT_DELETION_GRACE_DAYS", 14),
+		PasswordHashAlgorithm:      getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2MemoryKiB:            getEnvAsInt64("ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Iterations:           getEnvAsInt64("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:          getEnvAsInt64("ARGON2_PARALLELISM", 2),
+		Argon2KeyLength:            getEnvAsInt64("ARGON2_KEY_LENGTH", 32),
+		BcryptCost:                 getEnvAsInt64("BCRYPT_COST", 10),
+		PasswordMinLength:          getEnvAsInt64("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:          getEnvAsInt64("PASSWORD_MAX_LENGTH", 128),
+		BreachedPasswordsFile:      getEnv("BREACHED_PASSWORDS_FILE", ""),
+		MagicLinkTTLMinutes:        getEnvAsInt64("MAGIC_LINK_TTL_MINUTES", 15),
+		MagicLinkHourlyLimit:       getEnvAsInt64("MAGIC_LINK_HOURLY_LIMIT", 3),
+		UsernameChangeCooldownDays: getEnvAsInt64("USERNAME_CHANGE_COOLDOWN_DAYS", 30),
+		UsernameHoldDays:           getEnvAsInt64("USERNAME_HOLD_DAYS", 90),
+		ReservedUsernamesFile:      getEnv("RESERVED_USERNAMES_FILE", ""),
+		GuestHourlyLimit:           getEnvAsInt64("GUEST_HOURLY_LIMIT", 5),
+		GuestInactiveDays:          getEnvAsInt64("GUEST_INACTIVE_DAYS", 90),
+		VAPIDPrivateKey:            getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:               getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+		PushRetryAttempts:          getEnvAsInt64("PUSH_RETRY_ATTEMPTS", 5),
+		PushReminderHour:           getEnvAsInt64("PUSH_REMINDER_HOUR", 18),
+		PushAllowLocalEndpoints:    getEnvAsBool("PUSH_ALLOW_LOCAL_ENDPOINTS", false),
+		WebhookURL:                 getEnv("WEBHOOK_URL", ""),
+		WebhookSecret:              getEnv("WEBHOOK_SECRET", ""),
+		WebhookRetryAttempts:       getEnvAsInt64("WEBHOOK_RETRY_ATTEMPTS", 5),
+		XPRulesFile:                getEnv("XP_RULES_FILE", ""),
+		XPRulesReloadSeconds:       getEnvAsInt64("XP_RULES_RELOAD_SECONDS", 30),
+		MaxSessionMinutes:          getEnvAsInt64("MAX_SESSION_MINUTES", 120),
 	}
 }
+
+// Validate rejects invalid settings, and the settings that are only
+// acceptable in development outside of it
+func (c Config) Validate() error {
+	if c.MaxSessionMinutes < 1 {
+		return fmt.Errorf("MAX_SESSION_MINUTES must be at least 1")
+	}
+	if err := c.validatePasswordHashing(); err != nil {
+		return err
+	}
+	if c.AppEnv == "dev" {
+		return nil
+	}
+	if c.JWTAlgorithm == "HS256" && (c.JWTSecret == "" || c.JWTSecret == defaultJWTSecret) {
+		return fmt.Errorf("JWTSecret must be set to a non default value when APP_ENV=%s", c.AppEnv)
+	}
+	if c.MailDriver == "log" || c.MailDriver == "memory" {
+		return fmt.Errorf("MAIL_DRIVER=%s is only allowed when APP_ENV=dev, use smtp or file", c.MailDriver)
+	}
+	if c.PushAllowLocalEndpoints {
+		return fmt.Errorf("PUSH_ALLOW_LOCAL_ENDPOINTS is only allowed when APP_ENV=dev")
+	}
+	return nil
+}
+
+// validatePasswordHashing checks the parameters of the selected algorithm
+// against the ranges argon2 and bcrypt accept
+func (c Config) validatePasswordHashing() error {
+	switch c.PasswordHashAlgorithm {
+	case "argon2id":
+		if c.Argon2Parallelism < 1 || c.Argon2Parallelism > math.MaxUint8 {
+			return fmt.Errorf("ARGON2_PARALLELISM must be between 1 and %d", math.MaxUint8)
+		}
+		// argon2 needs 8 KiB per lane
+		if c.Argon2MemoryKiB < 8*c.Argon2Parallelism || c.Argon2MemoryKiB > math.MaxUint32 {
+			return fmt.Errorf("ARGON2_MEMORY_KIB must be between %d and %d", 8*c.Argon2Parallelism, uint32(math.MaxUint32))
+		}
+		if c.Argon2Iterations < 1 || c.Argon2Iterations > math.MaxUint32 {
+			return fmt.Errorf("ARGON2_ITERATIONS must be between 1 and %d", uint32(math.MaxUint32))
+		}
+		if c.Argon2KeyLength < 16 || c.Argon2KeyLength > 64 {
+			return fmt.Errorf("ARGON2_KEY_LENGTH must be between 16 and 64")
+		}
+	case "bcrypt":
+		if c.BcryptCost < 4 || c.BcryptCost > 31 {
+			return fmt.Errorf("BCRYPT_COST must be between 4 and 31")
+		}
+	default:
+		return fmt.Errorf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt, not %q", c.PasswordHashAlgorithm)
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -53,3 +220,23 @@ func getEnvAsInt64(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if res, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			return res
+		}
+	}
+	return fallback
+}
+
+// comma separated list, empty entries are dropped
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
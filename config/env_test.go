@@ -0,0 +1,67 @@
+package config
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	production := Config{
+		AppEnv: "production", JWTAlgorithm: "HS256", JWTSecret: "s3cret", MailDriver: "smtp", MaxSessionMinutes: 120,
+		PasswordHashAlgorithm: "argon2id", Argon2MemoryKiB: 64 * 1024, Argon2Iterations: 3, Argon2Parallelism: 2, Argon2KeyLength: 32, BcryptCost: 10,
+	}
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr bool
+	}{
+		{"production settings", func(c *Config) {}, false},
+		{"default JWT secret", func(c *Config) { c.JWTSecret = defaultJWTSecret }, true},
+		{"log mail driver", func(c *Config) { c.MailDriver = "log" }, true},
+		{"memory mail driver", func(c *Config) { c.MailDriver = "memory" }, true},
+		{"file mail driver", func(c *Config) { c.MailDriver = "file" }, false},
+		{"local push endpoints", func(c *Config) { c.PushAllowLocalEndpoints = true }, true},
+		{"no session length", func(c *Config) { c.MaxSessionMinutes = 0 }, true},
+		{"no session length in dev", func(c *Config) {
+			c.AppEnv = "dev"
+			c.MaxSessionMinutes = 0
+		}, true},
+		{"unknown hash algorithm", func(c *Config) { c.PasswordHashAlgorithm = "md5" }, true},
+		{"no argon2 lane", func(c *Config) { c.Argon2Parallelism = 0 }, true},
+		{"too many argon2 lanes", func(c *Config) { c.Argon2Parallelism = 256 }, true},
+		{"argon2 memory below 8 KiB per lane", func(c *Config) { c.Argon2MemoryKiB = 15 }, true},
+		{"argon2 memory of 8 KiB per lane", func(c *Config) { c.Argon2MemoryKiB = 16 }, false},
+		{"argon2 memory over 32 bits", func(c *Config) { c.Argon2MemoryKiB = 1 << 32 }, true},
+		{"no argon2 iteration", func(c *Config) { c.Argon2Iterations = 0 }, true},
+		{"short argon2 key", func(c *Config) { c.Argon2KeyLength = 8 }, true},
+		{"long argon2 key", func(c *Config) { c.Argon2KeyLength = 65 }, true},
+		{"bcrypt ignores the argon2 settings", func(c *Config) {
+			c.PasswordHashAlgorithm = "bcrypt"
+			c.Argon2KeyLength = 0
+		}, false},
+		{"bcrypt cost too low", func(c *Config) {
+			c.PasswordHashAlgorithm = "bcrypt"
+			c.BcryptCost = 3
+		}, true},
+		{"bcrypt cost too high", func(c *Config) {
+			c.PasswordHashAlgorithm = "bcrypt"
+			c.BcryptCost = 32
+		}, true},
+		{"argon2 settings in dev", func(c *Config) {
+			c.AppEnv = "dev"
+			c.Argon2Iterations = 0
+		}, true},
+		{"dev accepts development settings", func(c *Config) {
+			c.AppEnv = "dev"
+			c.JWTSecret = defaultJWTSecret
+			c.MailDriver = "log"
+			c.PushAllowLocalEndpoints = true
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := production
+			tt.change(&c)
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"backend/types"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every email into a maildir (tmp/, new/, cur/) so local
+// mail clients or a quick ls can show what would have been sent
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Int64
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(e types.Email) error {
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s.eml", time.Now().Unix(), os.Getpid(), m.seq.Add(1), hostname)
+	// written in tmp/ then moved so readers never see a partial message
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, buildMessage(m.from, e), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, "new", name))
+}
@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"backend/config"
+	"backend/types"
+	"backend/utils"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewMailer builds the mailer selected by MAIL_DRIVER. Except for the memory
+// driver, sends are queued and retried in the background so a failing mail
+// server never fails the request that triggered the email.
+func NewMailer(cfg config.Config) (types.Mailer, error) {
+	var mailer types.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		smtpMailer, err := NewSMTPMailer(cfg.SMTPHost, int(cfg.SMTPPort), cfg.SMTPTLSMode, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+		if err != nil {
+			return nil, err
+		}
+		mailer = smtpMailer
+	case "file":
+		fileMailer, err := NewFileMailer(cfg.MailDir, cfg.MailFrom)
+		if err != nil {
+			return nil, err
+		}
+		mailer = fileMailer
+	case "log":
+		mailer = LogMailer{}
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q, expected smtp, file, log or memory", cfg.MailDriver)
+	}
+	retrying := NewRetryingMailer(mailer, int(cfg.MailRetryAttempts), 256)
+	go retrying.Run()
+	return retrying, nil
+}
+
+// LogMailer only prints who an email goes to, for development without a mail
+// server. Bodies carry sign-in links and reset codes and never reach the log,
+// the file driver keeps the whole message.
+type LogMailer struct{}
+
+func (LogMailer) Send(e types.Email) error {
+	log.Printf("email to %s: %s", e.To, e.Subject)
+	return nil
+}
+
+// buildMessage renders the email as an RFC 5322 message, with a
+// multipart/alternative body when there is a plain-text version
+func buildMessage(from string, e types.Email) []byte {
+	var body bytes.Buffer
+	headers := map[string]string{
+		"From":         from,
+		"To":           e.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", e.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   fmt.Sprintf("<%s@%s>", utils.GenerateToken()[:32], domainOf(from)),
+		"MIME-Version": "1.0",
+	}
+	if e.TextBody == "" {
+		headers["Content-Type"] = `text/html; charset="UTF-8"`
+		headers["Content-Transfer-Encoding"] = "quoted-printable"
+		writeQuotedPrintable(&body, e.HTMLBody)
+	} else {
+		mw := multipart.NewWriter(&body)
+		headers["Content-Type"] = "multipart/alternative; boundary=" + mw.Boundary()
+		// the preferred version comes last (RFC 2046)
+		writePart(mw, "text/plain", e.TextBody)
+		writePart(mw, "text/html", e.HTMLBody)
+		mw.Close()
+	}
+	for name, value := range e.Headers {
+		headers[name] = value
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headers[name])
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func writePart(mw *multipart.Writer, contentType, content string) {
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + `; charset="UTF-8"`},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	writeQuotedPrintable(part, content)
+}
+
+func writeQuotedPrintable(w io.Writer, content string) {
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(content))
+	qp.Close()
+}
+
+// addressOf extracts the bare address from "Name <address>"
+func addressOf(from string) string {
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		return parsed.Address
+	}
+	return from
+}
+
+func domainOf(from string) string {
+	address := addressOf(from)
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"backend/types"
+	"sync"
+)
+
+// MemoryMailer keeps the sent emails in memory, for tests
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []types.Email
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(e types.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, e)
+	return nil
+}
+
+// Sent returns a copy of the emails sent so far
+func (m *MemoryMailer) Sent() []types.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]types.Email(nil), m.sent...)
+}
+
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = nil
+}
@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"time"
+)
+
+// RetryingMailer queues emails and delivers them in the background, failed
+// deliveries are retried with an exponential backoff up to attempts times
+type RetryingMailer struct {
+	next  types.Mailer
+	queue *utils.RetryQueue
+}
+
+func NewRetryingMailer(next types.Mailer, attempts int, queueSize int) *RetryingMailer {
+	return &RetryingMailer{next: next, queue: utils.NewRetryQueue("mail", attempts, queueSize)}
+}
+
+// Send only queues the email, it fails when the queue is full
+func (m *RetryingMailer) Send(e types.Email) error {
+	return m.queue.Push(utils.RetryJob{
+		Name:    fmt.Sprintf("email %q to %s", e.Subject, e.To),
+		Deliver: func() (time.Duration, error) { return 0, m.next.Send(e) },
+	})
+}
+
+// Run delivers queued emails until the process exits
+func (m *RetryingMailer) Run() {
+	m.queue.Run()
+}
@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"backend/types"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyMailer fails the first failures sends, then hands emails to next
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	next     types.Mailer
+}
+
+func (m *flakyMailer) Send(e types.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("mail server unavailable")
+	}
+	return m.next.Send(e)
+}
+
+func waitForSent(t *testing.T, mailer *MemoryMailer, want int) []types.Email {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if sent := mailer.Sent(); len(sent) >= want {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("got %d emails, want %d", len(mailer.Sent()), want)
+	return nil
+}
+
+func TestRetryingMailerRetriesFailedSends(t *testing.T) {
+	capture := NewMemoryMailer()
+	retrying := NewRetryingMailer(&flakyMailer{failures: 2, next: capture}, 3, 8)
+	retrying.queue.Backoff = time.Millisecond
+	go retrying.Run()
+
+	if err := retrying.Send(types.Email{To: "alice@example.com", Subject: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := waitForSent(t, capture, 1)
+	if sent[0].To != "alice@example.com" {
+		t.Errorf("got email to %q", sent[0].To)
+	}
+}
+
+func TestRetryingMailerGivesUp(t *testing.T) {
+	capture := NewMemoryMailer()
+	retrying := NewRetryingMailer(&flakyMailer{failures: 2, next: capture}, 2, 8)
+	retrying.queue.Backoff = time.Millisecond
+	go retrying.Run()
+
+	if err := retrying.Send(types.Email{To: "alice@example.com", Subject: "dropped"}); err != nil {
+		t.Fatal(err)
+	}
+	// the second email goes through once the first one used up the failures
+	time.Sleep(20 * time.Millisecond)
+	if err := retrying.Send(types.Email{To: "bob@example.com", Subject: "delivered"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := waitForSent(t, capture, 1)
+	if len(sent) != 1 || sent[0].Subject != "delivered" {
+		t.Errorf("got %v, want only the second email", sent)
+	}
+}
+
+func TestRetryingMailerFullQueue(t *testing.T) {
+	// without Run nothing drains the queue
+	retrying := NewRetryingMailer(NewMemoryMailer(), 1, 1)
+	if err := retrying.Send(types.Email{To: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := retrying.Send(types.Email{To: "bob@example.com"}); err == nil {
+		t.Error("sending to a full queue did not fail")
+	}
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"backend/config"
+	"backend/types"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSendVerificationEmail(t *testing.T) {
+	mailer := NewMemoryMailer()
+	token := "a+b/c"
+	if err := SendVerificationEmail(mailer, "en", "alice@example.com", token); err != nil {
+		t.Fatal(err)
+	}
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(sent))
+	}
+	email := sent[0]
+	if email.To != "alice@example.com" || email.Subject != "Verify Your Email" {
+		t.Errorf("got email to %q with subject %q", email.To, email.Subject)
+	}
+	link := APIURL("/verify?token=" + url.QueryEscape(token))
+	if !strings.Contains(email.TextBody, link) {
+		t.Errorf("text body %q does not contain %q", email.TextBody, link)
+	}
+	if !strings.Contains(email.HTMLBody, "token="+url.QueryEscape(token)) {
+		t.Errorf("html body does not contain the token")
+	}
+}
+
+func TestSendPasswordResetCodeLocale(t *testing.T) {
+	mailer := NewMemoryMailer()
+	if err := SendPasswordResetCode(mailer, "fr", "bob@example.com", "123456"); err != nil {
+		t.Fatal(err)
+	}
+	// a locale without a translation falls back to the default one
+	if err := SendPasswordResetCode(mailer, "de", "bob@example.com", "654321"); err != nil {
+		t.Fatal(err)
+	}
+	sent := mailer.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("got %d emails, want 2", len(sent))
+	}
+	if sent[0].Subject == sent[1].Subject {
+		t.Errorf("the fr and en emails share the subject %q", sent[0].Subject)
+	}
+	if !strings.Contains(sent[0].TextBody, "123456") || !strings.Contains(sent[1].TextBody, "654321") {
+		t.Errorf("the codes are missing from the bodies")
+	}
+
+	mailer.Reset()
+	if len(mailer.Sent()) != 0 {
+		t.Errorf("Reset kept the sent emails")
+	}
+}
+
+func TestSendWeeklyDigestUnsubscribeHeaders(t *testing.T) {
+	mailer := NewMemoryMailer()
+	digest := &types.WeeklyDigest{UnsubscribeURL: "https://example.com/unsubscribe?token=abc"}
+	if err := SendWeeklyDigest(mailer, "en", "carol@example.com", digest); err != nil {
+		t.Fatal(err)
+	}
+	sent := mailer.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(sent))
+	}
+	headers := sent[0].Headers
+	if headers["List-Unsubscribe"] != "<https://example.com/unsubscribe?token=abc>" {
+		t.Errorf("got List-Unsubscribe %q", headers["List-Unsubscribe"])
+	}
+	if headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Errorf("got List-Unsubscribe-Post %q", headers["List-Unsubscribe-Post"])
+	}
+}
+
+func TestAPIURLUsesThePublicHostOnly(t *testing.T) {
+	saved := config.Envs
+	defer func() { config.Envs = saved }()
+	config.Envs.PublicHost = "https://api.example.com/"
+	config.Envs.Port = "8000"
+	if got := APIURL("/verify?token=abc"); got != "https://api.example.com/api/v1/verify?token=abc" {
+		t.Errorf("got %q", got)
+	}
+}
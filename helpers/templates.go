@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"backend/config"
+	"backend/types"
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// DefaultLocale is used for users without a language and for missing translations
+const DefaultLocale = "en"
+
+var SupportedLocales = []string{"en", "fr"}
+
+// transactional email templates, each one is a <name>.html and a <name>.txt
+// (which also defines the "subject") per locale in helpers/templates
+const (
+	EmailVerifyEmail   = "verify_email"
+	EmailPasswordReset = "password_reset"
+	EmailNewDevice     = "new_device"
+	EmailMagicLink     = "magic_link"
+	EmailWeeklyDigest  = "weekly_digest"
+)
+
+var EmailTemplates = []string{EmailVerifyEmail, EmailPasswordReset, EmailNewDevice, EmailMagicLink, EmailWeeklyDigest}
+
+// helpers available in every template
+var templateFuncs = map[string]any{
+	// signed prints a change with its sign, e.g. +3 or -2
+	"signed": func(n int) string {
+		if n > 0 {
+			return fmt.Sprintf("+%d", n)
+		}
+		return fmt.Sprint(n)
+	},
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var templateCache sync.Map
+
+// RenderEmail renders the subject and both bodies of a template in the locale,
+// falling back to DefaultLocale when there is no translation
+func RenderEmail(name, locale string, data any) (*types.RenderedEmail, error) {
+	t, err := loadEmailTemplate(name, MatchLocale(locale))
+	if err != nil {
+		return nil, err
+	}
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := t.text.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return nil, err
+	}
+	if err := t.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return nil, err
+	}
+	return &types.RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// loadEmailTemplate parses the template once, unless EMAIL_TEMPLATES_DIR is set:
+// templates are then read from disk on every render so designers see their changes live
+func loadEmailTemplate(name, locale string) (*emailTemplate, error) {
+	key := locale + "/" + name
+	live := config.Envs.EmailTemplatesDir != ""
+	if !live {
+		if t, ok := templateCache.Load(key); ok {
+			return t.(*emailTemplate), nil
+		}
+	}
+	fsys, err := templateFS()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(fsys, path.Join(locale, name+".html")); err != nil {
+		locale = DefaultLocale
+	}
+	html, err := htmltemplate.New(name+".html").
+		Funcs(templateFuncs).
+		Funcs(htmltemplate.FuncMap{"lang": func() string { return locale }}).
+		ParseFS(fsys, "layout.html", path.Join(locale, "common.html"), path.Join(locale, name+".html"))
+	if err != nil {
+		return nil, fmt.Errorf("email template %s: %w", key, err)
+	}
+	text, err := texttemplate.New(name+".txt").Funcs(templateFuncs).ParseFS(fsys, path.Join(locale, name+".txt"))
+	if err != nil {
+		return nil, fmt.Errorf("email template %s: %w", key, err)
+	}
+	t := &emailTemplate{html: html, text: text}
+	if !live {
+		templateCache.Store(key, t)
+	}
+	return t, nil
+}
+
+func templateFS() (fs.FS, error) {
+	if dir := config.Envs.EmailTemplatesDir; dir != "" {
+		return os.DirFS(dir), nil
+	}
+	return fs.Sub(embeddedTemplates, "templates")
+}
+
+func IsSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchLocale maps a language tag such as "fr-BE" to a supported locale
+func MatchLocale(tag string) string {
+	if primary := primaryLanguage(tag); IsSupportedLocale(primary) {
+		return primary
+	}
+	return DefaultLocale
+}
+
+// LocaleFromAcceptLanguage picks the first supported language of an Accept-Language header
+func LocaleFromAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if primary := primaryLanguage(tag); IsSupportedLocale(primary) {
+			return primary
+		}
+	}
+	return DefaultLocale
+}
+
+func primaryLanguage(tag string) string {
+	primary := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(primary, "-_"); i >= 0 {
+		primary = primary[:i]
+	}
+	return primary
+}
+
+type verifyEmailData struct {
+	Link string
+}
+
+type passwordResetData struct {
+	Code string
+}
+
+type newDeviceData struct {
+	Device  string
+	Browser string
+	IP      string
+	Time    string
+}
+
+type magicLinkData struct {
+	Link    string
+	Minutes int
+}
+
+// PreviewData returns sample data to render a template without sending it
+func PreviewData(name string) (any, bool) {
+	switch name {
+	case EmailVerifyEmail:
+		return verifyEmailData{Link: APIURL("/verify?token=preview")}, true
+	case EmailPasswordReset:
+		return passwordResetData{Code: "12345678"}, true
+	case EmailNewDevice:
+		return newDeviceData{Device: "Work laptop", Browser: "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0", IP: "203.0.113.7", Time: time.Now().UTC().Format("2006-01-02 15:04 MST")}, true
+	case EmailMagicLink:
+		return magicLinkData{Link: APIURL("/login/magic/redeem?token=preview"), Minutes: 15}, true
+	case EmailWeeklyDigest:
+		week := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+		return &types.WeeklyDigest{
+			Username: "alice", WeekStart: week, WeekEnd: week.AddDate(0, 0, 6),
+			Pomodoros: 23, PreviousPomodoros: 18, PomodoroChange: 5,
+			FocusMinutes: 575, PreviousFocusMinutes: 450, FocusMinutesChange: 125,
+			CurrentStreak: 9, XP: 1320, RankName: "Silver II", NextRankName: "Silver III", XPToNextRank: 80, RankProgressPercent: 60,
+			GlobalRank: 412, PreviousGlobalRank: 431, GlobalRankChange: 19,
+			Country: "TN", CountryRank: 7, PreviousCountryRank: 6, CountryRankChange: -1,
+			UnsubscribeURL: APIURL("/digest/unsubscribe?token=preview"),
+		}, true
+	}
+	return nil, false
+}
@@ -2,36 +2,156 @@ package middleware
 
 import (
 	"backend/services/auth"
+	"backend/types"
 	"backend/utils"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
-func JWTMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow CORS preflight requests to pass without JWT
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("missing token"))
-			return
-		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+// scopedHandler marks a route as reachable by personal access tokens
+// holding the given scope
+type scopedHandler struct {
+	scope string
+	next  http.Handler
+}
 
-		token, err := auth.VerifyJWT(tokenString)
+func (s scopedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.next.ServeHTTP(w, r)
+}
 
-		if err != nil || !token.Valid {
-			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
-			return
-		}
+// RequireScope declares the scope a personal access token needs to call the route.
+// Routes that are not wrapped can only be called with a login JWT.
+func RequireScope(scope string, next http.HandlerFunc) http.Handler {
+	return scopedHandler{scope: scope, next: next}
+}
 
-		next.ServeHTTP(w, r)
-	})
+func JWTMiddleware(tokens types.AccessTokenRepo, sessions types.SessionRepo) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Allow CORS preflight requests to pass without JWT
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("missing token"))
+				return
+			}
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+			if auth.IsAccessToken(tokenString) {
+				principal, err := verifyAccessToken(tokens, tokenString)
+				if err != nil {
+					utils.WriteError(w, http.StatusUnauthorized, err)
+					return
+				}
+				if !routeAllowsScopes(r, principal) {
+					utils.WriteError(w, http.StatusForbidden, fmt.Errorf("token does not have the required scope"))
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			token, err := auth.VerifyJWT(tokenString)
+
+			if err != nil || !token.Valid {
+				utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+				return
+			}
+			claims := token.Claims.(*auth.Claims)
+			principal := &auth.Principal{
+				UserID:    claims.UserID,
+				Username:  claims.Username,
+				Role:      claims.Role,
+				SessionID: claims.SessionID,
+			}
+			session, err := checkSession(sessions, claims)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, err)
+				return
+			}
+			// the role of the claims is the one at login
+			principal.Role = session.Role
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
 }
+
+func verifyAccessToken(tokens types.AccessTokenRepo, tokenString string) (*auth.Principal, error) {
+	token, err := tokens.GetAccessTokenByHash(auth.HashAccessToken(tokenString))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if err := tokens.TouchAccessToken(token.Id); err != nil {
+		return nil, err
+	}
+	return &auth.Principal{
+		UserID:        token.UserId,
+		Username:      token.Username,
+		Role:          token.Role,
+		AccessTokenID: token.Id,
+		Scopes:        token.Scopes,
+	}, nil
+}
+
+// checkSession rejects tokens whose session was signed out or whose user was
+// deleted or anonymized, and tokens without a session, which cannot be revoked
+func checkSession(sessions types.SessionRepo, claims *auth.Claims) (*types.Session, error) {
+	if claims.SessionID == 0 {
+		return nil, fmt.Errorf("session has been signed out")
+	}
+	session, err := sessions.GetSession(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserId != claims.UserID || session.RevokedAt != nil {
+		return nil, fmt.Errorf("session has been signed out")
+	}
+	return session, sessions.TouchSession(session.Id)
+}
+
+func routeAllowsScopes(r *http.Request, principal *auth.Principal) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	scoped, ok := route.GetHandler().(scopedHandler)
+	return ok && principal.HasScope(scoped.scope)
+}
+
+// RequireRole only lets principals with one of the given roles through.
+// It must run after JWTMiddleware.
+func RequireRole(roles ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := auth.CurrentPrincipal(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusUnauthorized, err)
+				return
+			}
+			if !slices.Contains(roles, principal.Role) {
+				utils.WriteError(w, http.StatusForbidden, fmt.Errorf("insufficient role"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*") // allow all origins
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/services/auth"
+	"backend/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// memorySessionRepo answers GetSession like SessionRepoImpl: nil for unknown
+// sessions and for the sessions of anonymized users
+type memorySessionRepo struct {
+	types.SessionRepo
+	sessions map[int]*types.Session
+}
+
+func (m *memorySessionRepo) GetSession(id int) (*types.Session, error) {
+	return m.sessions[id], nil
+}
+
+func (m *memorySessionRepo) TouchSession(id int) error {
+	return nil
+}
+
+func TestJWTMiddlewareChecksTheSession(t *testing.T) {
+	saved := config.Envs
+	defer func() { config.Envs = saved }()
+	config.Envs.JWTAlgorithm = "HS256"
+	config.Envs.JWTSecret = "test-secret"
+	config.Envs.JWTExpirationInSeconds = 3600
+	if err := auth.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := time.Now()
+	sessions := &memorySessionRepo{sessions: map[int]*types.Session{
+		1: {Id: 1, UserId: 7, Role: auth.RoleModerator},
+		2: {Id: 2, UserId: 7, Role: auth.RoleUser, RevokedAt: &revoked},
+		3: {Id: 3, UserId: 8, Role: auth.RoleUser},
+	}}
+	var role string
+	handler := JWTMiddleware(nil, sessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.CurrentPrincipal(r)
+		role = principal.Role
+	}))
+
+	tests := []struct {
+		name      string
+		sessionID int
+		want      int
+	}{
+		{"active session", 1, http.StatusOK},
+		{"no session", 0, http.StatusUnauthorized},
+		{"revoked session", 2, http.StatusUnauthorized},
+		{"session of another user", 3, http.StatusUnauthorized},
+		{"deleted or anonymized user", 4, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.CreateToken(7, "alice", auth.RoleUser, tt.sessionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+	// the role comes from the session, not from the claims
+	if role != auth.RoleModerator {
+		t.Errorf("got role %q, want %q", role, auth.RoleModerator)
+	}
+}
+
+// memoryAccessTokenRepo answers GetAccessTokenByHash like AccessTokenRepoImpl:
+// nil for unknown hashes
+type memoryAccessTokenRepo struct {
+	types.AccessTokenRepo
+	tokens map[string]*types.AccessToken
+}
+
+func (m *memoryAccessTokenRepo) GetAccessTokenByHash(hash string) (*types.AccessToken, error) {
+	for plain, token := range m.tokens {
+		if auth.HashAccessToken(plain) == hash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryAccessTokenRepo) TouchAccessToken(id int) error {
+	return nil
+}
+
+func TestRequireScope(t *testing.T) {
+	saved := config.Envs
+	defer func() { config.Envs = saved }()
+	config.Envs.JWTAlgorithm = "HS256"
+	config.Envs.JWTSecret = "test-secret"
+	config.Envs.JWTExpirationInSeconds = 3600
+	if err := auth.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tokens := &memoryAccessTokenRepo{tokens: map[string]*types.AccessToken{
+		"xpat_writer":  {Id: 1, UserId: 7, Role: auth.RoleUser, Scopes: []string{auth.ScopePomodorosWrite}},
+		"xpat_reader":  {Id: 2, UserId: 7, Role: auth.RoleUser, Scopes: []string{auth.ScopeStatsRead, auth.ScopeRankingRead}, ExpiresAt: &future},
+		"xpat_revoked": {Id: 3, UserId: 7, Role: auth.RoleUser, Scopes: []string{auth.ScopeStatsRead}, RevokedAt: &past},
+		"xpat_expired": {Id: 4, UserId: 7, Role: auth.RoleUser, Scopes: []string{auth.ScopeStatsRead}, ExpiresAt: &past},
+	}}
+	sessions := &memorySessionRepo{sessions: map[int]*types.Session{1: {Id: 1, UserId: 7, Role: auth.RoleUser}}}
+	jwt, err := auth.CreateToken(7, "alice", auth.RoleUser, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router := mux.NewRouter()
+	router.Use(JWTMiddleware(tokens, sessions))
+	router.Handle("/pomodoro", RequireScope(auth.ScopePomodorosWrite, ok)).Methods(http.MethodPost)
+	router.Handle("/stats", RequireScope(auth.ScopeStatsRead, ok)).Methods(http.MethodGet)
+	router.HandleFunc("/me", ok).Methods(http.MethodGet)
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(RequireRole(auth.RoleAdmin))
+	admin.HandleFunc("/users", ok).Methods(http.MethodGet)
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		want   int
+	}{
+		{"scope granted", "xpat_writer", http.MethodPost, "/pomodoro", http.StatusOK},
+		{"scope missing", "xpat_writer", http.MethodGet, "/stats", http.StatusForbidden},
+		{"one of several scopes", "xpat_reader", http.MethodGet, "/stats", http.StatusOK},
+		{"other scopes only", "xpat_reader", http.MethodPost, "/pomodoro", http.StatusForbidden},
+		{"route without scope", "xpat_reader", http.MethodGet, "/me", http.StatusForbidden},
+		{"revoked token", "xpat_revoked", http.MethodGet, "/stats", http.StatusUnauthorized},
+		{"expired token", "xpat_expired", http.MethodGet, "/stats", http.StatusUnauthorized},
+		{"unknown token", "xpat_unknown", http.MethodGet, "/stats", http.StatusUnauthorized},
+		{"login JWT on a scoped route", jwt, http.MethodPost, "/pomodoro", http.StatusOK},
+		{"login JWT on a route without scope", jwt, http.MethodGet, "/me", http.StatusOK},
+		{"role still required", jwt, http.MethodGet, "/admin/users", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
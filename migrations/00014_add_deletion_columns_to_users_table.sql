@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN deletion_scheduled_at DATETIME NULL,
+    ADD COLUMN anonymized_at DATETIME NULL;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN deletion_scheduled_at,
+    DROP COLUMN anonymized_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN username_changed_at DATETIME NULL;
+
+CREATE TABLE IF NOT EXISTS username_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    old_username VARCHAR(255) NOT NULL,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    held_until DATETIME NOT NULL,
+    CONSTRAINT fk_username_history_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    KEY idx_username_history_name (old_username, held_until)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS username_history;
+ALTER TABLE users DROP COLUMN username_changed_at;
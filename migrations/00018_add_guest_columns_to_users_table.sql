@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN is_guest BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN guest_token_hash CHAR(64) NULL UNIQUE;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN is_guest,
+    DROP COLUMN guest_token_hash;
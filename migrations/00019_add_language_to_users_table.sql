@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT 'en';
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN language;
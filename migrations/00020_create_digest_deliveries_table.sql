@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN digest_opt_in BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN digest_unsubscribe_token CHAR(64) NULL UNIQUE;
+
+-- one row per weekly digest sent, the ranks are compared with the next week's
+CREATE TABLE IF NOT EXISTS digest_deliveries (
+    user_id INT NOT NULL,
+    week_start DATE NOT NULL,
+    xp INT NOT NULL,
+    global_rank INT NULL,
+    country_rank INT NULL,
+    sent_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, week_start),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS digest_deliveries;
+ALTER TABLE users
+    DROP COLUMN digest_opt_in,
+    DROP COLUMN digest_unsubscribe_token;
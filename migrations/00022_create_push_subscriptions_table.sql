@@ -0,0 +1,42 @@
+-- +goose Up
+-- endpoints can be long, uniqueness is enforced on their SHA-256
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    endpoint TEXT NOT NULL,
+    endpoint_hash CHAR(64) NOT NULL UNIQUE,
+    p256dh VARCHAR(255) NOT NULL,
+    auth VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    expires_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_success_at DATETIME NULL,
+    KEY idx_push_subscriptions_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- one pending timer per user and kind, deleted once sent
+CREATE TABLE IF NOT EXISTS scheduled_pushes (
+    user_id INT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body VARCHAR(1024) NOT NULL,
+    send_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, kind),
+    KEY idx_scheduled_pushes_send_at (send_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- reminders already sent, at most one per user, kind and day
+CREATE TABLE IF NOT EXISTS push_reminders (
+    user_id INT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    day DATE NOT NULL,
+    PRIMARY KEY (user_id, kind, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS push_reminders;
+DROP TABLE IF EXISTS scheduled_pushes;
+DROP TABLE IF EXISTS push_subscriptions;
@@ -0,0 +1,42 @@
+-- +goose Up
+-- every XP change, users.xp and users.rank_id are a cached projection of it
+CREATE TABLE IF NOT EXISTS xp_transactions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    delta INT NOT NULL,
+    reason VARCHAR(64) NOT NULL,
+    source_type VARCHAR(32) NULL,
+    source_id BIGINT NULL,
+    multiplier DECIMAL(8,4) NOT NULL DEFAULT 1,
+    balance_after INT NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    KEY idx_xp_transactions_user (user_id, id),
+    -- a source is rewarded (or reversed) at most once
+    UNIQUE KEY uniq_xp_transactions_source (source_type, source_id, reason),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- the XP earned before the ledger existed
+INSERT INTO xp_transactions (user_id, delta, reason, balance_after, created_at)
+SELECT id, xp, 'opening_balance', xp, NOW() FROM users WHERE xp > 0;
+
+-- +goose StatementBegin
+CREATE TRIGGER xp_transactions_no_update BEFORE UPDATE ON xp_transactions FOR EACH ROW
+BEGIN
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'xp_transactions is append-only';
+END;
+-- +goose StatementEnd
+
+-- foreign key cascades do not fire triggers, deleting a user still removes its ledger
+-- +goose StatementBegin
+CREATE TRIGGER xp_transactions_no_delete BEFORE DELETE ON xp_transactions FOR EACH ROW
+BEGIN
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'xp_transactions is append-only';
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS xp_transactions_no_delete;
+DROP TRIGGER IF EXISTS xp_transactions_no_update;
+DROP TABLE IF EXISTS xp_transactions;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- ranks are grouped by tier (Wood, Bronze, ...), thresholds must be distinct
+ALTER TABLE ranks ADD COLUMN tier VARCHAR(64) NOT NULL DEFAULT '';
+UPDATE ranks SET tier = SUBSTRING_INDEX(name, ' ', 1);
+ALTER TABLE ranks ADD UNIQUE KEY uniq_ranks_min_xp (min_xp);
+
+-- +goose Down
+ALTER TABLE ranks DROP INDEX uniq_ranks_min_xp;
+ALTER TABLE ranks DROP COLUMN tier;
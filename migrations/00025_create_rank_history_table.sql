@@ -0,0 +1,23 @@
+-- +goose Up
+-- every rank change, names are kept since admins can rename or delete ranks
+CREATE TABLE IF NOT EXISTS rank_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    from_rank_id INT NULL,
+    from_rank_name VARCHAR(255) NULL,
+    to_rank_id INT NOT NULL,
+    to_rank_name VARCHAR(255) NOT NULL,
+    direction VARCHAR(8) NOT NULL,
+    xp INT NOT NULL,
+    xp_transaction_id BIGINT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_rank_history_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- the starting point of each progression chart
+INSERT INTO rank_history (user_id, to_rank_id, to_rank_name, direction, xp, created_at)
+SELECT u.id, r.id, r.name, 'up', u.xp, NOW() FROM users u JOIN ranks r ON r.id = u.rank_id;
+
+-- +goose Down
+DROP TABLE IF EXISTS rank_history;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- achievements are defined in code, a user unlocks each one at most once
+CREATE TABLE IF NOT EXISTS user_achievements (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code VARCHAR(64) NOT NULL,
+    xp_reward INT NOT NULL DEFAULT 0,
+    unlocked_at DATETIME NOT NULL,
+    UNIQUE KEY uniq_user_achievements (user_id, code),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS user_achievements;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- quests are defined in code and assigned to each user per day or week
+CREATE TABLE IF NOT EXISTS user_quests (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code VARCHAR(64) NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    period_start DATE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    progress INT NOT NULL DEFAULT 0,
+    target INT NOT NULL,
+    xp_reward INT NOT NULL,
+    completed_at DATETIME NULL,
+    claimed_at DATETIME NULL,
+    UNIQUE KEY uniq_user_quests (user_id, code, period_start),
+    KEY idx_user_quests_active (user_id, expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS user_quests;
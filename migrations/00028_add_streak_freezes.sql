@@ -0,0 +1,39 @@
+-- +goose Up
+-- the streak engine counts days in UTC, last_streak_day is the last day
+-- kept by a focus session, a freeze or a repair
+ALTER TABLE stats
+    ADD COLUMN last_streak_day DATE NULL,
+    ADD COLUMN streak_freezes INT NOT NULL DEFAULT 0,
+    ADD COLUMN broken_streak INT NOT NULL DEFAULT 0,
+    ADD COLUMN broken_day DATE NULL,
+    ADD COLUMN broken_at DATETIME NULL;
+
+-- running streaks continue from the last completed focus session
+UPDATE stats s SET s.last_streak_day = (
+    SELECT DATE(MAX(p.end_time)) FROM pomodoros p
+    WHERE p.user_id = s.user_id AND p.type = 'pomodoro' AND p.completed = TRUE
+) WHERE s.current_streak > 0;
+
+-- freezes earned, bought and used, and repairs
+CREATE TABLE IF NOT EXISTS streak_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    day DATE NULL,
+    streak INT NOT NULL,
+    xp_cost INT NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    KEY idx_streak_events_user (user_id, id),
+    -- a day is frozen or repaired once, a freeze is earned once per day
+    UNIQUE KEY uniq_streak_events_day (user_id, kind, day),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS streak_events;
+ALTER TABLE stats
+    DROP COLUMN broken_at,
+    DROP COLUMN broken_day,
+    DROP COLUMN broken_streak,
+    DROP COLUMN streak_freezes,
+    DROP COLUMN last_streak_day;
@@ -0,0 +1,46 @@
+-- +goose Up
+-- seasons are calendar months (UTC), closed once their results are saved
+CREATE TABLE IF NOT EXISTS seasons (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    starts_at DATETIME NOT NULL UNIQUE,
+    ends_at DATETIME NOT NULL,
+    closed_at DATETIME NULL,
+    KEY idx_seasons_open (closed_at, ends_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- XP earned during a season, kept with users.xp by the XP ledger
+CREATE TABLE IF NOT EXISTS season_xp (
+    season_id INT NOT NULL,
+    user_id INT NOT NULL,
+    xp INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (season_id, user_id),
+    KEY idx_season_xp_ranking (season_id, xp),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- final placements, the username and country are the ones at the end of the season
+CREATE TABLE IF NOT EXISTS season_results (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    season_id INT NOT NULL,
+    user_id INT NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    country VARCHAR(50) NULL,
+    xp INT NOT NULL,
+    global_placement INT NOT NULL,
+    country_placement INT NULL,
+    reward_xp INT NOT NULL DEFAULT 0,
+    rewarded_at DATETIME NULL,
+    UNIQUE KEY uniq_season_results (season_id, user_id),
+    KEY idx_season_results_global (season_id, global_placement),
+    KEY idx_season_results_country (season_id, country, country_placement),
+    KEY idx_season_results_user (user_id, season_id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS season_results;
+DROP TABLE IF EXISTS season_xp;
+DROP TABLE IF EXISTS seasons;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- league weeks start on Monday (UTC), closed once the rollover is done
+CREATE TABLE IF NOT EXISTS league_weeks (
+    week_start DATE PRIMARY KEY,
+    closed_at DATETIME NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS league_cohorts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    week_start DATE NOT NULL,
+    tier INT NOT NULL,
+    KEY idx_league_cohorts_week (week_start, tier),
+    FOREIGN KEY (week_start) REFERENCES league_weeks(week_start) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- weekly XP is read from the XP ledger, the final standing is saved at rollover
+CREATE TABLE IF NOT EXISTS league_members (
+    week_start DATE NOT NULL,
+    user_id INT NOT NULL,
+    cohort_id INT NOT NULL,
+    tier INT NOT NULL,
+    joined_at DATETIME NOT NULL,
+    weekly_xp INT NULL,
+    placement INT NULL,
+    outcome VARCHAR(16) NULL,
+    PRIMARY KEY (week_start, user_id),
+    KEY idx_league_members_cohort (cohort_id),
+    KEY idx_league_members_user (user_id, week_start),
+    FOREIGN KEY (cohort_id) REFERENCES league_cohorts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS league_members;
+DROP TABLE IF EXISTS league_cohorts;
+DROP TABLE IF EXISTS league_weeks;
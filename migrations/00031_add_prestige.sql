@@ -0,0 +1,30 @@
+-- +goose Up
+-- users at the top rank can prestige: xp starts again from zero, the prestige
+-- level goes up for good and lifetime_xp keeps counting across prestiges
+ALTER TABLE users
+    ADD COLUMN prestige INT NOT NULL DEFAULT 0,
+    ADD COLUMN lifetime_xp INT NOT NULL DEFAULT 0,
+    ADD KEY idx_users_prestige_xp (prestige, xp);
+
+UPDATE users SET lifetime_xp = xp;
+
+-- every prestige, with the rank and XP the user left
+CREATE TABLE IF NOT EXISTS prestige_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    level INT NOT NULL,
+    from_rank_id INT NOT NULL,
+    from_rank_name VARCHAR(255) NOT NULL,
+    xp INT NOT NULL,
+    lifetime_xp INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uniq_prestige_history_level (user_id, level),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+DROP TABLE IF EXISTS prestige_history;
+ALTER TABLE users
+    DROP KEY idx_users_prestige_xp,
+    DROP COLUMN lifetime_xp,
+    DROP COLUMN prestige;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- new events hash the SHA-256 of their IP, user agent and metadata instead of
+-- those fields, so they can be redacted for a deleted or anonymized user
+-- without breaking the chain. Events recorded before have an empty
+-- personal_data_hash.
+ALTER TABLE audit_events
+    ADD COLUMN personal_data_hash CHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN redacted_at DATETIME(6) NULL;
+
+DROP TRIGGER IF EXISTS audit_events_no_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events FOR EACH ROW
+BEGIN
+    -- the only update allowed is emptying the IP, user agent and metadata once
+    IF NOT (OLD.redacted_at IS NULL AND NEW.redacted_at IS NOT NULL
+        AND NEW.ip = '' AND NEW.user_agent = '' AND NEW.metadata = '{}'
+        AND NEW.id = OLD.id AND NEW.event_type = OLD.event_type
+        AND NEW.actor_id <=> OLD.actor_id AND NEW.target_id <=> OLD.target_id
+        AND NEW.personal_data_hash = OLD.personal_data_hash AND NEW.created_at = OLD.created_at
+        AND NEW.prev_hash = OLD.prev_hash AND NEW.hash = OLD.hash) THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+    END IF;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_events_no_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events FOR EACH ROW
+BEGIN
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+END;
+-- +goose StatementEnd
+
+ALTER TABLE audit_events
+    DROP COLUMN redacted_at,
+    DROP COLUMN personal_data_hash;
@@ -0,0 +1,46 @@
+package achievements
+
+import "backend/types"
+
+// Catalogue lists every achievement, codes are stored in user_achievements
+// and must never change. Rewards are granted once, through the XP ledger.
+var Catalogue = []types.Achievement{
+	{Code: "first_pomodoro", Name: "First pomodoro", Description: "Complete your first focus session", Metric: types.AchievementMetricPomodoros, Target: 1, XPReward: 10},
+	{Code: "pomodoros_100", Name: "Centurion", Description: "Complete 100 focus sessions", Metric: types.AchievementMetricPomodoros, Target: 100, XPReward: 100},
+	{Code: "pomodoros_1000", Name: "Deep focus", Description: "Complete 1000 focus sessions", Metric: types.AchievementMetricPomodoros, Target: 1000, XPReward: 500},
+	{Code: "streak_7", Name: "One week streak", Description: "Keep a 7-day streak", Metric: types.AchievementMetricStreak, Target: 7, XPReward: 50},
+	{Code: "streak_30", Name: "One month streak", Description: "Keep a 30-day streak", Metric: types.AchievementMetricStreak, Target: 30, XPReward: 200},
+	{Code: "early_bird", Name: "Early bird", Description: "Complete 4 focus sessions before 9am on the same day", Metric: types.AchievementMetricEarlyPomodoros, Target: 4, XPReward: 50},
+	{Code: "gold_reached", Name: "Gold", Description: "Reach the Gold tier", Metric: types.AchievementMetricTier, Tier: "Gold", XPReward: 100},
+	{Code: "master_reached", Name: "Master", Description: "Reach the Master tier", Metric: types.AchievementMetricTier, Tier: "Master", XPReward: 500},
+}
+
+// Find returns the achievement with code, nil if there is none
+func Find(code string) *types.Achievement {
+	for i := range Catalogue {
+		if Catalogue[i].Code == code {
+			return &Catalogue[i]
+		}
+	}
+	return nil
+}
+
+// measure returns where the metrics stand against the achievement and its
+// goal, 0 when the goal is unknown (a tier missing from the rank catalogue)
+func measure(a types.Achievement, m *types.AchievementMetrics, ranks []types.Rank) (current int, goal int) {
+	switch a.Metric {
+	case types.AchievementMetricPomodoros:
+		return m.Pomodoros, a.Target
+	case types.AchievementMetricStreak:
+		return m.Streak, a.Target
+	case types.AchievementMetricEarlyPomodoros:
+		return m.EarlyPomodoros, a.Target
+	case types.AchievementMetricTier:
+		for _, rank := range ranks {
+			if rank.Tier == a.Tier {
+				return m.XP, rank.MinXP
+			}
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,144 @@
+package achievements
+
+import (
+	"backend/services/notifications"
+	"backend/types"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Evaluator unlocks achievements and grants their rewards
+type Evaluator struct {
+	store    types.AchievementRepo
+	ranks    types.RankRepo
+	ledger   types.XPLedger
+	notifier types.Notifier
+}
+
+func NewEvaluator(store types.AchievementRepo, rankRepo types.RankRepo, ledger types.XPLedger, notifier types.Notifier) *Evaluator {
+	return &Evaluator{store: store, ranks: rankRepo, ledger: ledger, notifier: notifier}
+}
+
+// Evaluate unlocks every achievement the user reached and does not have yet,
+// except the streak ones which only the streak engine unlocks with
+// EvaluateStreak. It is safe to call it again for the same event: unlocks and
+// rewards are recorded at most once.
+func (e *Evaluator) Evaluate(userID int, at time.Time) ([]types.UnlockedAchievement, error) {
+	progress, err := e.Progress(userID)
+	if err != nil {
+		return nil, err
+	}
+	reached := make([]types.Achievement, 0)
+	for _, p := range progress {
+		if p.Metric == types.AchievementMetricStreak || p.Goal == 0 || p.Current < p.Goal {
+			continue
+		}
+		reached = append(reached, p.Achievement)
+	}
+	return e.unlock(userID, reached, at)
+}
+
+// EvaluateStreak unlocks the streak achievements of a streak the streak
+// engine just reached
+func (e *Evaluator) EvaluateStreak(userID int, streak int, at time.Time) ([]types.UnlockedAchievement, error) {
+	owned, err := e.store.ListUnlocked(userID)
+	if err != nil {
+		return nil, err
+	}
+	has := make(map[string]bool, len(owned))
+	for _, a := range owned {
+		has[a.Code] = true
+	}
+	reached := make([]types.Achievement, 0)
+	for _, a := range Catalogue {
+		if a.Metric == types.AchievementMetricStreak && !has[a.Code] && streak >= a.Target {
+			reached = append(reached, a)
+		}
+	}
+	return e.unlock(userID, reached, at)
+}
+
+// unlock records the achievements, rewards and announces the new ones
+func (e *Evaluator) unlock(userID int, reached []types.Achievement, at time.Time) ([]types.UnlockedAchievement, error) {
+	unlocked := make([]types.UnlockedAchievement, 0)
+	for _, p := range reached {
+		a := types.UnlockedAchievement{
+			UserId:      userID,
+			Code:        p.Code,
+			Name:        p.Name,
+			Description: p.Description,
+			XPReward:    p.XPReward,
+			UnlockedAt:  at,
+		}
+		created, err := e.store.Unlock(&a)
+		if err != nil {
+			return unlocked, err
+		}
+		if !created {
+			continue
+		}
+		e.reward(&a)
+		notifications.Send(e.notifier, userID, types.NotificationAchievement,
+			fmt.Sprintf("Achievement unlocked: %s", a.Name), a.Description,
+			map[string]any{"code": a.Code, "xp_reward": a.XPReward},
+		)
+		unlocked = append(unlocked, a)
+	}
+	return unlocked, nil
+}
+
+// Progress returns the achievements the user has not unlocked yet, with how
+// far the user is from each
+func (e *Evaluator) Progress(userID int) ([]types.AchievementProgress, error) {
+	metrics, err := e.store.GetMetrics(userID)
+	if err != nil {
+		return nil, err
+	}
+	ranks, err := e.ranks.ListRanks()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := e.store.ListUnlocked(userID)
+	if err != nil {
+		return nil, err
+	}
+	has := make(map[string]bool, len(owned))
+	for _, a := range owned {
+		has[a.Code] = true
+	}
+	list := make([]types.AchievementProgress, 0)
+	for _, a := range Catalogue {
+		if has[a.Code] {
+			continue
+		}
+		current, goal := measure(a, metrics, ranks)
+		p := types.AchievementProgress{Achievement: a, Current: current, Goal: goal}
+		if goal > 0 {
+			p.Percent = min(current*100/goal, 100)
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// reward credits the XP of an achievement, failures are only logged since
+// the unlock is already recorded
+func (e *Evaluator) reward(a *types.UnlockedAchievement) {
+	if a.XPReward <= 0 {
+		return
+	}
+	source := types.XPSourceAchievement
+	t := &types.XPTransaction{
+		UserId:     a.UserId,
+		Delta:      a.XPReward,
+		Reason:     types.XPReasonAchievement,
+		SourceType: &source,
+		SourceId:   &a.Id,
+		Note:       a.Name,
+		CreatedAt:  a.UnlockedAt,
+	}
+	if _, err := e.ledger.Record(t); err != nil {
+		log.Printf("failed to reward achievement %s of user %d: %v", a.Code, a.UserId, err)
+	}
+}
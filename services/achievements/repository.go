@@ -0,0 +1,98 @@
+package achievements
+
+import (
+	"backend/types"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type AchievementRepoImpl struct {
+	db *sql.DB
+}
+
+func NewAchievementRepoImpl(db *sql.DB) *AchievementRepoImpl {
+	return &AchievementRepoImpl{db: db}
+}
+
+// ListUnlocked returns the unlocked achievements, newest first, named from the
+// catalogue. Codes no longer in the catalogue are skipped.
+func (a *AchievementRepoImpl) ListUnlocked(userID int) ([]types.UnlockedAchievement, error) {
+	rows, err := a.db.Query(
+		"SELECT id, user_id, code, xp_reward, unlocked_at FROM user_achievements WHERE user_id = ? ORDER BY unlocked_at DESC, id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.UnlockedAchievement, 0)
+	for rows.Next() {
+		var u types.UnlockedAchievement
+		if err := rows.Scan(&u.Id, &u.UserId, &u.Code, &u.XPReward, &u.UnlockedAt); err != nil {
+			return nil, err
+		}
+		achievement := Find(u.Code)
+		if achievement == nil {
+			continue
+		}
+		u.Name = achievement.Name
+		u.Description = achievement.Description
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (a *AchievementRepoImpl) Unlock(u *types.UnlockedAchievement) (bool, error) {
+	res, err := a.db.Exec(
+		"INSERT INTO user_achievements(user_id, code, xp_reward, unlocked_at) VALUES (?,?,?,?)",
+		u.UserId, u.Code, u.XPReward, u.UnlockedAt,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return false, nil
+		}
+		return false, err
+	}
+	if u.Id, err = res.LastInsertId(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *AchievementRepoImpl) GetMetrics(userID int) (*types.AchievementMetrics, error) {
+	var m types.AchievementMetrics
+	err := a.db.QueryRow("SELECT xp FROM users WHERE id = ?", userID).Scan(&m.XP)
+	if err != nil {
+		return nil, err
+	}
+	err = a.db.QueryRow(
+		"SELECT COUNT(*) FROM pomodoros WHERE user_id = ? AND type = 'pomodoro' AND completed = TRUE",
+		userID,
+	).Scan(&m.Pomodoros)
+	if err != nil {
+		return nil, err
+	}
+	// the streak column is nullable and the row may not exist yet
+	err = a.db.QueryRow(
+		"SELECT COALESCE(current_streak, 0) FROM stats WHERE user_id = ?",
+		userID,
+	).Scan(&m.Streak)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	err = a.db.QueryRow(`
+		SELECT COALESCE(MAX(sessions), 0) FROM (
+			SELECT COUNT(*) AS sessions FROM pomodoros
+			WHERE user_id = ? AND type = 'pomodoro' AND completed = TRUE AND end_time IS NOT NULL AND TIME(end_time) < '09:00:00'
+			GROUP BY DATE(end_time)
+		) days`,
+		userID,
+	).Scan(&m.EarlyPomodoros)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
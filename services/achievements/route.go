@@ -0,0 +1,87 @@
+package achievements
+
+import (
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store     types.AchievementRepo
+	evaluator *Evaluator
+}
+
+func NewHandler(store types.AchievementRepo, evaluator *Evaluator) *Handler {
+	return &Handler{store: store, evaluator: evaluator}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
+	router.HandleFunc("/achievements", h.HandleListAchievements).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/achievements", h.HandleListUnlocked).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/achievements/progress", h.HandleListProgress).Methods(http.MethodGet)
+}
+
+// HandleListAchievements godoc
+//
+// @Summary 			List the achievements
+// @Description 		Every achievement that can be unlocked, with what it counts, its target and its XP reward. Tier achievements are reached at the first rank of the tier.
+// @Tags 				Achievements
+// @Produce 			json
+// @Success 			200 {array} types.Achievement
+// @Router 				/achievements [get]
+func (h *Handler) HandleListAchievements(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, Catalogue)
+}
+
+// HandleListUnlocked godoc
+//
+// @Summary 			List my badges
+// @Description 		The achievements unlocked by the authenticated user, newest first
+// @Tags 				Achievements
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.UnlockedAchievement
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/achievements [get]
+func (h *Handler) HandleListUnlocked(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.store.ListUnlocked(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleListProgress godoc
+//
+// @Summary 			List my progress toward locked achievements
+// @Description 		The achievements the authenticated user has not unlocked yet, with the current value, the goal and the percent progress
+// @Tags 				Achievements
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.AchievementProgress
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/achievements/progress [get]
+func (h *Handler) HandleListProgress(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.evaluator.Progress(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
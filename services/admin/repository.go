@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AdminRepoImpl struct {
+	db *sql.DB
+}
+
+func NewAdminRepoImpl(db *sql.DB) *AdminRepoImpl {
+	return &AdminRepoImpl{db: db}
+}
+
+const userSummaryColumns = "id, username, email, country, xp, rank_id, role, is_guest, created_at, prestige, lifetime_xp"
+
+func (a *AdminRepoImpl) ListUsers(limit, offset int, search string) ([]types.UserSummary, error) {
+	rows, err := a.db.Query(
+		"SELECT "+userSummaryColumns+" FROM users WHERE username LIKE ? ORDER BY id LIMIT ? OFFSET ?",
+		"%"+search+"%", limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.UserSummary, 0)
+	for rows.Next() {
+		var u types.UserSummary
+		if err := scanRowIntoUserSummary(rows, &u); err != nil {
+			return nil, err
+		}
+		list = append(list, u)
+	}
+	return list, rows.Err()
+}
+
+func (a *AdminRepoImpl) GetUser(id int) (*types.UserSummary, error) {
+	var u types.UserSummary
+	row := a.db.QueryRow("SELECT "+userSummaryColumns+" FROM users WHERE id = ?", id)
+	if err := scanRowIntoUserSummary(row, &u); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ErrLastAdmin is returned when a role change would leave no admin
+var ErrLastAdmin = errors.New("the last admin cannot be demoted")
+
+func (a *AdminRepoImpl) SetUserRole(id int, role string) (*types.UserSummary, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	// the admin rows are locked so two admins cannot demote each other at once
+	rows, err := tx.Query("SELECT id FROM users WHERE role = ? FOR UPDATE", auth.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	admins := make([]int, 0)
+	for rows.Next() {
+		var adminID int
+		if err := rows.Scan(&adminID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		admins = append(admins, adminID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if role != auth.RoleAdmin && len(admins) == 1 && admins[0] == id {
+		return nil, ErrLastAdmin
+	}
+	if _, err := tx.Exec("UPDATE users SET role = ? WHERE id = ?", role, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return a.GetUser(id)
+}
+
+func (a *AdminRepoImpl) SetUserCountry(id int, country string) (*types.UserSummary, error) {
+	if _, err := a.db.Exec("UPDATE users SET country = ? WHERE id = ?", country, id); err != nil {
+		return nil, err
+	}
+	return a.GetUser(id)
+}
+
+func (a *AdminRepoImpl) ListUserPomodoros(userID int) ([]types.Pomodoro, error) {
+	rows, err := a.db.Query(`
+		SELECT id, user_id, type, completed, session_duration, start_time, end_time, created_at
+		FROM pomodoros WHERE user_id = ? ORDER BY start_time DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.Pomodoro, 0)
+	for rows.Next() {
+		var p types.Pomodoro
+		var endTime sql.NullTime
+		err := rows.Scan(&p.Id, &p.UserId, &p.Type, &p.Completed, &p.SessionDuration, &p.StartTime, &endTime, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		p.EndTime = endTime.Time
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// DeletePomodoro deletes the session and takes a completed focus session
+// out of the heatmap
+func (a *AdminRepoImpl) DeletePomodoro(id int) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var userID int
+	var kind string
+	var completed bool
+	var end sql.NullTime
+	err = tx.QueryRow("SELECT user_id, type, completed, end_time FROM pomodoros WHERE id = ? FOR UPDATE", id).Scan(&userID, &kind, &completed, &end)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("pomodoro not found")
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pomodoros WHERE id = ?", id); err != nil {
+		return err
+	}
+	if kind == "pomodoro" && completed && end.Valid {
+		_, err := tx.Exec(
+			"UPDATE heatmap SET count = GREATEST(count - 1, 0) WHERE user_id = ? AND date = ?",
+			userID, end.Time.UTC().Format(time.DateOnly),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AnonymizeUser strips every personal detail from an account but keeps its
+// pomodoros and stats so aggregate numbers stay correct. The account can no
+// longer log in and is hidden from the rankings.
+func (a *AdminRepoImpl) AnonymizeUser(id int) (*types.UserSummary, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`
+		UPDATE users SET
+			username = CONCAT('deleted-user-', id),
+			email = NULL,
+			country = NULL,
+			password_hash = '!',
+			guest_token_hash = NULL,
+			deletion_scheduled_at = NULL,
+			anonymized_at = ?
+		WHERE id = ?`,
+		time.Now(), id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+	for _, table := range []string{"sessions", "personal_access_tokens", "pending_email_updates", "password_reset_tokens"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+	// the audit log keeps the events but not the old emails and usernames
+	if err := audit.RedactUserEvents(tx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return a.GetUser(id)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoUserSummary(row scanner, u *types.UserSummary) error {
+	return row.Scan(&u.Id, &u.Username, &u.Email, &u.Country, &u.XP, &u.RankId, &u.Role, &u.IsGuest, &u.CreatedAt, &u.Prestige, &u.LifetimeXP)
+}
@@ -0,0 +1,372 @@
+package admin
+
+import (
+	"backend/middleware"
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/services/notifications"
+	"backend/services/ranks"
+	"backend/types"
+	"backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.AdminRepo
+	ledger   types.XPLedger
+	auditLog types.AuditLogger
+	notifier types.Notifier
+	ranks    types.RankRepo
+}
+
+func NewHandler(store types.AdminRepo, ledger types.XPLedger, auditLog types.AuditLogger, notifier types.Notifier, rankRepo types.RankRepo) *Handler {
+	return &Handler{store: store, ledger: ledger, auditLog: auditLog, notifier: notifier, ranks: rankRepo}
+}
+
+// RegisterRoutes mounts the /admin group on the authenticated router.
+// Moderators can look at users and moderate sessions, only admins can
+// change roles and XP.
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	router := authRouter.PathPrefix("/admin").Subrouter()
+	router.Use(middleware.RequireRole(auth.RoleModerator, auth.RoleAdmin))
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+
+	router.HandleFunc("/users", h.HandleListUsers).Methods(http.MethodGet)
+	router.HandleFunc("/users/{id}", h.HandleGetUser).Methods(http.MethodGet)
+	router.Handle("/users/{id}/role", adminOnly(http.HandlerFunc(h.HandleUpdateRole))).Methods(http.MethodPatch)
+	router.HandleFunc("/users/{id}/country", h.HandleUpdateCountry).Methods(http.MethodPatch)
+	router.Handle("/users/{id}/xp", adminOnly(http.HandlerFunc(h.HandleAdjustXP))).Methods(http.MethodPost)
+	router.Handle("/users/{id}/anonymize", adminOnly(http.HandlerFunc(h.HandleAnonymizeUser))).Methods(http.MethodPost)
+	router.HandleFunc("/users/{id}/pomodoros", h.HandleListUserPomodoros).Methods(http.MethodGet)
+	router.HandleFunc("/pomodoros/{id}", h.HandleDeletePomodoro).Methods(http.MethodDelete)
+}
+
+// HandleListUsers godoc
+//
+// @Summary 			List users
+// @Description 		List users, optionally filtered by a username search (moderator or admin)
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				search query string false "Username contains"
+// @Param 				limit query int false "Page size (default 50)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.UserSummary
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users [get]
+func (h *Handler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	list, err := h.store.ListUsers(limit, offset, query.Get("search"))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	users := make([]types.Ranked, len(list))
+	for i := range list {
+		users[i] = &list[i]
+	}
+	if err := ranks.FillProgress(h.ranks, users...); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleGetUser godoc
+//
+// @Summary 			Get a user
+// @Description 		Get a user by id (moderator or admin)
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Success 			200 {object} types.UserSummary
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Router 				/admin/users/{id} [get]
+func (h *Handler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.GetUser(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+// HandleUpdateRole godoc
+//
+// @Summary 			Change a user's role
+// @Description 		Set the role of a user to user, moderator or admin (admin only). Takes effect on the user's next request. The last admin cannot be demoted.
+// @Tags 				Admin
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Param 				request body types.UpdateRolePayload true "Role payload"
+// @Success 			200 {object} types.UserSummary
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users/{id}/role [patch]
+func (h *Handler) HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var payload types.UpdateRolePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !auth.IsValidRole(payload.Role) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown role %q", payload.Role))
+		return
+	}
+	user, err := h.store.SetUserRole(id, payload.Role)
+	if errors.Is(err, ErrLastAdmin) {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminRoleChanged, actorID, id, map[string]any{"role": payload.Role})
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+// HandleUpdateCountry godoc
+//
+// @Summary 			Change a user's country
+// @Description 		Set the country a user is ranked in, e.g. to move them out of a country ranking they do not belong to
+// @Tags 				Admin
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Param 				request body types.UserInfoUpdate true "Country payload"
+// @Success 			200 {object} types.UserSummary
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users/{id}/country [patch]
+func (h *Handler) HandleUpdateCountry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var payload types.UserInfoUpdate
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	before, err := h.store.GetUser(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	user, err := h.store.SetUserCountry(id, payload.Country)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminCountryChanged, actorID, id, map[string]any{"before": before.Country, "country": payload.Country})
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+// HandleAdjustXP godoc
+//
+// @Summary 			Adjust a user's XP
+// @Description 		Add or remove XP from a user through the XP ledger and update their rank accordingly (admin only)
+// @Tags 				Admin
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Param 				request body types.AdjustXPPayload true "XP adjustment"
+// @Success 			200 {object} types.UserSummary
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users/{id}/xp [post]
+func (h *Handler) HandleAdjustXP(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var payload types.AdjustXPPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Delta == 0 || payload.Reason == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("a non zero delta and a reason are required"))
+		return
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	// the actor is in the audit log, admin transactions have no source id so the
+	// same user can be adjusted several times
+	source := types.XPSourceAdmin
+	note := payload.Reason
+	if len(note) > 255 {
+		note = note[:255]
+	}
+	t := &types.XPTransaction{
+		UserId:     id,
+		Delta:      payload.Delta,
+		Reason:     types.XPReasonAdminAdjustment,
+		SourceType: &source,
+		Note:       note,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := h.ledger.Record(t); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	user, err := h.store.GetUser(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditAdminXPAdjusted, actorID, id, map[string]any{"delta": t.Delta, "reason": payload.Reason, "transaction_id": t.Id})
+	notifications.Send(h.notifier, id, types.NotificationSystem,
+		fmt.Sprintf("Your XP was adjusted by %+d", t.Delta), payload.Reason,
+		map[string]any{"delta": t.Delta, "xp": user.XP},
+	)
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+// HandleListUserPomodoros godoc
+//
+// @Summary 			List a user's sessions
+// @Description 		List the pomodoro sessions of a user, newest first (moderator or admin)
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Success 			200 {array} types.Pomodoro
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users/{id}/pomodoros [get]
+func (h *Handler) HandleListUserPomodoros(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	list, err := h.store.ListUserPomodoros(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleDeletePomodoro godoc
+//
+// @Summary 			Delete a session
+// @Description 		Remove a pomodoro session, e.g. a fake or abusive one, and take back the XP it earned or give back its penalty (moderator or admin)
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Pomodoro ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Router 				/admin/pomodoros/{id} [delete]
+func (h *Handler) HandleDeletePomodoro(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.store.DeletePomodoro(id); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	reversal, err := h.ledger.Reverse(types.XPSourcePomodoro, int64(id), types.XPReasonFocusSession, time.Now())
+	if reversal == nil && err == nil {
+		// an abandoned session gets its penalty back
+		reversal, err = h.ledger.Reverse(types.XPSourcePomodoro, int64(id), types.XPReasonSessionAbandoned, time.Now())
+	}
+	if err != nil {
+		log.Printf("failed to reverse the XP of pomodoro %d: %v", id, err)
+	}
+	metadata := map[string]any{"pomodoro_id": id}
+	targetID := 0
+	if reversal != nil {
+		metadata["xp_reversed"] = -reversal.Delta
+		targetID = reversal.UserId
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminPomodoroDelete, actorID, targetID, metadata)
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Pomodoro deleted successfully"})
+}
+
+// HandleAnonymizeUser godoc
+//
+// @Summary 			Anonymize a user
+// @Description 		Erase the personal data of a user (username, email, country, credentials, sessions) while keeping their pomodoros and stats for aggregate numbers (admin only)
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Success 			200 {object} types.UserSummary
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/users/{id}/anonymize [post]
+func (h *Handler) HandleAnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.AnonymizeUser(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminUserAnonymized, actorID, id, nil)
+	utils.WriteJSON(w, http.StatusOK, user)
+}
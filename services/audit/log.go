@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"backend/types"
+	"backend/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Log records a security event for the request, actorID and targetID are left
+// empty when 0. Failures are only logged so auditing never blocks the user.
+func Log(logger types.AuditLogger, r *http.Request, eventType string, actorID, targetID int, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		log.Printf("failed to encode audit metadata for %s: %v", eventType, err)
+		data = []byte("{}")
+	}
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	event := &types.AuditEvent{
+		EventType: eventType,
+		ActorId:   optionalID(actorID),
+		TargetId:  optionalID(targetID),
+		IP:        utils.ClientIP(r),
+		UserAgent: userAgent,
+		Metadata:  string(data),
+		CreatedAt: time.Now(),
+	}
+	if err := logger.Record(event); err != nil {
+		log.Printf("failed to record audit event %s: %v", eventType, err)
+	}
+}
+
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
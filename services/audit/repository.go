@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"backend/types"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type AuditRepoImpl struct {
+	db *sql.DB
+}
+
+func NewAuditRepoImpl(db *sql.DB) *AuditRepoImpl {
+	return &AuditRepoImpl{db: db}
+}
+
+const auditColumns = "id, event_type, actor_id, target_id, ip, user_agent, metadata, created_at, prev_hash, hash, personal_data_hash, redacted_at"
+
+// Record appends the event to the chain. The chain head row is locked for the
+// duration of the transaction so concurrent events are chained one after the other.
+func (a *AuditRepoImpl) Record(e *types.AuditEvent) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.QueryRow("SELECT last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE").Scan(&e.PrevHash); err != nil {
+		return err
+	}
+	// MySQL keeps microseconds, hash what will be read back
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+	e.PersonalDataHash = personalDataHash(e)
+	e.Hash = computeHash(e)
+	res, err := tx.Exec(
+		"INSERT INTO audit_events(event_type, actor_id, target_id, ip, user_agent, metadata, created_at, prev_hash, hash, personal_data_hash) VALUES (?,?,?,?,?,?,?,?,?,?)",
+		e.EventType, e.ActorId, e.TargetId, e.IP, e.UserAgent, e.Metadata, e.CreatedAt, e.PrevHash, e.Hash, e.PersonalDataHash,
+	)
+	if err != nil {
+		return err
+	}
+	if e.Id, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE audit_chain_head SET last_hash = ? WHERE id = 1", e.Hash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListUserEvents returns the events where the user is the actor or the target, newest first
+func (a *AuditRepoImpl) ListUserEvents(userID int, limit, offset int) ([]types.AuditEvent, error) {
+	rows, err := a.db.Query(
+		"SELECT "+auditColumns+" FROM audit_events WHERE target_id = ? OR actor_id = ? ORDER BY id DESC LIMIT ? OFFSET ?",
+		userID, userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+func (a *AuditRepoImpl) QueryEvents(f types.AuditFilter) ([]types.AuditEvent, error) {
+	var where []string
+	var args []any
+	if f.ActorId != 0 {
+		where = append(where, "actor_id = ?")
+		args = append(args, f.ActorId)
+	}
+	if f.TargetId != 0 {
+		where = append(where, "target_id = ?")
+		args = append(args, f.TargetId)
+	}
+	if f.EventType != "" {
+		where = append(where, "event_type = ?")
+		args = append(args, f.EventType)
+	}
+	if f.From != nil {
+		where = append(where, "created_at >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		where = append(where, "created_at < ?")
+		args = append(args, *f.To)
+	}
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// RedactUserEvents empties the IP, user agent and metadata of the events where
+// the user is the actor or the target; the metadata holds their old emails and
+// usernames. It runs in the transaction that anonymizes or deletes the account.
+func RedactUserEvents(tx *sql.Tx, userID int, at time.Time) error {
+	_, err := tx.Exec(
+		"UPDATE audit_events SET ip = '', user_agent = '', metadata = '{}', redacted_at = ? WHERE (target_id = ? OR actor_id = ?) AND redacted_at IS NULL",
+		at, userID, userID,
+	)
+	return err
+}
+
+// VerifyChain walks the whole log in order and recomputes every hash. A
+// redacted event is checked against its personal data hash, the ones recorded
+// before personal data hashes can only be checked for their place in the chain.
+func (a *AuditRepoImpl) VerifyChain() (*types.AuditVerification, error) {
+	rows, err := a.db.Query("SELECT " + auditColumns + " FROM audit_events ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := &types.AuditVerification{Valid: true}
+	prev := strings.Repeat("0", 64)
+	for rows.Next() {
+		var e types.AuditEvent
+		if err := scanRowIntoEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		result.Checked++
+		valid := e.PrevHash == prev
+		switch {
+		case e.PersonalDataHash != "":
+			valid = valid && computeHash(&e) == e.Hash && (e.RedactedAt != nil || personalDataHash(&e) == e.PersonalDataHash)
+		case e.RedactedAt != nil:
+			result.Unverifiable++
+		default:
+			valid = valid && computeHash(&e) == e.Hash
+		}
+		if !valid {
+			result.Valid = false
+			result.BrokenAt = &e.Id
+			return result, nil
+		}
+		prev = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// the head must point to the last event, otherwise events were removed from the end
+	var head string
+	if err := a.db.QueryRow("SELECT last_hash FROM audit_chain_head WHERE id = 1").Scan(&head); err != nil {
+		return nil, err
+	}
+	result.Valid = head == prev
+	return result, nil
+}
+
+func computeHash(e *types.AuditEvent) string {
+	if e.PersonalDataHash != "" {
+		data, _ := json.Marshal(struct {
+			PrevHash     string `json:"prev_hash"`
+			EventType    string `json:"event_type"`
+			ActorId      *int   `json:"actor_id"`
+			TargetId     *int   `json:"target_id"`
+			PersonalData string `json:"personal_data"`
+			CreatedAt    string `json:"created_at"`
+		}{e.PrevHash, e.EventType, e.ActorId, e.TargetId, e.PersonalDataHash, e.CreatedAt.UTC().Format(time.RFC3339Nano)})
+		return sha256Hex(string(data))
+	}
+	data, _ := json.Marshal(struct {
+		PrevHash  string `json:"prev_hash"`
+		EventType string `json:"event_type"`
+		ActorId   *int   `json:"actor_id"`
+		TargetId  *int   `json:"target_id"`
+		IP        string `json:"ip"`
+		UserAgent string `json:"user_agent"`
+		Metadata  string `json:"metadata"`
+		CreatedAt string `json:"created_at"`
+	}{e.PrevHash, e.EventType, e.ActorId, e.TargetId, e.IP, e.UserAgent, e.Metadata, e.CreatedAt.UTC().Format(time.RFC3339Nano)})
+	return sha256Hex(string(data))
+}
+
+// personalDataHash is the digest of the fields redacted with the user
+func personalDataHash(e *types.AuditEvent) string {
+	data, _ := json.Marshal(struct {
+		IP        string `json:"ip"`
+		UserAgent string `json:"user_agent"`
+		Metadata  string `json:"metadata"`
+	}{e.IP, e.UserAgent, e.Metadata})
+	return sha256Hex(string(data))
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanEvents(rows *sql.Rows) ([]types.AuditEvent, error) {
+	defer rows.Close()
+	list := make([]types.AuditEvent, 0)
+	for rows.Next() {
+		var e types.AuditEvent
+		if err := scanRowIntoEvent(rows, &e); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func scanRowIntoEvent(rows *sql.Rows, e *types.AuditEvent) error {
+	return rows.Scan(&e.Id, &e.EventType, &e.ActorId, &e.TargetId, &e.IP, &e.UserAgent, &e.Metadata, &e.CreatedAt, &e.PrevHash, &e.Hash, &e.PersonalDataHash, &e.RedactedAt)
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store types.AuditRepo
+}
+
+func NewHandler(store types.AuditRepo) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+	authRouter.HandleFunc("/me/security-events", h.HandleListMyEvents).Methods(http.MethodGet)
+	authRouter.Handle("/admin/audit", adminOnly(http.HandlerFunc(h.HandleQueryEvents))).Methods(http.MethodGet)
+	authRouter.Handle("/admin/audit/verify", adminOnly(http.HandlerFunc(h.HandleVerifyChain))).Methods(http.MethodGet)
+}
+
+// HandleListMyEvents godoc
+//
+// @Summary 			List my security events
+// @Description 		Logins, failed logins, password resets, email, username and country changes and admin actions concerning the authenticated user, newest first
+// @Tags 				Audit
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.AuditEvent
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/security-events [get]
+func (h *Handler) HandleListMyEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListUserEvents(userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleQueryEvents godoc
+//
+// @Summary 			Query the audit log
+// @Description 		Search the audit log (admin only), newest first
+// @Tags 				Audit
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				actor_id query int false "Actor user ID"
+// @Param 				target_id query int false "Target user ID"
+// @Param 				event_type query string false "Event type, e.g. login_failed"
+// @Param 				from query string false "From (RFC3339, inclusive)"
+// @Param 				to query string false "To (RFC3339, exclusive)"
+// @Param 				limit query int false "Page size (default 50)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.AuditEvent
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/audit [get]
+func (h *Handler) HandleQueryEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter types.AuditFilter
+	var err error
+	for name, dest := range map[string]*int{"actor_id": &filter.ActorId, "target_id": &filter.TargetId} {
+		if value := query.Get(name); value != "" {
+			if *dest, err = strconv.Atoi(value); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid %s", name))
+				return
+			}
+		}
+	}
+	for name, dest := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid %s, expected RFC3339", name))
+				return
+			}
+			*dest = &t
+		}
+	}
+	filter.EventType = query.Get("event_type")
+	filter.Limit, filter.Offset = pagination(r)
+	list, err := h.store.QueryEvents(filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleVerifyChain godoc
+//
+// @Summary 			Verify the audit log
+// @Description 		Recompute the hash chain of the whole audit log and report the first event that was tampered with (admin only)
+// @Tags 				Audit
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.AuditVerification
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/audit/verify [get]
+func (h *Handler) HandleVerifyChain(w http.ResponseWriter, r *http.Request) {
+	result, err := h.store.VerifyChain()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+// Principal is the authenticated caller of a protected route.
+// AccessTokenID is 0 when the caller used a login JWT, SessionID is 0 for
+// personal access tokens.
+// The user is identified by UserID; for JWTs Username comes from the claims
+// and may be outdated after a rename.
+type Principal struct {
+	UserID        int
+	Username      string
+	Role          string
+	SessionID     int
+	AccessTokenID int
+	Scopes        []string
+}
+
+func (p *Principal) IsAccessToken() bool {
+	return p.AccessTokenID != 0
+}
+
+// login JWTs have full access, personal access tokens only what they were granted
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAccessToken() {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
+
+// CurrentUserID returns the id of the authenticated user for the request
+func CurrentUserID(r *http.Request) (int, error) {
+	p, err := CurrentPrincipal(r)
+	if err != nil {
+		return 0, err
+	}
+	return p.UserID, nil
+}
+
+// CurrentPrincipal returns the authenticated caller, or an error for tokens
+// issued before user ids were added to the claims
+func CurrentPrincipal(r *http.Request) (*Principal, error) {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok || p.UserID == 0 {
+		return nil, fmt.Errorf("missing user identity, please log in again")
+	}
+	return p, nil
+}
@@ -1,16 +1,126 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"backend/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher produces self-describing hashes: argon2id hashes use the PHC
+// string format ($argon2id$v=19$m=...,t=...,p=...$salt$hash) and bcrypt hashes
+// carry their cost, so the parameters used for a stored hash can always be read
+// back and compared with the current configuration.
+type PasswordHasher struct {
+	Algorithm   string
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	KeyLength   uint32
+	BcryptCost  int
+}
+
+const argon2SaltLength = 16
+
+// bcrypt only hashes the first 72 bytes of a password and refuses longer ones
+const bcryptMaxPasswordBytes = 72
+
+var defaultHasher = PasswordHasher{
+	Algorithm:   config.Envs.PasswordHashAlgorithm,
+	Memory:      uint32(config.Envs.Argon2MemoryKiB),
+	Iterations:  uint32(config.Envs.Argon2Iterations),
+	Parallelism: uint8(config.Envs.Argon2Parallelism),
+	KeyLength:   uint32(config.Envs.Argon2KeyLength),
+	BcryptCost:  int(config.Envs.BcryptCost),
+}
 
 func HashPassword(pw string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+	return defaultHasher.Hash(pw)
+}
+
+func ComparePasswords(hashed string, plain []byte) bool {
+	ok, _ := defaultHasher.Verify(hashed, plain)
+	return ok
+}
+
+// VerifyPassword also reports whether the stored hash should be replaced
+// because it was made with another algorithm or weaker parameters
+func VerifyPassword(hashed string, plain []byte) (ok bool, needsRehash bool) {
+	return defaultHasher.Verify(hashed, plain)
+}
+
+func (h PasswordHasher) Hash(pw string) (string, error) {
+	switch h.Algorithm {
+	case "argon2id":
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(pw), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	case "bcrypt":
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+	return "", fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+}
+
+func (h PasswordHasher) Verify(hashed string, plain []byte) (ok bool, needsRehash bool) {
+	if strings.HasPrefix(hashed, "$argon2id$") {
+		params, salt, key, err := parseArgon2Hash(hashed)
+		if err != nil {
+			return false, false
+		}
+		computed := argon2.IDKey(plain, salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		outdated := h.Algorithm != "argon2id" ||
+			params.Memory != h.Memory ||
+			params.Iterations != h.Iterations ||
+			params.Parallelism != h.Parallelism ||
+			uint32(len(key)) != h.KeyLength
+		return true, outdated
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hashed), plain); err != nil {
+		return false, false
 	}
-	return string(hash), nil
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return true, err != nil || h.Algorithm != "bcrypt" || cost != h.BcryptCost
 }
 
-func ComparePasswords(hashed string,plain []byte) bool{
-	err := bcrypt.CompareHashAndPassword([]byte(hashed),plain)
-	return err == nil
+func parseArgon2Hash(hashed string) (params PasswordHasher, salt []byte, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, fmt.Errorf("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+	params.Algorithm = "argon2id"
+	return params, salt, key, nil
 }
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"backend/config"
+	"strings"
+	"testing"
+)
+
+// cheap parameters, the tests only check what the hashes carry
+var (
+	testArgon2 = PasswordHasher{Algorithm: "argon2id", Memory: 1024, Iterations: 1, Parallelism: 1, KeyLength: 32}
+	testBcrypt = PasswordHasher{Algorithm: "bcrypt", BcryptCost: 4}
+)
+
+func TestHashAndVerify(t *testing.T) {
+	for _, hasher := range []PasswordHasher{testArgon2, testBcrypt} {
+		t.Run(hasher.Algorithm, func(t *testing.T) {
+			hash, err := hasher.Hash("correct horse battery")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, rehash := hasher.Verify(hash, []byte("correct horse battery")); !ok || rehash {
+				t.Errorf("Verify(right password) = %v, %v, want true, false", ok, rehash)
+			}
+			if ok, _ := hasher.Verify(hash, []byte("correct horse staple")); ok {
+				t.Errorf("Verify(wrong password) = true")
+			}
+			other, err := hasher.Hash("correct horse battery")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other == hash {
+				t.Errorf("two hashes of the same password are equal, the salt is not random")
+			}
+		})
+	}
+}
+
+func TestHashRefusesUnknownAlgorithm(t *testing.T) {
+	if _, err := (PasswordHasher{Algorithm: "md5"}).Hash("correct horse battery"); err == nil {
+		t.Error("Hash() with md5 succeeded")
+	}
+}
+
+func TestVerifyNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  PasswordHasher
+		current func(h *PasswordHasher)
+		want    bool
+	}{
+		{"same argon2 parameters", testArgon2, func(h *PasswordHasher) {}, false},
+		{"more memory", testArgon2, func(h *PasswordHasher) { h.Memory = 2048 }, true},
+		{"more iterations", testArgon2, func(h *PasswordHasher) { h.Iterations = 2 }, true},
+		{"more lanes", testArgon2, func(h *PasswordHasher) { h.Parallelism = 2 }, true},
+		{"longer key", testArgon2, func(h *PasswordHasher) { h.KeyLength = 64 }, true},
+		{"argon2 to bcrypt", testArgon2, func(h *PasswordHasher) { *h = testBcrypt }, true},
+		{"same bcrypt cost", testBcrypt, func(h *PasswordHasher) {}, false},
+		{"higher bcrypt cost", testBcrypt, func(h *PasswordHasher) { h.BcryptCost = 5 }, true},
+		{"bcrypt to argon2", testBcrypt, func(h *PasswordHasher) { *h = testArgon2 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.stored.Hash("correct horse battery")
+			if err != nil {
+				t.Fatal(err)
+			}
+			current := tt.stored
+			tt.current(&current)
+			ok, rehash := current.Verify(hash, []byte("correct horse battery"))
+			if !ok || rehash != tt.want {
+				t.Errorf("Verify() = %v, %v, want true, %v", ok, rehash, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgon2Hash(t *testing.T) {
+	// salt "0123456789abcdef" and a 32 byte key
+	const salt = "MDEyMzQ1Njc4OWFiY2RlZg"
+	const key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8"
+	tests := []struct {
+		name    string
+		hash    string
+		want    PasswordHasher
+		wantErr bool
+	}{
+		{"valid", "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$" + key, PasswordHasher{Algorithm: "argon2id", Memory: 65536, Iterations: 3, Parallelism: 2}, false},
+		{"missing key", "$argon2id$v=19$m=65536,t=3,p=2$" + salt, PasswordHasher{}, true},
+		{"older version", "$argon2id$v=16$m=65536,t=3,p=2$" + salt + "$" + key, PasswordHasher{}, true},
+		{"no version", "$argon2id$m=65536,t=3,p=2$" + salt + "$" + key + "$", PasswordHasher{}, true},
+		{"bad parameters", "$argon2id$v=19$m=lots,t=3,p=2$" + salt + "$" + key, PasswordHasher{}, true},
+		{"bad salt", "$argon2id$v=19$m=65536,t=3,p=2$not base64!$" + key, PasswordHasher{}, true},
+		{"bad key", "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$not base64!", PasswordHasher{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, gotSalt, gotKey, err := parseArgon2Hash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArgon2Hash() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if params != tt.want {
+				t.Errorf("got parameters %+v, want %+v", params, tt.want)
+			}
+			if string(gotSalt) != "0123456789abcdef" || len(gotKey) != 32 {
+				t.Errorf("got salt %q and a %d byte key", gotSalt, len(gotKey))
+			}
+		})
+	}
+}
+
+func TestValidatePasswordBcryptLength(t *testing.T) {
+	algorithm := config.Envs.PasswordHashAlgorithm
+	t.Cleanup(func() { config.Envs.PasswordHashAlgorithm = algorithm })
+	tests := []struct {
+		name      string
+		algorithm string
+		password  string
+		wantErr   bool
+	}{
+		{"72 bytes with bcrypt", "bcrypt", strings.Repeat("a", 72), false},
+		{"73 bytes with bcrypt", "bcrypt", strings.Repeat("a", 73), true},
+		{"73 bytes with argon2id", "argon2id", strings.Repeat("a", 73), false},
+		{"multibyte characters over 72 bytes with bcrypt", "bcrypt", strings.Repeat("€", 25), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Envs.PasswordHashAlgorithm = tt.algorithm
+			if err := ValidatePassword(tt.password); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePassword() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
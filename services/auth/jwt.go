@@ -2,26 +2,35 @@ package auth
 
 import (
 	"backend/config"
+	"backend/utils"
 	"errors"
+	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	Username string `json:"username"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID int    `json:"session_id"`
 	jwt.RegisteredClaims
 }
 
-func CreateToken(username string) (string, error) {
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"username": username,
-			"exp":      time.Now().Add(time.Duration(config.Envs.JWTExpirationInSeconds) * time.Second).Unix(),
-		})
+func CreateToken(userID int, username string, role string, sessionID int) (string, error) {
+	ks, err := currentKeys()
+	if err != nil {
+		return "", err
+	}
 
-	tokenString, err := token.SignedString([]byte(config.Envs.JWTSecret))
+	tokenString, err := ks.sign(jwt.MapClaims{
+		"user_id":    userID,
+		"username":   username,
+		"role":       role,
+		"session_id": sessionID,
+		"exp":        time.Now().Add(time.Duration(config.Envs.JWTExpirationInSeconds) * time.Second).Unix(),
+	})
 	if err != nil {
 		return "", err
 	}
@@ -29,15 +38,15 @@ func CreateToken(username string) (string, error) {
 	return tokenString, nil
 }
 func VerifyJWT(tokenString string) (*jwt.Token, error) {
+	ks, err := currentKeys()
+	if err != nil {
+		return nil, err
+	}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
-		&jwt.RegisteredClaims{},
-		func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(config.Envs.JWTSecret), nil
-		})
+		&Claims{},
+		ks.verificationKey,
+	)
 
 	if err != nil {
 		return nil, err
@@ -48,3 +57,15 @@ func VerifyJWT(tokenString string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+// HandleJWKS serves the public verification keys at /.well-known/jwks.json
+// so other services can verify the tokens issued by this API
+func HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	set, err := PublicJWKS()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSON(w, http.StatusOK, set)
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"backend/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the key used to sign new tokens and every key that is still
+// accepted when verifying, indexed by key id. Keeping the previous public keys
+// in JWT_VERIFICATION_KEY_FILES lets old tokens verify after a rotation.
+type KeySet struct {
+	method     jwt.SigningMethod
+	signingKey any
+	signingKid string
+	verifying  map[string]crypto.PublicKey
+}
+
+var keys *KeySet
+
+// LoadKeys reads the signing configuration, it must be called before any token is created or verified
+func LoadKeys() error {
+	ks, err := newKeySet(config.Envs)
+	if err != nil {
+		return err
+	}
+	keys = ks
+	return nil
+}
+
+func currentKeys() (*KeySet, error) {
+	if keys == nil {
+		return nil, errors.New("signing keys are not loaded")
+	}
+	return keys, nil
+}
+
+func newKeySet(cfg config.Config) (*KeySet, error) {
+	ks := &KeySet{verifying: map[string]crypto.PublicKey{}}
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		ks.method = jwt.SigningMethodHS256
+		ks.signingKey = []byte(cfg.JWTSecret)
+		return ks, nil
+	case "RS256":
+		ks.method = jwt.SigningMethodRS256
+	case "EdDSA":
+		ks.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JWTAlgorithm)
+	}
+
+	if cfg.JWTPrivateKeyFile == "" {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.JWTAlgorithm)
+	}
+	private, err := readPrivateKey(cfg.JWTPrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	public := private.(crypto.Signer).Public()
+	if err := checkKeyType(ks.method, public); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.JWTPrivateKeyFile, err)
+	}
+	kid, err := thumbprint(public)
+	if err != nil {
+		return nil, err
+	}
+	ks.signingKey = private
+	ks.signingKid = kid
+	ks.verifying[kid] = public
+
+	for _, file := range cfg.JWTVerificationKeys {
+		public, err := readPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkKeyType(ks.method, public); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		kid, err := thumbprint(public)
+		if err != nil {
+			return nil, err
+		}
+		ks.verifying[kid] = public
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.signingKid != "" {
+		token.Header["kid"] = ks.signingKid
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+func (ks *KeySet) verificationKey(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != ks.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	if ks.method == jwt.SigningMethodHS256 {
+		return ks.signingKey, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.verifying[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	return key, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+	return block, nil
+}
+
+func readPrivateKey(file string) (crypto.PrivateKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported private key format", file)
+}
+
+// verification key files may contain either a public key or a private key
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	private, err := readPrivateKey(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unsupported public key format", file)
+	}
+	return private.(crypto.Signer).Public(), nil
+}
+
+func checkKeyType(method jwt.SigningMethod, key crypto.PublicKey) error {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if method == jwt.SigningMethodRS256 {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if method == jwt.SigningMethodEdDSA {
+			return nil
+		}
+	}
+	return fmt.Errorf("key type %T cannot be used with %s", key, method.Alg())
+}
+
+// JWK is the JSON Web Key representation of a public verification key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the verification keys; it is empty with HS256 since the secret must stay private
+func PublicJWKS() (JWKSet, error) {
+	set := JWKSet{Keys: []JWK{}}
+	ks, err := currentKeys()
+	if err != nil {
+		return set, err
+	}
+	for kid, key := range ks.verifying {
+		jwk := toJWK(key)
+		jwk.Kid = kid
+		jwk.Use = "sig"
+		jwk.Alg = ks.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set, nil
+}
+
+func toJWK(key crypto.PublicKey) JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: b64(k)}
+	}
+	return JWK{}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as key id, so the same
+// key always gets the same kid no matter which file it was loaded from
+func thumbprint(key crypto.PublicKey) (string, error) {
+	jwk := toJWK(key)
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"backend/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePEM stores der in a PEM file of the test directory and returns its path
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func newEd25519Key(t *testing.T) (ed25519.PrivateKey, string) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private, writePEM(t, "ed25519.pem", "PRIVATE KEY", der)
+}
+
+func publicKeyFile(t *testing.T, key any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "public.pem", "PUBLIC KEY", der)
+}
+
+func TestNewKeySet(t *testing.T) {
+	edPrivate, edFile := newEd25519Key(t)
+	_, otherEdFile := newEd25519Key(t)
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaFile := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaPrivate))
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       config.Config
+		verifying int
+		wantErr   bool
+	}{
+		{"HS256", config.Config{JWTAlgorithm: "HS256", JWTSecret: "s3cret"}, 0, false},
+		{"EdDSA", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: edFile}, 1, false},
+		{"RS256 with a PKCS #1 key", config.Config{JWTAlgorithm: "RS256", JWTPrivateKeyFile: rsaFile}, 1, false},
+		{"rotated public key", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: edFile, JWTVerificationKeys: []string{publicKeyFile(t, edPrivate.Public())}}, 1, false},
+		{"rotated private key", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: edFile, JWTVerificationKeys: []string{otherEdFile}}, 2, false},
+		{"unknown algorithm", config.Config{JWTAlgorithm: "none"}, 0, true},
+		{"no private key", config.Config{JWTAlgorithm: "EdDSA"}, 0, true},
+		{"missing private key", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}, 0, true},
+		{"private key is not PEM", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: notPEM}, 0, true},
+		{"RSA key for EdDSA", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: rsaFile}, 0, true},
+		{"Ed25519 key for RS256", config.Config{JWTAlgorithm: "RS256", JWTPrivateKeyFile: edFile}, 0, true},
+		{"rotated key of another type", config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: edFile, JWTVerificationKeys: []string{rsaFile}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := newKeySet(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newKeySet() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && len(ks.verifying) != tt.verifying {
+				t.Errorf("got %d verification keys, want %d", len(ks.verifying), tt.verifying)
+			}
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 8037 appendix A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, err := thumbprint(ed25519.PublicKey(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"; kid != want {
+		t.Errorf("got kid %s, want %s", kid, want)
+	}
+}
+
+func TestRotatedKeysVerify(t *testing.T) {
+	oldPrivate, oldFile := newEd25519Key(t)
+	_, newFile := newEd25519Key(t)
+	old, err := newKeySet(config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: oldFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := newKeySet(config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: newFile, JWTVerificationKeys: []string{publicKeyFile(t, oldPrivate.Public())}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutOld, err := newKeySet(config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: newFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	oldToken, err := old.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := rotated.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    *KeySet
+		token   string
+		wantKid string
+		wantErr bool
+	}{
+		{"old token, old key kept", rotated, oldToken, old.signingKid, false},
+		{"new token", rotated, newToken, rotated.signingKid, false},
+		{"old token, old key dropped", withoutOld, oldToken, old.signingKid, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.Parse(tt.token, tt.keys.verificationKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if kid := token.Header["kid"]; kid != tt.wantKid {
+				t.Errorf("got kid %v, want %s", kid, tt.wantKid)
+			}
+		})
+	}
+}
+
+func TestPublicJWKS(t *testing.T) {
+	saved := keys
+	t.Cleanup(func() { keys = saved })
+	edPrivate, edFile := newEd25519Key(t)
+	_, otherEdFile := newEd25519Key(t)
+
+	var err error
+	if keys, err = newKeySet(config.Config{JWTAlgorithm: "HS256", JWTSecret: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	set, err := PublicJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 0 {
+		t.Errorf("HS256 published %d keys", len(set.Keys))
+	}
+
+	if keys, err = newKeySet(config.Config{JWTAlgorithm: "EdDSA", JWTPrivateKeyFile: edFile, JWTVerificationKeys: []string{otherEdFile}}); err != nil {
+		t.Fatal(err)
+	}
+	set, err = PublicJWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("got %d keys, want the signing and the rotated key", len(set.Keys))
+	}
+	if set.Keys[0].Kid >= set.Keys[1].Kid {
+		t.Errorf("keys are not sorted by kid: %s, %s", set.Keys[0].Kid, set.Keys[1].Kid)
+	}
+	var signing *JWK
+	for i, jwk := range set.Keys {
+		if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.Use != "sig" || jwk.Alg != "EdDSA" || jwk.N != "" {
+			t.Errorf("unexpected key %+v", jwk)
+		}
+		if jwk.Kid == keys.signingKid {
+			signing = &set.Keys[i]
+		}
+	}
+	if signing == nil {
+		t.Fatalf("the signing key %s is not published", keys.signingKid)
+	}
+	if want := base64.RawURLEncoding.EncodeToString(edPrivate.Public().(ed25519.PublicKey)); signing.X != want {
+		t.Errorf("got x %s, want %s", signing.X, want)
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"backend/config"
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	breachedOnce sync.Once
+	breached     map[string]bool
+)
+
+// ValidatePassword checks a new password against the configured policy:
+// length bounds (72 bytes at most with bcrypt) and, when BREACHED_PASSWORDS_FILE
+// is set, the breached list
+func ValidatePassword(pw string) error {
+	length := len([]rune(pw))
+	if length < int(config.Envs.PasswordMinLength) {
+		return fmt.Errorf("password must be at least %d characters long", config.Envs.PasswordMinLength)
+	}
+	if length > int(config.Envs.PasswordMaxLength) {
+		return fmt.Errorf("password must be at most %d characters long", config.Envs.PasswordMaxLength)
+	}
+	if config.Envs.PasswordHashAlgorithm == "bcrypt" && len(pw) > bcryptMaxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes long", bcryptMaxPasswordBytes)
+	}
+	if isBreachedPassword(pw) {
+		return fmt.Errorf("this password appears in a list of breached passwords, please choose another one")
+	}
+	return nil
+}
+
+func isBreachedPassword(pw string) bool {
+	breachedOnce.Do(loadBreachedPasswords)
+	if len(breached) == 0 {
+		return false
+	}
+	sum := sha1.Sum([]byte(pw))
+	return breached[strings.ToLower(pw)] || breached[hex.EncodeToString(sum[:])]
+}
+
+// the file holds one entry per line, either a plain password or the hex SHA-1
+// of one (an optional ":count" suffix as in the Have I Been Pwned dumps is ignored)
+func loadBreachedPasswords() {
+	breached = map[string]bool{}
+	if config.Envs.BreachedPasswordsFile == "" {
+		return
+	}
+	file, err := os.Open(config.Envs.BreachedPasswordsFile)
+	if err != nil {
+		log.Printf("failed to load breached passwords list: %v", err)
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 41 && line[40] == ':' {
+			line = line[:40]
+		}
+		if line != "" {
+			breached[strings.ToLower(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("failed to read breached passwords list: %v", err)
+	}
+}
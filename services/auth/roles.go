@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// CanActOn reports whether the principal may write the data of userID.
+// Everyone, staff included, only changes their own data: pomodoros and stats
+// mint XP, so moderation goes through the audited /admin routes.
+func (p *Principal) CanActOn(userID int) bool {
+	return p.UserID == userID
+}
+
+// CheckCanActOn returns an error when the caller of r may not change the data of userID
+func CheckCanActOn(r *http.Request, userID int) error {
+	p, err := CurrentPrincipal(r)
+	if err != nil {
+		return err
+	}
+	if !p.CanActOn(userID) {
+		return fmt.Errorf("you can only change your own data")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"backend/utils"
+	"strings"
+)
+
+// scopes that can be granted to personal access tokens
+const (
+	ScopePomodorosWrite = "pomodoros:write"
+	ScopeStatsRead      = "stats:read"
+	ScopeStatsWrite     = "stats:write"
+	ScopeRankingRead    = "ranking:read"
+)
+
+// every personal access token starts with this prefix so the middleware
+// can tell it apart from a JWT without parsing it
+const AccessTokenPrefix = "xpat_"
+
+var validScopes = map[string]bool{
+	ScopePomodorosWrite: true,
+	ScopeStatsRead:      true,
+	ScopeStatsWrite:     true,
+	ScopeRankingRead:    true,
+}
+
+func IsValidScope(scope string) bool {
+	return validScopes[scope]
+}
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// only the sha256 of a personal access token is stored in the database
+func HashAccessToken(token string) string {
+	return utils.HashToken(token)
+}
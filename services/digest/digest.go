@@ -0,0 +1,171 @@
+package digest
+
+import (
+	"backend/helpers"
+	"backend/services/ranks"
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+// Digester builds weekly digests and sends the due ones
+type Digester struct {
+	store   types.DigestRepo
+	ranking types.RankingRepo
+	ranks   types.RankRepo
+	mailer  types.Mailer
+}
+
+func NewDigester(store types.DigestRepo, ranking types.RankingRepo, rankRepo types.RankRepo, mailer types.Mailer) *Digester {
+	return &Digester{store: store, ranking: ranking, ranks: rankRepo, mailer: mailer}
+}
+
+// weekStart returns the Monday 00:00 UTC of the week containing t
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// Build computes the digest of the week starting at week for the user. Ranks
+// are the current ones, compared with the snapshot taken with the previous digest.
+func (d *Digester) Build(user *types.DigestRecipient, week time.Time) (*types.WeeklyDigest, error) {
+	week = weekStart(week)
+	end := week.AddDate(0, 0, 7)
+	digest := &types.WeeklyDigest{
+		UserId:    user.UserId,
+		Username:  user.Username,
+		WeekStart: week,
+		WeekEnd:   end.AddDate(0, 0, -1),
+		XP:        user.XP,
+	}
+	var err error
+	if digest.Pomodoros, digest.FocusMinutes, err = d.store.WeekActivity(user.UserId, week, end); err != nil {
+		return nil, err
+	}
+	if digest.PreviousPomodoros, digest.PreviousFocusMinutes, err = d.store.WeekActivity(user.UserId, week.AddDate(0, 0, -7), week); err != nil {
+		return nil, err
+	}
+	digest.PomodoroChange = digest.Pomodoros - digest.PreviousPomodoros
+	digest.FocusMinutesChange = digest.FocusMinutes - digest.PreviousFocusMinutes
+	if digest.CurrentStreak, err = d.store.GetCurrentStreak(user.UserId); err != nil {
+		return nil, err
+	}
+
+	catalogue, err := d.ranks.ListRanks()
+	if err != nil {
+		return nil, err
+	}
+	if progress := ranks.Progress(catalogue, user.XP); progress != nil {
+		digest.RankName = progress.Current.Name
+		digest.RankProgressPercent = progress.Percent
+		digest.XPToNextRank = progress.XPToNextRank
+		if progress.Next != nil {
+			digest.NextRankName = progress.Next.Name
+		}
+	}
+
+	globalRank, countryRank, err := d.currentRanks(user)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := d.store.GetDelivery(user.UserId, week.AddDate(0, 0, -7))
+	if err != nil {
+		return nil, err
+	}
+	if globalRank != nil {
+		digest.GlobalRank = *globalRank
+		if previous != nil && previous.GlobalRank != nil {
+			digest.PreviousGlobalRank = *previous.GlobalRank
+			digest.GlobalRankChange = digest.PreviousGlobalRank - digest.GlobalRank
+		}
+	}
+	if user.Country != nil {
+		digest.Country = *user.Country
+	}
+	if countryRank != nil {
+		digest.CountryRank = *countryRank
+		if previous != nil && previous.CountryRank != nil {
+			digest.PreviousCountryRank = *previous.CountryRank
+			digest.CountryRankChange = digest.PreviousCountryRank - digest.CountryRank
+		}
+	}
+	if user.UnsubscribeToken != nil {
+		digest.UnsubscribeURL = helpers.APIURL("/digest/unsubscribe?token=" + url.QueryEscape(*user.UnsubscribeToken))
+	}
+	return digest, nil
+}
+
+// currentRanks returns nil ranks for users left out of the rankings
+func (d *Digester) currentRanks(user *types.DigestRecipient) (global *int, country *int, err error) {
+	entry, err := d.ranking.GetUserGlobalRank(user.UserId)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+	if entry != nil {
+		global = &entry.Rank
+	}
+	if user.Country == nil {
+		return global, nil, nil
+	}
+	entry, err = d.ranking.GetUserLocalRank(user.UserId, *user.Country)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+	if entry != nil {
+		country = &entry.Rank
+	}
+	return global, country, nil
+}
+
+// SendDue sends the digest of the last complete week to every opted-in user who
+// did not get it yet. Digests go out during the first two days of the week only,
+// so late opt-ins wait for the next week. Running it again is harmless.
+func (d *Digester) SendDue(now time.Time) error {
+	thisWeek := weekStart(now)
+	if now.Sub(thisWeek) > 48*time.Hour {
+		return nil
+	}
+	week := thisWeek.AddDate(0, 0, -7)
+	recipients, err := d.store.ListDueRecipients(week)
+	if err != nil {
+		return err
+	}
+	sent := 0
+	for i := range recipients {
+		if err := d.send(&recipients[i], week, now); err != nil {
+			log.Printf("failed to send weekly digest to user %d: %v", recipients[i].UserId, err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		log.Printf("sent %d weekly digests for the week of %s", sent, week.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func (d *Digester) send(user *types.DigestRecipient, week time.Time, now time.Time) error {
+	if user.Email == nil {
+		return fmt.Errorf("user has no email")
+	}
+	digest, err := d.Build(user, week)
+	if err != nil {
+		return err
+	}
+	if err := helpers.SendWeeklyDigest(d.mailer, user.Language, *user.Email, digest); err != nil {
+		return err
+	}
+	delivery := &types.DigestDelivery{UserId: user.UserId, WeekStart: week, XP: user.XP, SentAt: now}
+	if digest.GlobalRank != 0 {
+		delivery.GlobalRank = &digest.GlobalRank
+	}
+	if digest.CountryRank != 0 {
+		delivery.CountryRank = &digest.CountryRank
+	}
+	return d.store.RecordDelivery(delivery)
+}
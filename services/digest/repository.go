@@ -0,0 +1,126 @@
+package digest
+
+import (
+	"backend/types"
+	"database/sql"
+	"time"
+)
+
+type DigestRepoImpl struct {
+	db *sql.DB
+}
+
+func NewDigestRepoImpl(db *sql.DB) *DigestRepoImpl {
+	return &DigestRepoImpl{db: db}
+}
+
+const recipientColumns = "id, username, email, country, language, xp, digest_opt_in, digest_unsubscribe_token"
+
+func (d *DigestRepoImpl) SetDigestOptIn(userID int, enabled bool, unsubscribeToken string) error {
+	_, err := d.db.Exec(
+		"UPDATE users SET digest_opt_in = ?, digest_unsubscribe_token = COALESCE(digest_unsubscribe_token, ?) WHERE id = ?",
+		enabled, unsubscribeToken, userID,
+	)
+	return err
+}
+
+// Unsubscribe reports whether the token belongs to a user
+func (d *DigestRepoImpl) Unsubscribe(token string) (bool, error) {
+	var userID int
+	err := d.db.QueryRow("SELECT id FROM users WHERE digest_unsubscribe_token = ?", token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = d.db.Exec("UPDATE users SET digest_opt_in = FALSE WHERE id = ?", userID)
+	return err == nil, err
+}
+
+func (d *DigestRepoImpl) GetDigestRecipient(userID int) (*types.DigestRecipient, error) {
+	var r types.DigestRecipient
+	row := d.db.QueryRow("SELECT "+recipientColumns+" FROM users WHERE id = ?", userID)
+	if err := scanRowIntoRecipient(row, &r); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (d *DigestRepoImpl) ListDueRecipients(weekStart time.Time) ([]types.DigestRecipient, error) {
+	rows, err := d.db.Query(`
+		SELECT `+recipientColumns+` FROM users u
+		WHERE u.digest_opt_in = TRUE AND u.email IS NOT NULL
+		AND u.deletion_scheduled_at IS NULL AND u.anonymized_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM digest_deliveries d WHERE d.user_id = u.id AND d.week_start = ?)
+		ORDER BY u.id`,
+		weekStart,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.DigestRecipient, 0)
+	for rows.Next() {
+		var r types.DigestRecipient
+		if err := scanRowIntoRecipient(rows, &r); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// WeekActivity counts the completed focus sessions started in [from, to)
+func (d *DigestRepoImpl) WeekActivity(userID int, from, to time.Time) (int, int, error) {
+	var pomodoros, minutes int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(session_duration), 0) FROM pomodoros
+		WHERE user_id = ? AND type = 'pomodoro' AND completed = TRUE AND start_time >= ? AND start_time < ?`,
+		userID, from, to,
+	).Scan(&pomodoros, &minutes)
+	return pomodoros, minutes, err
+}
+
+func (d *DigestRepoImpl) GetCurrentStreak(userID int) (int, error) {
+	var streak int
+	err := d.db.QueryRow("SELECT current_streak FROM stats WHERE user_id = ?", userID).Scan(&streak)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return streak, err
+}
+
+func (d *DigestRepoImpl) GetDelivery(userID int, weekStart time.Time) (*types.DigestDelivery, error) {
+	var e types.DigestDelivery
+	err := d.db.QueryRow(
+		"SELECT user_id, week_start, xp, global_rank, country_rank, sent_at FROM digest_deliveries WHERE user_id = ? AND week_start = ?",
+		userID, weekStart,
+	).Scan(&e.UserId, &e.WeekStart, &e.XP, &e.GlobalRank, &e.CountryRank, &e.SentAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (d *DigestRepoImpl) RecordDelivery(e *types.DigestDelivery) error {
+	_, err := d.db.Exec(
+		"INSERT INTO digest_deliveries(user_id, week_start, xp, global_rank, country_rank, sent_at) VALUES (?,?,?,?,?,?)",
+		e.UserId, e.WeekStart, e.XP, e.GlobalRank, e.CountryRank, e.SentAt,
+	)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoRecipient(row scanner, r *types.DigestRecipient) error {
+	return row.Scan(&r.UserId, &r.Username, &r.Email, &r.Country, &r.Language, &r.XP, &r.OptIn, &r.UnsubscribeToken)
+}
@@ -0,0 +1,196 @@
+package digest
+
+import (
+	"backend/helpers"
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.DigestRepo
+	digester *Digester
+}
+
+func NewHandler(store types.DigestRepo, digester *Digester) *Handler {
+	return &Handler{store: store, digester: digester}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
+	router.HandleFunc("/digest/unsubscribe", h.HandleUnsubscribePage).Methods(http.MethodGet)
+	router.HandleFunc("/digest/unsubscribe", h.HandleUnsubscribe).Methods(http.MethodPost)
+
+	staff := middleware.RequireRole(auth.RoleModerator, auth.RoleAdmin)
+	authRouter.HandleFunc("/me/digest", h.HandleSetOptIn).Methods(http.MethodPut)
+	authRouter.Handle("/admin/digest/{id}", staff(http.HandlerFunc(h.HandleRender))).Methods(http.MethodGet)
+}
+
+// HandleSetOptIn godoc
+//
+// @Summary 			Subscribe to the weekly digest
+// @Description 		Opt in or out of the weekly progress email. It is sent on Mondays for the previous week, to verified email addresses only.
+// @Tags 				Digest
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.DigestOptInPayload true "Opt-in payload"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/digest [put]
+func (h *Handler) HandleSetOptIn(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.DigestOptInPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	// the token is only stored the first time, so older emails keep working
+	if err := h.store.SetDigestOptIn(userID, payload.Enabled, utils.GenerateToken()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	message := "Unsubscribed from the weekly digest"
+	if payload.Enabled {
+		message = "Subscribed to the weekly digest"
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: message})
+}
+
+// link scanners prefetch the links of emails, so the GET page only asks to confirm
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family:Arial,sans-serif; text-align:center; padding:40px;">
+<p>Stop receiving the XPomodoro weekly digest?</p>
+<form method="post" action="?token={{.}}"><button type="submit">Unsubscribe</button></form>
+</body></html>`))
+
+// HandleUnsubscribePage godoc
+//
+// @Summary 			Unsubscribe page
+// @Description 		Page opened from the link of a digest email, asking to confirm the unsubscription
+// @Tags 				Digest
+// @Produce 			html
+// @Param 				token query string true "Unsubscribe token"
+// @Success 			200 {string} string "HTML page"
+// @Failure 			400 {object} types.ErrorResponse
+// @Router 				/digest/unsubscribe [get]
+func (h *Handler) HandleUnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing token"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, token)
+}
+
+// HandleUnsubscribe godoc
+//
+// @Summary 			Unsubscribe from the weekly digest
+// @Description 		One-click unsubscription (RFC 8058) with the token of a digest email, no login needed
+// @Tags 				Digest
+// @Produce 			json
+// @Param 				token query string true "Unsubscribe token"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/digest/unsubscribe [post]
+func (h *Handler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing token"))
+		return
+	}
+	ok, err := h.store.Unsubscribe(token)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("invalid unsubscribe token"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Unsubscribed from the weekly digest"})
+}
+
+// HandleRender godoc
+//
+// @Summary 			Render a user's digest
+// @Description 		Build the weekly digest of any user and week without sending it, whether or not the user opted in (moderator or admin). Ranks are the current ones.
+// @Tags 				Digest
+// @Produce 			html
+// @Produce 			plain
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "User ID"
+// @Param 				week query string false "Any day of the week, YYYY-MM-DD (default last week)"
+// @Param 				format query string false "html (default), text or json"
+// @Success 			200 {object} types.WeeklyDigest
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/digest/{id} [get]
+func (h *Handler) HandleRender(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+	week := time.Now().AddDate(0, 0, -7)
+	if value := r.URL.Query().Get("week"); value != "" {
+		if week, err = time.Parse("2006-01-02", value); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("week must be a date formatted YYYY-MM-DD"))
+			return
+		}
+	}
+	user, err := h.store.GetDigestRecipient(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+	digest, err := h.digester.Build(user, week)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "json" {
+		utils.WriteJSON(w, http.StatusOK, digest)
+		return
+	}
+	rendered, err := helpers.RenderEmail(helpers.EmailWeeklyDigest, user.Language, digest)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	switch format {
+	case "", "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(rendered.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("Subject: " + rendered.Subject + "\n\n" + rendered.Text))
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("format must be html, text or json"))
+	}
+}
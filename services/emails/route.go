@@ -0,0 +1,84 @@
+package emails
+
+import (
+	"backend/helpers"
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// RegisterRoutes mounts the email previews for designers, staff only
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	staff := middleware.RequireRole(auth.RoleModerator, auth.RoleAdmin)
+	authRouter.Handle("/admin/emails", staff(http.HandlerFunc(h.HandleListTemplates))).Methods(http.MethodGet)
+	authRouter.Handle("/admin/emails/{name}/preview", staff(http.HandlerFunc(h.HandlePreview))).Methods(http.MethodGet)
+}
+
+// HandleListTemplates godoc
+//
+// @Summary 			List email templates
+// @Description 		List the transactional email templates and the supported locales (moderator or admin)
+// @Tags 				Emails
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.EmailTemplateList
+// @Failure 			403 {object} types.ErrorResponse
+// @Router 				/admin/emails [get]
+func (h *Handler) HandleListTemplates(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, types.EmailTemplateList{Templates: helpers.EmailTemplates, Locales: helpers.SupportedLocales})
+}
+
+// HandlePreview godoc
+//
+// @Summary 			Preview an email
+// @Description 		Render an email template with sample data without sending it (moderator or admin). With EMAIL_TEMPLATES_DIR set, template edits show up on reload.
+// @Tags 				Emails
+// @Produce 			html
+// @Produce 			plain
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				name path string true "Template name, e.g. verify_email"
+// @Param 				locale query string false "Locale (default en)"
+// @Param 				format query string false "html (default), text or json"
+// @Success 			200 {object} types.RenderedEmail
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/emails/{name}/preview [get]
+func (h *Handler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	data, ok := helpers.PreviewData(name)
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown email template %q", name))
+		return
+	}
+	rendered, err := helpers.RenderEmail(name, r.URL.Query().Get("locale"), data)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	switch r.URL.Query().Get("format") {
+	case "", "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(rendered.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("Subject: " + rendered.Subject + "\n\n" + rendered.Text))
+	case "json":
+		utils.WriteJSON(w, http.StatusOK, rendered)
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("format must be html, text or json"))
+	}
+}
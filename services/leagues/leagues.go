@@ -0,0 +1,128 @@
+package leagues
+
+import (
+	"backend/config"
+	"backend/types"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Tiers is the league ladder, everyone starts in the first one
+var Tiers = []types.LeagueTier{
+	{Tier: 1, Name: "Bronze"},
+	{Tier: 2, Name: "Silver"},
+	{Tier: 3, Name: "Gold"},
+	{Tier: 4, Name: "Sapphire"},
+	{Tier: 5, Name: "Ruby"},
+	{Tier: 6, Name: "Emerald"},
+	{Tier: 7, Name: "Amethyst"},
+	{Tier: 8, Name: "Pearl"},
+	{Tier: 9, Name: "Obsidian"},
+	{Tier: 10, Name: "Diamond"},
+}
+
+// Find returns the league of a tier, tiers out of the ladder are clamped
+func Find(tier int) types.LeagueTier {
+	return Tiers[min(max(tier, 1), len(Tiers))-1]
+}
+
+// Week returns the league week t falls in, from Monday (UTC)
+func Week(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return start, start.AddDate(0, 0, 7)
+}
+
+// NextTier returns the tier a member plays in after their week
+func NextTier(m *types.LeagueMember) int {
+	if m.Outcome == nil {
+		return m.Tier
+	}
+	switch *m.Outcome {
+	case types.LeaguePromoted:
+		return min(m.Tier+1, len(Tiers))
+	case types.LeagueRelegated:
+		return max(m.Tier-1, 1)
+	}
+	return m.Tier
+}
+
+// Zones returns how many members of a cohort of size move up and down. The
+// top and bottom leagues only move one way, and at most half of the cohort
+// moves each way so the zones never overlap.
+func Zones(tier int, size int) (promoted int, relegated int) {
+	if tier < len(Tiers) {
+		promoted = min(config.LeaguePromoted, size/2)
+	}
+	if tier > 1 {
+		relegated = min(config.LeagueRelegated, size/2)
+	}
+	return promoted, relegated
+}
+
+// Rank sets the placement and outcome of standings sorted by weekly XP.
+// Members without XP in the week are never promoted.
+func Rank(tier int, standings []types.LeagueStanding) {
+	promoted, relegated := Zones(tier, len(standings))
+	for i := range standings {
+		s := &standings[i]
+		s.Placement = i + 1
+		switch {
+		case i < promoted && s.XP > 0:
+			s.Outcome = types.LeaguePromoted
+		case i >= len(standings)-relegated:
+			s.Outcome = types.LeagueRelegated
+		default:
+			s.Outcome = types.LeagueStayed
+		}
+	}
+}
+
+// Assign splits the users of a tier into cohorts of at most
+// LeagueCohortSize, with sizes differing by one at most. The order is a hash
+// of the user and the week, so cohorts mix differently every week and the
+// result is the same on every run.
+func Assign(tier int, week time.Time, userIDs []int) []types.LeagueAssignment {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	users := append([]int(nil), userIDs...)
+	key := func(userID int) uint64 {
+		sum := sha256.Sum256(fmt.Appendf(nil, "%d|%s", userID, week.Format(time.DateOnly)))
+		return binary.BigEndian.Uint64(sum[:8])
+	}
+	sort.Slice(users, func(i, j int) bool { return key(users[i]) < key(users[j]) })
+	count := (len(users) + config.LeagueCohortSize - 1) / config.LeagueCohortSize
+	cohorts := make([]types.LeagueAssignment, count)
+	for i, userID := range users {
+		cohorts[i%count].Tier = tier
+		cohorts[i%count].UserIds = append(cohorts[i%count].UserIds, userID)
+	}
+	return cohorts
+}
+
+// rollover ranks the cohorts of a week and regroups the members who earned
+// XP into the cohorts of the next week
+func rollover(next time.Time, cohorts []types.LeagueCohort) []types.LeagueAssignment {
+	byTier := make(map[int][]int)
+	for _, c := range cohorts {
+		Rank(c.Tier, c.Standings)
+		for _, s := range c.Standings {
+			if s.XP <= 0 {
+				continue
+			}
+			outcome := s.Outcome
+			tier := NextTier(&types.LeagueMember{Tier: c.Tier, Outcome: &outcome})
+			byTier[tier] = append(byTier[tier], s.UserId)
+		}
+	}
+	assignments := make([]types.LeagueAssignment, 0)
+	for _, tier := range Tiers {
+		assignments = append(assignments, Assign(tier.Tier, next, byTier[tier.Tier])...)
+	}
+	return assignments
+}
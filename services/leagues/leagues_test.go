@@ -0,0 +1,158 @@
+package leagues
+
+import (
+	"backend/config"
+	"backend/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+func userIDs(n int) []int {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
+}
+
+func TestAssign(t *testing.T) {
+	week := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		users int
+		sizes []int
+	}{
+		{"nobody", 0, nil},
+		{"one user", 1, []int{1}},
+		{"one full cohort", config.LeagueCohortSize, []int{30}},
+		{"one over a cohort", config.LeagueCohortSize + 1, []int{16, 15}},
+		{"three cohorts", 2*config.LeagueCohortSize + 1, []int{21, 20, 20}},
+		{"four full cohorts", 4 * config.LeagueCohortSize, []int{30, 30, 30, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := userIDs(tt.users)
+			cohorts := Assign(3, week, ids)
+			if len(cohorts) != len(tt.sizes) {
+				t.Fatalf("got %d cohorts, want %d", len(cohorts), len(tt.sizes))
+			}
+			var assigned []int
+			for i, c := range cohorts {
+				if c.Tier != 3 {
+					t.Errorf("cohort %d is in tier %d, want 3", i, c.Tier)
+				}
+				if len(c.UserIds) != tt.sizes[i] {
+					t.Errorf("cohort %d has %d users, want %d", i, len(c.UserIds), tt.sizes[i])
+				}
+				assigned = append(assigned, c.UserIds...)
+			}
+			// every user lands in exactly one cohort
+			slices.Sort(assigned)
+			if !slices.Equal(assigned, ids) {
+				t.Errorf("assigned %v, want every user once", assigned)
+			}
+			if !slices.Equal(ids, userIDs(tt.users)) {
+				t.Errorf("Assign reordered its input")
+			}
+		})
+	}
+}
+
+func TestAssignMixesEveryWeek(t *testing.T) {
+	week := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	ids := userIDs(2 * config.LeagueCohortSize)
+	first := Assign(1, week, ids)
+	again := Assign(1, week, ids)
+	for i := range first {
+		if !slices.Equal(first[i].UserIds, again[i].UserIds) {
+			t.Fatalf("the cohorts of the same week differ between runs")
+		}
+	}
+	next := Assign(1, week.AddDate(0, 0, 7), ids)
+	if slices.Equal(first[0].UserIds, next[0].UserIds) {
+		t.Errorf("the cohorts of the next week are the same")
+	}
+}
+
+func TestZones(t *testing.T) {
+	top := len(Tiers)
+	tests := []struct {
+		name          string
+		tier, size    int
+		wantPromoted  int
+		wantRelegated int
+	}{
+		{"full cohort", 3, 30, 5, 5},
+		{"bottom league", 1, 30, 5, 0},
+		{"top league", top, 30, 0, 5},
+		{"small cohort", 3, 6, 3, 3},
+		{"odd cohort", 3, 5, 2, 2},
+		{"alone", 3, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promoted, relegated := Zones(tt.tier, tt.size)
+			if promoted != tt.wantPromoted || relegated != tt.wantRelegated {
+				t.Errorf("Zones(%d, %d) = %d, %d, want %d, %d", tt.tier, tt.size, promoted, relegated, tt.wantPromoted, tt.wantRelegated)
+			}
+		})
+	}
+}
+
+func TestRollover(t *testing.T) {
+	next := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	standings := func(firstID int, xp ...int) []types.LeagueStanding {
+		list := make([]types.LeagueStanding, len(xp))
+		for i := range xp {
+			list[i] = types.LeagueStanding{UserId: firstID + i, XP: xp[i]}
+		}
+		return list
+	}
+	// cohorts of six: three move up when they earned XP, three move down
+	cohorts := []types.LeagueCohort{
+		{Id: 1, Tier: 1, Standings: standings(10, 300, 200, 100, 50, 20, 10)},
+		{Id: 2, Tier: 2, Standings: standings(20, 400, 0, 0, 0, 0, 0)},
+	}
+	want := map[int][]int{
+		// nobody is relegated from the bottom league
+		1: {13, 14, 15},
+		2: {10, 11, 12},
+		// the members without XP are left out, even in the promotion zone
+		3: {20},
+	}
+	got := map[int][]int{}
+	for _, a := range rollover(next, cohorts) {
+		got[a.Tier] = append(got[a.Tier], a.UserIds...)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for tier, ids := range want {
+		slices.Sort(got[tier])
+		if !slices.Equal(got[tier], ids) {
+			t.Errorf("tier %d got %v, want %v", tier, got[tier], ids)
+		}
+	}
+}
+
+func TestWeek(t *testing.T) {
+	monday := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		at   time.Time
+	}{
+		{"monday midnight", monday},
+		{"wednesday", monday.AddDate(0, 0, 2).Add(15 * time.Hour)},
+		{"sunday night", monday.AddDate(0, 0, 7).Add(-time.Nanosecond)},
+		{"monday morning east of UTC", time.Date(2026, 3, 16, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*3600))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Week(tt.at)
+			if !start.Equal(monday) || !end.Equal(monday.AddDate(0, 0, 7)) {
+				t.Errorf("Week(%s) = %s, %s", tt.at, start, end)
+			}
+		})
+	}
+}
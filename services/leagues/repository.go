@@ -0,0 +1,295 @@
+package leagues
+
+import (
+	"backend/services/ranking"
+	"backend/types"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type LeagueRepoImpl struct {
+	db *sql.DB
+}
+
+func NewLeagueRepoImpl(db *sql.DB) *LeagueRepoImpl {
+	return &LeagueRepoImpl{db: db}
+}
+
+// the users are aliased u in every query
+var rankedUsers = ranking.RankedUsers("u")
+
+const memberColumns = "user_id, week_start, cohort_id, tier, joined_at, weekly_xp, placement, outcome"
+
+// earnedBetween keeps the transactions t earning XP between two placeholders
+var earnedBetween = "t.created_at >= ? AND t.created_at < ? AND t.reason IN (" +
+	strings.TrimSuffix(strings.Repeat("?,", len(types.EarnedXPReasons)), ",") + ")"
+
+// weeklyXP joins the XP earned by member m as t
+var weeklyXP = "LEFT JOIN xp_transactions t ON t.user_id = m.user_id AND " + earnedBetween
+
+// earnedArgs returns the arguments of earnedBetween
+func earnedArgs(from, to time.Time) []any {
+	args := []any{from, to}
+	for _, reason := range types.EarnedXPReasons {
+		args = append(args, reason)
+	}
+	return args
+}
+
+func (l *LeagueRepoImpl) GetLatestMembership(userID int) (*types.LeagueMember, error) {
+	return l.getMembership("SELECT "+memberColumns+" FROM league_members WHERE user_id = ? ORDER BY week_start DESC LIMIT 1", userID)
+}
+
+func (l *LeagueRepoImpl) GetMembership(userID int, week time.Time) (*types.LeagueMember, error) {
+	return l.getMembership("SELECT "+memberColumns+" FROM league_members WHERE user_id = ? AND week_start = ?", userID, week)
+}
+
+func (l *LeagueRepoImpl) getMembership(query string, args ...any) (*types.LeagueMember, error) {
+	var m types.LeagueMember
+	if err := scanRowIntoMember(l.db.QueryRow(query, args...), &m); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// lockWeek creates the week if needed and locks it, placements and the
+// rollover of a week are serialized on this row
+func lockWeek(tx *sql.Tx, week time.Time) (closedAt *time.Time, err error) {
+	if _, err := tx.Exec("INSERT IGNORE INTO league_weeks(week_start) VALUES (?)", week); err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow("SELECT closed_at FROM league_weeks WHERE week_start = ? FOR UPDATE", week).Scan(&closedAt)
+	return closedAt, err
+}
+
+func (l *LeagueRepoImpl) JoinLeague(userID int, week time.Time, tier int, cohortSize int, at time.Time) (*types.LeagueMember, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := lockWeek(tx, week); err != nil {
+		return nil, err
+	}
+	var m types.LeagueMember
+	row := tx.QueryRow("SELECT "+memberColumns+" FROM league_members WHERE user_id = ? AND week_start = ?", userID, week)
+	if err := scanRowIntoMember(row, &m); err == nil {
+		return &m, nil
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+	var cohortID int64
+	err = tx.QueryRow(`
+		SELECT c.id FROM league_cohorts c
+		LEFT JOIN league_members m ON m.cohort_id = c.id
+		WHERE c.week_start = ? AND c.tier = ?
+		GROUP BY c.id
+		HAVING COUNT(m.user_id) < ?
+		ORDER BY COUNT(m.user_id), c.id
+		LIMIT 1`,
+		week, tier, cohortSize,
+	).Scan(&cohortID)
+	if err == sql.ErrNoRows {
+		res, err := tx.Exec("INSERT INTO league_cohorts(week_start, tier) VALUES (?,?)", week, tier)
+		if err != nil {
+			return nil, err
+		}
+		if cohortID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec(
+		"INSERT INTO league_members(week_start, user_id, cohort_id, tier, joined_at) SELECT ?, u.id, ?, ?, ? FROM users u WHERE u.id = ? AND "+rankedUsers,
+		week, cohortID, tier, at, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// users left out of rankings do not play, the empty cohort goes with the rollback
+	if inserted, err := res.RowsAffected(); err != nil || inserted == 0 {
+		return nil, err
+	}
+	row = tx.QueryRow("SELECT "+memberColumns+" FROM league_members WHERE user_id = ? AND week_start = ?", userID, week)
+	if err := scanRowIntoMember(row, &m); err != nil {
+		return nil, err
+	}
+	return &m, tx.Commit()
+}
+
+func (l *LeagueRepoImpl) ListCohortStandings(cohortID int, from, to time.Time) ([]types.LeagueStanding, error) {
+	args := append(earnedArgs(from, to), cohortID)
+	rows, err := l.db.Query(`
+		SELECT m.user_id, u.username, GREATEST(COALESCE(SUM(t.delta), 0), 0) AS xp
+		FROM league_members m
+		JOIN users u ON u.id = m.user_id
+		`+weeklyXP+`
+		WHERE m.cohort_id = ? AND `+rankedUsers+`
+		GROUP BY m.user_id, u.username, m.joined_at
+		ORDER BY xp DESC, m.joined_at, m.user_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.LeagueStanding, 0)
+	for rows.Next() {
+		var s types.LeagueStanding
+		if err := rows.Scan(&s.UserId, &s.Username, &s.XP); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (l *LeagueRepoImpl) ListUnplacedUsers(from, to time.Time) ([]int, error) {
+	args := append(earnedArgs(from, to), from)
+	rows, err := l.db.Query(`
+		SELECT DISTINCT u.id
+		FROM xp_transactions t
+		JOIN users u ON u.id = t.user_id
+		WHERE `+earnedBetween+` AND `+rankedUsers+`
+		AND NOT EXISTS (SELECT 1 FROM league_members m WHERE m.week_start = ? AND m.user_id = u.id)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]int, 0)
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		list = append(list, userID)
+	}
+	return list, rows.Err()
+}
+
+func (l *LeagueRepoImpl) ListWeeksToClose(before time.Time) ([]time.Time, error) {
+	rows, err := l.db.Query("SELECT week_start FROM league_weeks WHERE closed_at IS NULL AND week_start < ? ORDER BY week_start", before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]time.Time, 0)
+	for rows.Next() {
+		var week time.Time
+		if err := rows.Scan(&week); err != nil {
+			return nil, err
+		}
+		list = append(list, week)
+	}
+	return list, rows.Err()
+}
+
+func (l *LeagueRepoImpl) CloseWeek(week time.Time, at time.Time, rollover func([]types.LeagueCohort) []types.LeagueAssignment) (bool, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	closedAt, err := lockWeek(tx, week)
+	if err != nil {
+		return false, err
+	}
+	if closedAt != nil {
+		return false, nil
+	}
+	cohorts, err := cohortStandings(tx, week)
+	if err != nil {
+		return false, err
+	}
+	assignments := rollover(cohorts)
+	next := week.AddDate(0, 0, 7)
+	if len(assignments) > 0 {
+		if _, err := lockWeek(tx, next); err != nil {
+			return false, err
+		}
+	}
+	for _, c := range cohorts {
+		for _, s := range c.Standings {
+			_, err := tx.Exec(
+				"UPDATE league_members SET weekly_xp = ?, placement = ?, outcome = ? WHERE week_start = ? AND user_id = ?",
+				s.XP, s.Placement, s.Outcome, week, s.UserId,
+			)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, a := range assignments {
+		res, err := tx.Exec("INSERT INTO league_cohorts(week_start, tier) VALUES (?,?)", next, a.Tier)
+		if err != nil {
+			return false, err
+		}
+		cohortID, err := res.LastInsertId()
+		if err != nil {
+			return false, err
+		}
+		for _, userID := range a.UserIds {
+			// members who already joined next week keep their cohort
+			_, err := tx.Exec(
+				"INSERT IGNORE INTO league_members(week_start, user_id, cohort_id, tier, joined_at) VALUES (?,?,?,?,?)",
+				next, userID, cohortID, a.Tier, at,
+			)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	if _, err := tx.Exec("UPDATE league_weeks SET closed_at = ? WHERE week_start = ?", at, week); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// cohortStandings reads the final standings of every cohort of the week,
+// members left out of rankings since they joined are skipped
+func cohortStandings(tx *sql.Tx, week time.Time) ([]types.LeagueCohort, error) {
+	rows, err := tx.Query(`
+		SELECT c.id, c.tier, m.user_id, u.username, GREATEST(COALESCE(SUM(t.delta), 0), 0) AS xp
+		FROM league_cohorts c
+		JOIN league_members m ON m.cohort_id = c.id
+		JOIN users u ON u.id = m.user_id
+		`+weeklyXP+`
+		WHERE c.week_start = ? AND `+rankedUsers+`
+		GROUP BY c.id, c.tier, m.user_id, u.username, m.joined_at
+		ORDER BY c.id, xp DESC, m.joined_at, m.user_id`,
+		append(earnedArgs(week, week.AddDate(0, 0, 7)), week)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cohorts := make([]types.LeagueCohort, 0)
+	for rows.Next() {
+		var id, tier int
+		var s types.LeagueStanding
+		if err := rows.Scan(&id, &tier, &s.UserId, &s.Username, &s.XP); err != nil {
+			return nil, err
+		}
+		if len(cohorts) == 0 || cohorts[len(cohorts)-1].Id != id {
+			cohorts = append(cohorts, types.LeagueCohort{Id: id, Tier: tier})
+		}
+		last := &cohorts[len(cohorts)-1]
+		last.Standings = append(last.Standings, s)
+	}
+	return cohorts, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoMember(row scanner, m *types.LeagueMember) error {
+	return row.Scan(&m.UserId, &m.WeekStart, &m.CohortId, &m.Tier, &m.JoinedAt, &m.WeeklyXP, &m.Placement, &m.Outcome)
+}
@@ -0,0 +1,53 @@
+package leagues
+
+import (
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	authRouter.Handle("/leagues/current", middleware.RequireScope(auth.ScopeRankingRead, h.HandleGetCurrentLeague)).Methods(http.MethodGet)
+}
+
+// HandleGetCurrentLeague godoc
+//
+// @Summary 			Get my current league
+// @Description 		The league and cohort of the authenticated user this week (from Monday UTC), with the standings by weekly XP and the outcome of each member if the week ended now. Users are placed on their first XP of the week or on the first call.
+// @Tags 				Leagues
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.LeagueResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/leagues/current [get]
+func (h *Handler) HandleGetCurrentLeague(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	league, err := h.service.Current(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if league == nil {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("guests and accounts scheduled for deletion do not play in leagues"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, league)
+}
@@ -0,0 +1,145 @@
+package leagues
+
+import (
+	"backend/config"
+	"backend/services/notifications"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"time"
+)
+
+// Service places users in weekly cohorts and rolls the weeks over. It reads
+// the time from its clock only.
+type Service struct {
+	store    types.LeagueRepo
+	notifier types.Notifier
+	clock    utils.Clock
+}
+
+func NewService(store types.LeagueRepo, notifier types.Notifier, clock utils.Clock) *Service {
+	return &Service{store: store, notifier: notifier, clock: clock}
+}
+
+// Run rolls the ended weeks over and places the users who earned XP this
+// week. It is idempotent and meant to run regularly.
+func (s *Service) Run() error {
+	if err := s.Rollover(); err != nil {
+		return err
+	}
+	start, end := Week(s.clock.Now())
+	users, err := s.store.ListUnplacedUsers(start, end)
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		if _, err := s.join(userID, start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollover closes the weeks that ended, oldest first. Closing a week can
+// open the next one, which is closed too when it already ended.
+func (s *Service) Rollover() error {
+	now := s.clock.Now()
+	current, _ := Week(now)
+	for {
+		weeks, err := s.store.ListWeeksToClose(current)
+		if err != nil {
+			return err
+		}
+		if len(weeks) == 0 {
+			return nil
+		}
+		for _, week := range weeks {
+			var results []types.LeagueCohort
+			closed, err := s.store.CloseWeek(week, now, func(cohorts []types.LeagueCohort) []types.LeagueAssignment {
+				results = cohorts
+				return rollover(week.AddDate(0, 0, 7), cohorts)
+			})
+			if err != nil {
+				return err
+			}
+			if closed {
+				s.announce(results)
+			}
+		}
+	}
+}
+
+// Current returns the cohort of the user this week, placing them first if
+// needed. It returns nil for users left out of rankings.
+func (s *Service) Current(userID int) (*types.LeagueResponse, error) {
+	if err := s.Rollover(); err != nil {
+		return nil, err
+	}
+	start, end := Week(s.clock.Now())
+	member, err := s.store.GetMembership(userID, start)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		if member, err = s.join(userID, start); err != nil || member == nil {
+			return nil, err
+		}
+	}
+	standings, err := s.store.ListCohortStandings(member.CohortId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	Rank(member.Tier, standings)
+	promoted, relegated := Zones(member.Tier, len(standings))
+	response := &types.LeagueResponse{
+		WeekStart: start,
+		EndsAt:    end,
+		League:    Find(member.Tier),
+		CohortId:  member.CohortId,
+		Promoted:  promoted,
+		Relegated: relegated,
+		Standings: standings,
+	}
+	for i := range standings {
+		if standings[i].UserId == userID {
+			response.Me = &standings[i]
+		}
+	}
+	return response, nil
+}
+
+// join places the user in the tier their last week earned them
+func (s *Service) join(userID int, week time.Time) (*types.LeagueMember, error) {
+	tier := 1
+	latest, err := s.store.GetLatestMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		tier = NextTier(latest)
+	}
+	return s.store.JoinLeague(userID, week, tier, config.LeagueCohortSize, s.clock.Now())
+}
+
+// announce tells the members who moved where they play next week
+func (s *Service) announce(cohorts []types.LeagueCohort) {
+	for _, c := range cohorts {
+		from := Find(c.Tier)
+		for _, standing := range c.Standings {
+			var title string
+			switch standing.Outcome {
+			case types.LeaguePromoted:
+				title = fmt.Sprintf("You moved up to the %s League", Find(c.Tier+1).Name)
+			case types.LeagueRelegated:
+				title = fmt.Sprintf("You moved down to the %s League", Find(c.Tier-1).Name)
+			default:
+				continue
+			}
+			notifications.Send(s.notifier, standing.UserId, types.NotificationLeague,
+				title,
+				fmt.Sprintf("You finished #%d of your %s cohort with %d XP.", standing.Placement, from.Name, standing.XP),
+				map[string]any{"cohort_id": c.Id, "placement": standing.Placement, "outcome": standing.Outcome},
+			)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package notifications
+
+import (
+	"backend/types"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Send notifies the user, data is passed to clients as JSON so they can link to
+// the related resource. Failures are only logged so notifications never block the caller.
+func Send(notifier types.Notifier, userID int, category, title, body string, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to encode notification data for %s: %v", category, err)
+		encoded = []byte("{}")
+	}
+	n := &types.Notification{
+		UserId:    userID,
+		Category:  category,
+		Title:     title,
+		Body:      body,
+		Data:      encoded,
+		CreatedAt: time.Now(),
+	}
+	if err := notifier.Notify(n); err != nil {
+		log.Printf("failed to notify user %d (%s): %v", userID, category, err)
+	}
+}
@@ -0,0 +1,134 @@
+package notifications
+
+import (
+	"backend/types"
+	"database/sql"
+	"time"
+)
+
+type NotificationRepoImpl struct {
+	db *sql.DB
+}
+
+func NewNotificationRepoImpl(db *sql.DB) *NotificationRepoImpl {
+	return &NotificationRepoImpl{db: db}
+}
+
+const notificationColumns = "id, user_id, category, title, body, data, read_at, created_at"
+
+func (n *NotificationRepoImpl) Notify(e *types.Notification) error {
+	res, err := n.db.Exec(`
+		INSERT INTO notifications(user_id, category, title, body, data, created_at)
+		SELECT ?, ?, ?, ?, ?, ? FROM DUAL
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences WHERE user_id = ? AND category = ? AND enabled = FALSE
+		)`,
+		e.UserId, e.Category, e.Title, e.Body, e.Data, e.CreatedAt, e.UserId, e.Category,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return err
+	}
+	e.Id, err = res.LastInsertId()
+	return err
+}
+
+func (n *NotificationRepoImpl) ListNotifications(userID int, unreadOnly bool, limit, offset int) ([]types.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE user_id = ?"
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	rows, err := n.db.Query(query+" ORDER BY id DESC LIMIT ? OFFSET ?", userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.Notification, 0)
+	for rows.Next() {
+		var e types.Notification
+		if err := rows.Scan(&e.Id, &e.UserId, &e.Category, &e.Title, &e.Body, &e.Data, &e.ReadAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (n *NotificationRepoImpl) CountUnread(userID int) (int, error) {
+	var count int
+	err := n.db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+func (n *NotificationRepoImpl) MarkRead(userID int, id int64, at time.Time) (bool, error) {
+	var found bool
+	err := n.db.QueryRow("SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)", id, userID).Scan(&found)
+	if err != nil || !found {
+		return false, err
+	}
+	_, err = n.db.Exec("UPDATE notifications SET read_at = ? WHERE id = ? AND read_at IS NULL", at, id)
+	return err == nil, err
+}
+
+func (n *NotificationRepoImpl) MarkAllRead(userID int, at time.Time) (int64, error) {
+	res, err := n.db.Exec("UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL", at, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetPreferences lists every category, the ones without a row are enabled
+func (n *NotificationRepoImpl) GetPreferences(userID int) ([]types.NotificationPreference, error) {
+	rows, err := n.db.Query("SELECT category, enabled FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stored := map[string]bool{}
+	for rows.Next() {
+		var category string
+		var enabled bool
+		if err := rows.Scan(&category, &enabled); err != nil {
+			return nil, err
+		}
+		stored[category] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	list := make([]types.NotificationPreference, 0, len(types.NotificationCategories))
+	for _, category := range types.NotificationCategories {
+		enabled, ok := stored[category]
+		list = append(list, types.NotificationPreference{Category: category, Enabled: enabled || !ok})
+	}
+	return list, nil
+}
+
+func (n *NotificationRepoImpl) SetPreferences(userID int, preferences map[string]bool) error {
+	tx, err := n.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for category, enabled := range preferences {
+		_, err := tx.Exec(
+			"INSERT INTO notification_preferences(user_id, category, enabled) VALUES (?,?,?) ON DUPLICATE KEY UPDATE enabled = VALUES(enabled)",
+			userID, category, enabled,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (n *NotificationRepoImpl) PurgeReadNotifications(before time.Time) (int64, error) {
+	res, err := n.db.Exec("DELETE FROM notifications WHERE read_at IS NOT NULL AND read_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,205 @@
+package notifications
+
+import (
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store types.NotificationRepo
+}
+
+func NewHandler(store types.NotificationRepo) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	authRouter.HandleFunc("/notifications", h.HandleListNotifications).Methods(http.MethodGet)
+	authRouter.HandleFunc("/notifications/read-all", h.HandleMarkAllRead).Methods(http.MethodPost)
+	authRouter.HandleFunc("/notifications/{id}/read", h.HandleMarkRead).Methods(http.MethodPost)
+	authRouter.HandleFunc("/me/notification-preferences", h.HandleGetPreferences).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/notification-preferences", h.HandleUpdatePreferences).Methods(http.MethodPut)
+}
+
+// HandleListNotifications godoc
+//
+// @Summary 			List my notifications
+// @Description 		Rank changes, streaks, achievements and other activity for the authenticated user, newest first, with the unread count
+// @Tags 				Notifications
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				unread query bool false "Only unread notifications"
+// @Param 				limit query int false "Page size (default 50)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {object} types.NotificationPage
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/notifications [get]
+func (h *Handler) HandleListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	unreadOnly, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+	list, err := h.store.ListNotifications(userID, unreadOnly, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	unread, err := h.store.CountUnread(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.NotificationPage{Notifications: list, UnreadCount: unread, Limit: limit, Offset: offset})
+}
+
+// HandleMarkRead godoc
+//
+// @Summary 			Mark a notification as read
+// @Tags 				Notifications
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Notification ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/notifications/{id}/read [post]
+func (h *Handler) HandleMarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid notification id"))
+		return
+	}
+	found, err := h.store.MarkRead(userID, id, time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("notification not found"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Notification marked as read"})
+}
+
+// HandleMarkAllRead godoc
+//
+// @Summary 			Mark all my notifications as read
+// @Tags 				Notifications
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.MarkAllReadResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/notifications/read-all [post]
+func (h *Handler) HandleMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	updated, err := h.store.MarkAllRead(userID, time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.MarkAllReadResponse{Updated: updated})
+}
+
+// HandleGetPreferences godoc
+//
+// @Summary 			Get my notification preferences
+// @Description 		List every notification category and whether it is enabled
+// @Tags 				Notifications
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.NotificationPreference
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/notification-preferences [get]
+func (h *Handler) HandleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.store.GetPreferences(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleUpdatePreferences godoc
+//
+// @Summary 			Update my notification preferences
+// @Description 		Enable or mute notification categories, e.g. {"preferences": {"social": false}}. Categories left out keep their setting.
+// @Tags 				Notifications
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.UpdateNotificationPreferencesPayload true "Preferences by category"
+// @Success 			200 {array} types.NotificationPreference
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/notification-preferences [put]
+func (h *Handler) HandleUpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.UpdateNotificationPreferencesPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	for category := range payload.Preferences {
+		if !types.IsNotificationCategory(category) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown notification category %q", category))
+			return
+		}
+	}
+	if err := h.store.SetPreferences(userID, payload.Preferences); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list, err := h.store.GetPreferences(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
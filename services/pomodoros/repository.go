@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type PomodoroRepoImpl struct {
@@ -15,8 +16,15 @@ func NewPomodoroRepoImpl(db *sql.DB) *PomodoroRepoImpl {
 	return &PomodoroRepoImpl{db: db}
 }
 
+// AddPomodoro saves the session and counts a completed focus session in the
+// heatmap of the day it ended (UTC), which only the server writes
 func (p *PomodoroRepoImpl) AddPomodoro(payload types.AddingPomodoroPayload) (*types.Pomodoro, error) {
-	res, err := p.db.Exec(
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(
 		"insert into pomodoros(user_id,type,completed,session_duration,start_time,end_time) values (?,?,?,?,?,?)",
 		payload.UserId,
 		payload.Type,
@@ -31,10 +39,32 @@ func (p *PomodoroRepoImpl) AddPomodoro(payload types.AddingPomodoroPayload) (*ty
 		}
 		return nil, err
 	}
+	if payload.Type == "pomodoro" && payload.Completed {
+		_, err := tx.Exec(
+			"INSERT INTO heatmap (user_id, date, count) VALUES (?, ?, 1) ON DUPLICATE KEY UPDATE count = count + 1",
+			payload.UserId, payload.EndTime.UTC().Format(time.DateOnly),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	id, _ := res.LastInsertId()
 	return p.getPomodoroById(id)
 }
 
+// CountCompletedPomodoros counts the completed focus sessions, breaks excluded
+func (p *PomodoroRepoImpl) CountCompletedPomodoros(userID int) (int, error) {
+	var count int
+	err := p.db.QueryRow(
+		"SELECT COUNT(*) FROM pomodoros WHERE user_id = ? AND type = 'pomodoro' AND completed = TRUE",
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
 func (p *PomodoroRepoImpl) getPomodoroById(id int64) (*types.Pomodoro, error) {
 	var pomodoro types.Pomodoro
 	res := p.db.QueryRow("Select * from pomodoros where id = ?", id)
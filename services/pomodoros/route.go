@@ -1,29 +1,44 @@
 package pomodoros
 
 import (
+	"backend/config"
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/services/notifications"
 	"backend/types"
 	"backend/utils"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
-	store types.PomodoroRepo
+	store        types.PomodoroRepo
+	scorer       types.SessionScorer
+	notifier     types.Notifier
+	achievements types.AchievementEvaluator
+	quests       types.QuestTracker
+	streaks      types.StreakTracker
 }
 
-func NewHandler(store types.PomodoroRepo) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.PomodoroRepo, scorer types.SessionScorer, notifier types.Notifier, achievements types.AchievementEvaluator, quests types.QuestTracker, streaks types.StreakTracker) *Handler {
+	return &Handler{store: store, scorer: scorer, notifier: notifier, achievements: achievements, quests: quests, streaks: streaks}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/pomodoro", h.HandleAddingPomodoro).Methods(http.MethodPost)
+	router.Handle("/pomodoro", middleware.RequireScope(auth.ScopePomodorosWrite, h.HandleAddingPomodoro)).Methods(http.MethodPost)
 }
 
 //	 HandleAddingPomodoro godoc
 //
 //		@Summary 			Add a new pomodoro session
-//		@Description 		Create a new pomodoro session for a user
+//		@Description 		Create a new pomodoro session for a user. The type is pomodoro, short break or long break, and session_duration (minutes) must be positive, fit between start_time and end_time and stay under MAX_SESSION_MINUTES. The first completed focus session of the day (UTC) extends the streak. Focus sessions are scored with the XP rules (see /xp/rules) and recorded in the XP ledger: completed ones earn XP and unlock the achievements they complete, abandoned ones can cost a penalty. Completed sessions and breaks count toward the current quests.
 //		@Tags 				pomodoros
 //		@Accept 			json
 //		@Produce 			json
@@ -31,6 +46,7 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 //		@Success 			201 {object} types.Pomodoro
 //		@Failure 			400 {object} types.ErrorResponse
 //		@Failure 			401 {object} types.ErrorResponse
+//		@Failure 			403 {object} types.ErrorResponse
 //		@Failure 			500 {object} types.ErrorResponse
 //		@Param 				request body types.AddingPomodoroPayload true "Pomodoro request payload"
 //	 @Router 			/pomodoro [post]
@@ -41,10 +57,84 @@ func (h *Handler) HandleAddingPomodoro(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	if err := auth.CheckCanActOn(r, payload.UserId); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+	if err := validatePomodoro(&payload, int(config.Envs.MaxSessionMinutes)); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 	pomodoro, err := h.store.AddPomodoro(payload)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	var streakUnlocked []types.UnlockedAchievement
+	if pomodoro.Type == "pomodoro" && pomodoro.Completed {
+		// the first session of the day extends the streak before it is scored
+		streakUnlocked, err = h.streaks.RecordSession(pomodoro.UserId)
+		if err != nil {
+			log.Printf("failed to update the streak of user %d: %v", pomodoro.UserId, err)
+		}
+	}
+	// abandoned focus sessions are scored too, the rules can take a penalty
+	if t := h.scorer.Award(pomodoro); t != nil {
+		pomodoro.XPAwarded = t.Delta
+	}
+	if pomodoro.Type == "pomodoro" && pomodoro.Completed {
+		h.notifyMilestone(pomodoro.UserId)
+		unlocked, err := h.achievements.Evaluate(pomodoro.UserId, time.Now())
+		if err != nil {
+			log.Printf("failed to evaluate the achievements of user %d: %v", pomodoro.UserId, err)
+		}
+		pomodoro.AchievementsUnlocked = append(streakUnlocked, unlocked...)
+	}
+	if err := h.quests.TrackPomodoro(pomodoro); err != nil {
+		log.Printf("failed to track the quests of user %d: %v", pomodoro.UserId, err)
+	}
 	utils.WriteJSON(w, http.StatusCreated, pomodoro)
 }
+
+// validatePomodoro checks the session before it is saved and scored: the
+// duration is in minutes and must fit between start_time and end_time, which
+// allows a minute of rounding, and stay under maxMinutes
+func validatePomodoro(payload *types.AddingPomodoroPayload, maxMinutes int) error {
+	if !slices.Contains(types.PomodoroTypes, payload.Type) {
+		return fmt.Errorf("type must be one of %s", strings.Join(types.PomodoroTypes, ", "))
+	}
+	if payload.StartTime.IsZero() || payload.EndTime.IsZero() {
+		return fmt.Errorf("start_time and end_time are required")
+	}
+	if !payload.EndTime.After(payload.StartTime) {
+		return fmt.Errorf("end_time must be after start_time")
+	}
+	if payload.SessionDuration <= 0 {
+		return fmt.Errorf("session_duration must be positive")
+	}
+	if payload.SessionDuration > maxMinutes {
+		return fmt.Errorf("session_duration cannot exceed %d minutes", maxMinutes)
+	}
+	elapsed := int(math.Ceil(payload.EndTime.Sub(payload.StartTime).Minutes()))
+	if payload.SessionDuration > elapsed {
+		return fmt.Errorf("session_duration is longer than the %d minutes between start_time and end_time", elapsed)
+	}
+	return nil
+}
+
+// notifyMilestone celebrates the first completed focus session and every hundredth
+func (h *Handler) notifyMilestone(userID int) {
+	count, err := h.store.CountCompletedPomodoros(userID)
+	if err != nil {
+		log.Printf("failed to count pomodoros of user %d: %v", userID, err)
+		return
+	}
+	if count != 1 && count%100 != 0 {
+		return
+	}
+	title := fmt.Sprintf("%d pomodoros completed!", count)
+	if count == 1 {
+		title = "First pomodoro completed!"
+	}
+	notifications.Send(h.notifier, userID, types.NotificationMilestone, title, "Keep the focus going.", map[string]any{"pomodoros": count})
+}
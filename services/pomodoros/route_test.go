@@ -0,0 +1,42 @@
+package pomodoros
+
+import (
+	"backend/types"
+	"testing"
+	"time"
+)
+
+func TestValidatePomodoro(t *testing.T) {
+	start := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	session := func(kind string, minutes int, elapsed time.Duration) types.AddingPomodoroPayload {
+		return types.AddingPomodoroPayload{UserId: 1, Type: kind, Completed: true, SessionDuration: minutes, StartTime: start, EndTime: start.Add(elapsed)}
+	}
+	tests := []struct {
+		name    string
+		payload types.AddingPomodoroPayload
+		wantErr bool
+	}{
+		{"focus session", session("pomodoro", 25, 25*time.Minute), false},
+		{"short break", session("short break", 5, 5*time.Minute), false},
+		{"long break", session("long break", 15, 20*time.Minute), false},
+		{"rounded up minute", session("pomodoro", 25, 24*time.Minute+30*time.Second), false},
+		{"longest session", session("pomodoro", 120, 2*time.Hour), false},
+		{"unknown type", session("nap", 25, 25*time.Minute), true},
+		{"empty type", session("", 25, 25*time.Minute), true},
+		{"zero duration", session("pomodoro", 0, 25*time.Minute), true},
+		{"negative duration", session("pomodoro", -25, 25*time.Minute), true},
+		{"over the maximum", session("pomodoro", 121, 3*time.Hour), true},
+		{"longer than its times", session("pomodoro", 50, 25*time.Minute), true},
+		{"end before start", session("pomodoro", 25, -25*time.Minute), true},
+		{"no end time", types.AddingPomodoroPayload{Type: "pomodoro", SessionDuration: 25, StartTime: start}, true},
+		{"no start time", types.AddingPomodoroPayload{Type: "pomodoro", SessionDuration: 25, EndTime: start}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := tt.payload
+			if err := validatePomodoro(&payload, 120); (err != nil) != tt.wantErr {
+				t.Errorf("validatePomodoro() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package push
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// a single aes128gcm record carries the whole message
+	recordSize = 4096
+	// salt, record size, key id length and the 65 bytes key id
+	headerSize = 16 + 4 + 1 + 65
+	// push services accept at least 4096 bytes of encrypted body
+	MaxPayloadSize = recordSize - headerSize - 16 - 1
+)
+
+// Encrypt encrypts the payload for a subscription as described in RFC 8291
+// (Message Encryption for Web Push) with the aes128gcm content coding of RFC 8188.
+// p256dh is the uncompressed P-256 public key of the browser and auth its 16 bytes secret.
+func Encrypt(payload, p256dh, auth []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("push payload is %d bytes, the maximum is %d", len(payload), MaxPayloadSize)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	cek, nonce, err := deriveKeys(asPrivate, uaPublic, p256dh, asPublic, auth, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 marks the last record, no extra padding
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(recordSize))
+	body.WriteByte(byte(len(asPublic)))
+	body.Write(asPublic)
+	body.Write(gcm.Seal(nil, nonce, plaintext, nil))
+	return body.Bytes(), nil
+}
+
+// Decrypt is the browser side of Encrypt, used by the local push service stub
+func Decrypt(body []byte, uaPrivate *ecdh.PrivateKey, auth []byte) ([]byte, error) {
+	if len(body) < 21 {
+		return nil, fmt.Errorf("push message too short")
+	}
+	salt := body[:16]
+	idLength := int(body[20])
+	if len(body) < 21+idLength {
+		return nil, fmt.Errorf("push message too short")
+	}
+	asPublicBytes := body[21 : 21+idLength]
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key id: %w", err)
+	}
+	cek, nonce, err := deriveKeys(uaPrivate, asPublic, uaPrivate.PublicKey().Bytes(), asPublicBytes, auth, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLength:], nil)
+	if err != nil {
+		return nil, err
+	}
+	// strip the padding and the delimiter
+	plaintext = bytes.TrimRight(plaintext, "\x00")
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		return nil, fmt.Errorf("invalid padding delimiter")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
+
+// deriveKeys returns the content encryption key and nonce shared by the
+// application server (as) and the user agent (ua)
+func deriveKeys(private *ecdh.PrivateKey, peer *ecdh.PublicKey, uaPublic, asPublic, auth, salt []byte) (cek []byte, nonce []byte, err error) {
+	secret, err := private.ECDH(peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	prkKey, err := hkdf.Extract(sha256.New, secret, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cek, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16); err != nil {
+		return nil, nil, err
+	}
+	if nonce, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12); err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,122 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func newBrowserKeys(t *testing.T) (*ecdh.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+	return key, auth
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, auth := newBrowserKeys(t)
+	for _, payload := range [][]byte{
+		[]byte(`{"title":"Rank up! You reached Gold I"}`),
+		{},
+		bytes.Repeat([]byte("x"), MaxPayloadSize),
+	} {
+		body, err := Encrypt(payload, key.PublicKey().Bytes(), auth)
+		if err != nil {
+			t.Fatalf("Encrypt(%d bytes): %v", len(payload), err)
+		}
+		if len(body) > recordSize {
+			t.Errorf("a %d bytes payload gives a %d bytes body, over the %d bytes record", len(payload), len(body), recordSize)
+		}
+		got, err := Decrypt(body, key, auth)
+		if err != nil {
+			t.Fatalf("Decrypt(%d bytes): %v", len(payload), err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("got %q back, want %q", got, payload)
+		}
+	}
+}
+
+func TestEncryptUsesFreshKeys(t *testing.T) {
+	key, auth := newBrowserKeys(t)
+	payload := []byte("same message")
+	first, err := Encrypt(payload, key.PublicKey().Bytes(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Encrypt(payload, key.PublicKey().Bytes(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("two encryptions of the same payload are identical")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	key, auth := newBrowserKeys(t)
+	body, err := Encrypt([]byte("secret"), key.PublicKey().Bytes(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, otherAuth := newBrowserKeys(t)
+	tampered := bytes.Clone(body)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name string
+		body []byte
+		key  *ecdh.PrivateKey
+		auth []byte
+	}{
+		{"another browser key", body, otherKey, auth},
+		{"another auth secret", body, key, otherAuth},
+		{"tampered body", tampered, key, auth},
+		{"truncated body", body[:20], key, auth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.body, tt.key, tt.auth); err == nil {
+				t.Error("Decrypt succeeded")
+			}
+		})
+	}
+}
+
+func TestEncryptRejectsLargePayloads(t *testing.T) {
+	key, auth := newBrowserKeys(t)
+	if _, err := Encrypt(make([]byte, MaxPayloadSize+1), key.PublicKey().Bytes(), auth); err == nil {
+		t.Error("Encrypt accepted a payload over MaxPayloadSize")
+	}
+}
+
+// the example of RFC 8291 section 5, encrypted by another implementation
+func TestDecryptRFC8291Example(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	key, err := ecdh.P256().NewPrivateKey(decode("q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := decode("BTBZMqHH6r4Tts7J_aSIgg")
+	body := decode("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+	got, err := Decrypt(body, key, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "When I grow up, I want to be a watermelon"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package push
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// carrier-grade NAT, not covered by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublic tells if the server may connect to ip on behalf of a user
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// checkEndpoint refuses the endpoints the server must not post to. Push
+// services are public https hosts, anything else would let users make the
+// server reach internal addresses. allowLocal lets the push stub run on
+// http://localhost in development.
+func checkEndpoint(raw string, allowLocal bool) error {
+	endpoint, err := url.Parse(raw)
+	if err != nil || endpoint.Hostname() == "" || endpoint.User != nil {
+		return fmt.Errorf("invalid endpoint")
+	}
+	if allowLocal && (endpoint.Scheme == "https" || endpoint.Scheme == "http") {
+		return nil
+	}
+	if endpoint.Scheme != "https" {
+		return fmt.Errorf("the endpoint must use https")
+	}
+	host := strings.ToLower(endpoint.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("the endpoint must be a public host")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublic(ip) {
+		return fmt.Errorf("the endpoint must be a public host")
+	}
+	return nil
+}
+
+// newClient returns the client posting to push services. Unless allowLocal is
+// set, it only connects to public addresses: the check runs on the resolved
+// address, so a public name pointing to an internal address is refused too.
+func newClient(allowLocal bool) *http.Client {
+	if allowLocal {
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublic(ip) {
+				return fmt.Errorf("refusing to connect to %s", address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would be dialed instead of the push service
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+		// push services answer directly, redirects are not followed
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package push
+
+import (
+	"backend/types"
+	"log"
+)
+
+// Notifier stores notifications with next and also pushes them to the
+// devices of the user, muted categories are not pushed either
+type Notifier struct {
+	next   types.Notifier
+	pusher types.Pusher
+}
+
+func NewNotifier(next types.Notifier, pusher types.Pusher) *Notifier {
+	return &Notifier{next: next, pusher: pusher}
+}
+
+func (n *Notifier) Notify(e *types.Notification) error {
+	if err := n.next.Notify(e); err != nil || e.Id == 0 {
+		return err
+	}
+	message := types.PushMessage{Title: e.Title, Body: e.Body, Tag: e.Category, URL: "/notifications"}
+	if err := n.pusher.Push(e.UserId, message); err != nil {
+		log.Printf("failed to push notification %d: %v", e.Id, err)
+	}
+	return nil
+}
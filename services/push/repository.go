@@ -0,0 +1,160 @@
+package push
+
+import (
+	"backend/types"
+	"backend/utils"
+	"database/sql"
+	"time"
+)
+
+type PushRepoImpl struct {
+	db *sql.DB
+}
+
+func NewPushRepoImpl(db *sql.DB) *PushRepoImpl {
+	return &PushRepoImpl{db: db}
+}
+
+const subscriptionColumns = "id, user_id, endpoint, p256dh, auth, user_agent, expires_at, created_at, last_success_at"
+
+func (p *PushRepoImpl) SaveSubscription(s *types.PushSubscription) error {
+	_, err := p.db.Exec(`
+		INSERT INTO push_subscriptions(user_id, endpoint, endpoint_hash, p256dh, auth, user_agent, expires_at, created_at)
+		VALUES (?,?,?,?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE user_id = VALUES(user_id), p256dh = VALUES(p256dh), auth = VALUES(auth),
+			user_agent = VALUES(user_agent), expires_at = VALUES(expires_at)`,
+		s.UserId, s.Endpoint, utils.HashToken(s.Endpoint), s.P256dh, s.Auth, s.UserAgent, s.ExpiresAt, s.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return p.db.QueryRow("SELECT id, created_at FROM push_subscriptions WHERE endpoint_hash = ?", utils.HashToken(s.Endpoint)).
+		Scan(&s.Id, &s.CreatedAt)
+}
+
+func (p *PushRepoImpl) ListUserSubscriptions(userID int) ([]types.PushSubscription, error) {
+	rows, err := p.db.Query("SELECT "+subscriptionColumns+" FROM push_subscriptions WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.PushSubscription, 0)
+	for rows.Next() {
+		var s types.PushSubscription
+		err := rows.Scan(&s.Id, &s.UserId, &s.Endpoint, &s.P256dh, &s.Auth, &s.UserAgent, &s.ExpiresAt, &s.CreatedAt, &s.LastSuccessAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func (p *PushRepoImpl) DeleteSubscription(userID int, id int) (bool, error) {
+	res, err := p.db.Exec("DELETE FROM push_subscriptions WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (p *PushRepoImpl) DeleteSubscriptionByEndpoint(endpoint string) error {
+	_, err := p.db.Exec("DELETE FROM push_subscriptions WHERE endpoint_hash = ?", utils.HashToken(endpoint))
+	return err
+}
+
+func (p *PushRepoImpl) MarkSubscriptionUsed(id int, at time.Time) error {
+	_, err := p.db.Exec("UPDATE push_subscriptions SET last_success_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+func (p *PushRepoImpl) PurgeExpiredSubscriptions(now time.Time) (int64, error) {
+	res, err := p.db.Exec("DELETE FROM push_subscriptions WHERE expires_at IS NOT NULL AND expires_at < ?", now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *PushRepoImpl) SchedulePush(s *types.ScheduledPush) error {
+	_, err := p.db.Exec(`
+		INSERT INTO scheduled_pushes(user_id, kind, title, body, send_at) VALUES (?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE title = VALUES(title), body = VALUES(body), send_at = VALUES(send_at)`,
+		s.UserId, s.Kind, s.Title, s.Body, s.SendAt,
+	)
+	return err
+}
+
+func (p *PushRepoImpl) CancelScheduledPush(userID int, kind string) (bool, error) {
+	res, err := p.db.Exec("DELETE FROM scheduled_pushes WHERE user_id = ? AND kind = ?", userID, kind)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// TakeDuePushes locks the due rows so two servers never send the same push
+func (p *PushRepoImpl) TakeDuePushes(now time.Time) ([]types.ScheduledPush, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query("SELECT user_id, kind, title, body, send_at FROM scheduled_pushes WHERE send_at <= ? FOR UPDATE", now)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]types.ScheduledPush, 0)
+	for rows.Next() {
+		var s types.ScheduledPush
+		if err := rows.Scan(&s.UserId, &s.Kind, &s.Title, &s.Body, &s.SendAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		if _, err := tx.Exec("DELETE FROM scheduled_pushes WHERE user_id = ? AND kind = ?", s.UserId, s.Kind); err != nil {
+			return nil, err
+		}
+	}
+	return list, tx.Commit()
+}
+
+func (p *PushRepoImpl) ListStreakAtRisk(day time.Time) ([]int, error) {
+	rows, err := p.db.Query(`
+		SELECT s.user_id FROM stats s
+		WHERE s.current_streak > 0
+		AND EXISTS (SELECT 1 FROM push_subscriptions ps WHERE ps.user_id = s.user_id)
+		AND NOT EXISTS (
+			SELECT 1 FROM pomodoros p
+			WHERE p.user_id = s.user_id AND p.type = 'pomodoro' AND p.completed = TRUE AND p.start_time >= ?
+		)
+		AND NOT EXISTS (SELECT 1 FROM push_reminders r WHERE r.user_id = s.user_id AND r.kind = ? AND r.day = ?)`,
+		day, types.PushStreakAtRisk, day,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (p *PushRepoImpl) RecordReminder(userID int, kind string, day time.Time) error {
+	_, err := p.db.Exec("INSERT IGNORE INTO push_reminders(user_id, kind, day) VALUES (?,?,?)", userID, kind, day)
+	return err
+}
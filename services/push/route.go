@@ -0,0 +1,280 @@
+package push
+
+import (
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store     types.PushRepo
+	pusher    types.Pusher
+	publicKey string
+	// development only, see checkEndpoint
+	allowLocal bool
+}
+
+func NewHandler(store types.PushRepo, pusher types.Pusher, publicKey string, allowLocal bool) *Handler {
+	return &Handler{store: store, pusher: pusher, publicKey: publicKey, allowLocal: allowLocal}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
+	router.HandleFunc("/push/vapid-public-key", h.HandleGetPublicKey).Methods(http.MethodGet)
+
+	authRouter.HandleFunc("/me/push-subscriptions", h.HandleSubscribe).Methods(http.MethodPost)
+	authRouter.HandleFunc("/me/push-subscriptions", h.HandleListSubscriptions).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/push-subscriptions/{id}", h.HandleUnsubscribe).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/me/push/timer", h.HandleSetTimer).Methods(http.MethodPut)
+	authRouter.HandleFunc("/me/push/timer", h.HandleCancelTimer).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/me/push/test", h.HandleTestPush).Methods(http.MethodPost)
+}
+
+// HandleGetPublicKey godoc
+//
+// @Summary 			Get the VAPID public key
+// @Description 		Key to pass as applicationServerKey to PushManager.subscribe() in the browser
+// @Tags 				Push
+// @Produce 			json
+// @Success 			200 {object} types.VAPIDPublicKeyResponse
+// @Router 				/push/vapid-public-key [get]
+func (h *Handler) HandleGetPublicKey(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, types.VAPIDPublicKeyResponse{PublicKey: h.publicKey})
+}
+
+// HandleSubscribe godoc
+//
+// @Summary 			Register a push subscription
+// @Description 		Save the PushSubscription of a browser, as returned by subscription.toJSON(). Registering an endpoint again updates it.
+// @Tags 				Push
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.PushSubscriptionPayload true "Browser push subscription"
+// @Success 			201 {object} types.PushSubscription
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push-subscriptions [post]
+func (h *Handler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.PushSubscriptionPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateSubscription(&payload, h.allowLocal); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	subscription := types.PushSubscription{
+		UserId:    userID,
+		Endpoint:  payload.Endpoint,
+		P256dh:    payload.Keys.P256dh,
+		Auth:      payload.Keys.Auth,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}
+	if payload.ExpirationTime != nil {
+		expiresAt := time.UnixMilli(*payload.ExpirationTime)
+		subscription.ExpiresAt = &expiresAt
+	}
+	if err := h.store.SaveSubscription(&subscription); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, subscription)
+}
+
+func validateSubscription(payload *types.PushSubscriptionPayload, allowLocal bool) error {
+	if err := checkEndpoint(payload.Endpoint, allowLocal); err != nil {
+		return err
+	}
+	p256dh, err := base64.RawURLEncoding.DecodeString(payload.Keys.P256dh)
+	if err != nil || len(p256dh) != 65 {
+		return fmt.Errorf("keys.p256dh must be an uncompressed P-256 key, base64url encoded")
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(payload.Keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return fmt.Errorf("keys.auth must be 16 bytes, base64url encoded")
+	}
+	return nil
+}
+
+// HandleListSubscriptions godoc
+//
+// @Summary 			List my push subscriptions
+// @Tags 				Push
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.PushSubscription
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push-subscriptions [get]
+func (h *Handler) HandleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.store.ListUserSubscriptions(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleUnsubscribe godoc
+//
+// @Summary 			Remove a push subscription
+// @Tags 				Push
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Subscription ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push-subscriptions/{id} [delete]
+func (h *Handler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid subscription id"))
+		return
+	}
+	found, err := h.store.DeleteSubscription(userID, id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("subscription not found"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Push subscription removed"})
+}
+
+// the messages of the session end timers
+var timerMessages = map[string][2]string{
+	types.PushTimerFocus: {"Focus session over", "Time for a break."},
+	types.PushTimerBreak: {"Break is over", "Ready for the next pomodoro?"},
+}
+
+// HandleSetTimer godoc
+//
+// @Summary 			Push when a session ends
+// @Description 		Schedule a push for the end of the running focus session (focus_end) or break (break_end), so the user is told even with the app closed. Setting a timer again replaces the previous one of the same kind.
+// @Tags 				Push
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.PushTimerPayload true "Timer"
+// @Success 			200 {object} types.ScheduledPush
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push/timer [put]
+func (h *Handler) HandleSetTimer(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.PushTimerPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	text, ok := timerMessages[payload.Kind]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("kind must be %s or %s", types.PushTimerFocus, types.PushTimerBreak))
+		return
+	}
+	if !payload.EndsAt.After(time.Now()) || payload.EndsAt.After(time.Now().Add(24*time.Hour)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("ends_at must be within the next 24 hours"))
+		return
+	}
+	scheduled := types.ScheduledPush{UserId: userID, Kind: payload.Kind, Title: text[0], Body: text[1], SendAt: payload.EndsAt}
+	if err := h.store.SchedulePush(&scheduled); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, scheduled)
+}
+
+// HandleCancelTimer godoc
+//
+// @Summary 			Cancel a session end push
+// @Description 		Cancel the pending timer, e.g. when the session is paused or skipped
+// @Tags 				Push
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				kind query string true "focus_end or break_end"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push/timer [delete]
+func (h *Handler) HandleCancelTimer(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	found, err := h.store.CancelScheduledPush(userID, r.URL.Query().Get("kind"))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("no pending timer"))
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Timer cancelled"})
+}
+
+// HandleTestPush godoc
+//
+// @Summary 			Send a test push
+// @Description 		Push a test message to every subscription of the authenticated user
+// @Tags 				Push
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			202 {object} types.SuccessResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/push/test [post]
+func (h *Handler) HandleTestPush(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	message := types.PushMessage{Title: "XPomodoro", Body: "Push notifications are working.", Tag: "test"}
+	if err := h.pusher.Push(userID, message); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusAccepted, types.SuccessResponse{Message: "Test push queued"})
+}
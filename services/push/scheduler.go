@@ -0,0 +1,64 @@
+package push
+
+import (
+	"backend/types"
+	"log"
+	"time"
+)
+
+// Scheduler sends the timers users set and the daily reminders
+type Scheduler struct {
+	store  types.PushRepo
+	pusher types.Pusher
+	// UTC hour after which users with a streak and no pomodoro today are reminded
+	reminderHour int
+}
+
+func NewScheduler(store types.PushRepo, pusher types.Pusher, reminderHour int) *Scheduler {
+	return &Scheduler{store: store, pusher: pusher, reminderHour: reminderHour}
+}
+
+// SendDueTimers pushes the session end timers due at now
+func (s *Scheduler) SendDueTimers(now time.Time) error {
+	due, err := s.store.TakeDuePushes(now)
+	if err != nil {
+		return err
+	}
+	for _, p := range due {
+		message := types.PushMessage{Title: p.Title, Body: p.Body, Tag: p.Kind, Urgency: "high", TTL: 5 * time.Minute}
+		if err := s.pusher.Push(p.UserId, message); err != nil {
+			log.Printf("failed to push %s timer to user %d: %v", p.Kind, p.UserId, err)
+		}
+	}
+	return nil
+}
+
+// SendStreakReminders warns the users whose streak ends tonight, once a day
+func (s *Scheduler) SendStreakReminders(now time.Time) error {
+	now = now.UTC()
+	if now.Hour() < s.reminderHour {
+		return nil
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	users, err := s.store.ListStreakAtRisk(day)
+	if err != nil {
+		return err
+	}
+	message := types.PushMessage{
+		Title: "Your streak is at risk",
+		Body:  "No pomodoro yet today. One focus session keeps your streak alive.",
+		Tag:   types.PushStreakAtRisk,
+		// useless once the day is over
+		TTL: day.AddDate(0, 0, 1).Sub(now),
+	}
+	for _, userID := range users {
+		if err := s.pusher.Push(userID, message); err != nil {
+			log.Printf("failed to push streak reminder to user %d: %v", userID, err)
+			continue
+		}
+		if err := s.store.RecordReminder(userID, types.PushStreakAtRisk, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
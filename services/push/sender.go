@@ -0,0 +1,139 @@
+package push
+
+import (
+	"backend/types"
+	"backend/utils"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sender encrypts and delivers push messages in the background of its retry
+// queue. Failed deliveries are retried with an exponential backoff,
+// subscriptions the push service reports as gone (404 or 410) are deleted.
+type Sender struct {
+	store   types.PushRepo
+	keys    *VAPIDKeys
+	subject string
+	client  *http.Client
+	// development only, see checkEndpoint
+	allowLocal bool
+	queue      *utils.RetryQueue
+}
+
+func NewSender(store types.PushRepo, keys *VAPIDKeys, subject string, attempts int, queueSize int, allowLocal bool) *Sender {
+	return &Sender{
+		store:      store,
+		keys:       keys,
+		subject:    subject,
+		client:     newClient(allowLocal),
+		allowLocal: allowLocal,
+		queue:      utils.NewRetryQueue("push", attempts, queueSize),
+	}
+}
+
+// Push queues the message for every subscription of the user
+func (s *Sender) Push(userID int, message types.PushMessage) error {
+	subscriptions, err := s.store.ListUserSubscriptions(userID)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		err := s.queue.Push(utils.RetryJob{
+			Name:    fmt.Sprintf("push %q to subscription %d", message.Title, subscription.Id),
+			Deliver: func() (time.Duration, error) { return s.deliver(&subscription, message) },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run delivers queued messages until the process exits
+func (s *Sender) Run() {
+	s.queue.Run()
+}
+
+// Wait blocks until the messages sent so far are delivered or given up
+func (s *Sender) Wait() {
+	s.queue.Wait()
+}
+
+// deliver sends one message, a negative retryAfter means retrying is useless
+func (s *Sender) deliver(subscription *types.PushSubscription, message types.PushMessage) (retryAfter time.Duration, err error) {
+	// subscriptions saved before endpoints were checked
+	if err := checkEndpoint(subscription.Endpoint, s.allowLocal); err != nil {
+		return -1, err
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return -1, err
+	}
+	p256dh, err := base64.RawURLEncoding.DecodeString(subscription.P256dh)
+	if err != nil {
+		return -1, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	auth, err := base64.RawURLEncoding.DecodeString(subscription.Auth)
+	if err != nil {
+		return -1, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	body, err := Encrypt(payload, p256dh, auth)
+	if err != nil {
+		return -1, err
+	}
+	authorization, err := s.keys.Authorization(subscription.Endpoint, s.subject, time.Now())
+	if err != nil {
+		return -1, err
+	}
+	req, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	ttl := message.TTL
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+	urgency := message.Urgency
+	if urgency == "" {
+		urgency = "normal"
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", urgency)
+	if message.Tag != "" {
+		// the Topic header replaces an undelivered message with the same topic
+		req.Header.Set("Topic", message.Tag)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		if err := s.store.MarkSubscriptionUsed(subscription.Id, time.Now()); err != nil {
+			log.Printf("failed to update push subscription %d: %v", subscription.Id, err)
+		}
+		return 0, nil
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		// the browser unsubscribed or the subscription expired
+		if err := s.store.DeleteSubscriptionByEndpoint(subscription.Endpoint); err != nil {
+			log.Printf("failed to delete push subscription %d: %v", subscription.Id, err)
+		}
+		return -1, fmt.Errorf("subscription is gone (%s)", res.Status)
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		seconds, _ := strconv.Atoi(res.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("push service answered %s", res.Status)
+	default:
+		return -1, fmt.Errorf("push service rejected the message (%s)", res.Status)
+	}
+}
@@ -0,0 +1,152 @@
+package push
+
+import (
+	"backend/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryPushRepo serves the subscriptions of the test and records what the
+// sender reports back, the other methods are not used by the sender
+type memoryPushRepo struct {
+	types.PushRepo
+	mu            sync.Mutex
+	subscriptions []types.PushSubscription
+	used          []int
+	deleted       []string
+}
+
+func (m *memoryPushRepo) ListUserSubscriptions(userID int) ([]types.PushSubscription, error) {
+	list := make([]types.PushSubscription, 0)
+	for _, s := range m.subscriptions {
+		if s.UserId == userID {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func (m *memoryPushRepo) MarkSubscriptionUsed(id int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.used = append(m.used, id)
+	return nil
+}
+
+func (m *memoryPushRepo) DeleteSubscriptionByEndpoint(endpoint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, endpoint)
+	return nil
+}
+
+func subscription(id int, payload types.PushSubscriptionPayload) types.PushSubscription {
+	return types.PushSubscription{Id: id, UserId: 1, Endpoint: payload.Endpoint, P256dh: payload.Keys.P256dh, Auth: payload.Keys.Auth}
+}
+
+// newStubServer serves a stub on a local port until the end of the test
+func newStubServer(t *testing.T) (*Stub, *httptest.Server) {
+	t.Helper()
+	var stub *Stub
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	stub, err := NewStub(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stub, server
+}
+
+func newSender(t *testing.T, store types.PushRepo, allowLocal bool) *Sender {
+	t.Helper()
+	keys, _, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := NewSender(store, keys, "mailto:test@example.com", 2, 8, allowLocal)
+	sender.queue.Backoff = time.Millisecond
+	go sender.Run()
+	return sender
+}
+
+func wait(t *testing.T, sender *Sender) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		sender.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the messages were not delivered")
+	}
+}
+
+func TestSenderDeliversToStub(t *testing.T) {
+	stub, server := newStubServer(t)
+	gone := stub.Subscription("expired")
+	gone.Endpoint = server.URL + "/gone/expired"
+	store := &memoryPushRepo{subscriptions: []types.PushSubscription{
+		subscription(1, stub.Subscription("laptop")),
+		subscription(2, gone),
+		{Id: 3, UserId: 2, Endpoint: server.URL + "/push/other-user"},
+	}}
+	sender := newSender(t, store, true)
+
+	message := types.PushMessage{Title: "Rank up!", Body: "You reached Gold I", Tag: "rank", Urgency: "high", TTL: time.Hour}
+	if err := sender.Push(1, message); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, sender)
+
+	received := stub.Received()
+	if len(received) != 1 {
+		t.Fatalf("the stub received %d messages, want 1", len(received))
+	}
+	got := received[0]
+	if got.Path != "/push/laptop" {
+		t.Errorf("delivered to %s", got.Path)
+	}
+	var decoded types.PushMessage
+	if err := json.Unmarshal(got.Payload, &decoded); err != nil {
+		t.Fatalf("payload %q: %v", got.Payload, err)
+	}
+	if decoded.Title != message.Title || decoded.Body != message.Body || decoded.Tag != message.Tag {
+		t.Errorf("got %+v, want %+v", decoded, message)
+	}
+	for header, want := range map[string]string{"TTL": "3600", "Urgency": "high", "Topic": "rank"} {
+		if got.Headers.Get(header) != want {
+			t.Errorf("got %s %q, want %q", header, got.Headers.Get(header), want)
+		}
+	}
+	if len(store.used) != 1 || store.used[0] != 1 {
+		t.Errorf("marked subscriptions %v as used, want [1]", store.used)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != gone.Endpoint {
+		t.Errorf("deleted %v, want the expired subscription", store.deleted)
+	}
+}
+
+func TestSenderRefusesLocalEndpoints(t *testing.T) {
+	stub, _ := newStubServer(t)
+	store := &memoryPushRepo{subscriptions: []types.PushSubscription{subscription(1, stub.Subscription("laptop"))}}
+	sender := newSender(t, store, false)
+
+	if err := sender.Push(1, types.PushMessage{Title: "Rank up!"}); err != nil {
+		t.Fatal(err)
+	}
+	wait(t, sender)
+	if received := stub.Received(); len(received) != 0 {
+		t.Errorf("the stub received %d messages from a sender without allowLocal", len(received))
+	}
+	if len(store.used) != 0 {
+		t.Errorf("marked subscriptions %v as used", store.used)
+	}
+}
@@ -0,0 +1,95 @@
+package push
+
+import (
+	"backend/types"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Stub is a local push service for development and tests. It plays the
+// browser side of its subscriptions: messages are decrypted, logged and kept
+// for Received. Endpoints under /gone/ answer 410 like an expired subscription.
+type Stub struct {
+	baseURL  string
+	key      *ecdh.PrivateKey
+	auth     []byte
+	mu       sync.Mutex
+	received []StubMessage
+}
+
+// StubMessage is a message the stub decrypted
+type StubMessage struct {
+	Path    string
+	Headers http.Header
+	Payload []byte
+}
+
+func NewStub(baseURL string) (*Stub, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		return nil, err
+	}
+	return &Stub{baseURL: strings.TrimRight(baseURL, "/"), key: key, auth: auth}, nil
+}
+
+// Subscription returns a subscription to register with POST /me/push-subscriptions
+func (s *Stub) Subscription(name string) types.PushSubscriptionPayload {
+	var payload types.PushSubscriptionPayload
+	payload.Endpoint = s.baseURL + "/push/" + name
+	payload.Keys.P256dh = base64.RawURLEncoding.EncodeToString(s.key.PublicKey().Bytes())
+	payload.Keys.Auth = base64.RawURLEncoding.EncodeToString(s.auth)
+	return payload
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/gone/") {
+		log.Printf("push to %s: answering 410 Gone", r.URL.Path)
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "vapid t=") {
+		http.Error(w, "missing VAPID authorization", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+		http.Error(w, "expected aes128gcm content encoding and a TTL", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4096+1))
+	if err != nil || len(body) > 4096 {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	message, err := Decrypt(body, s.key, s.auth)
+	if err != nil {
+		log.Printf("push to %s: cannot decrypt: %v", r.URL.Path, err)
+		http.Error(w, "cannot decrypt", http.StatusBadRequest)
+		return
+	}
+	log.Printf("push to %s (TTL %s, urgency %s, topic %q): %s", r.URL.Path, r.Header.Get("TTL"), r.Header.Get("Urgency"), r.Header.Get("Topic"), message)
+	s.mu.Lock()
+	s.received = append(s.received, StubMessage{Path: r.URL.Path, Headers: r.Header.Clone(), Payload: message})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Received returns the messages decrypted so far, oldest first
+func (s *Stub) Received() []StubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StubMessage(nil), s.received...)
+}
@@ -0,0 +1,93 @@
+package push
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// VAPIDKeys identify this server to the push services (RFC 8292)
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	// PublicKey is the uncompressed point, base64url encoded, that browsers
+	// pass as applicationServerKey when subscribing
+	PublicKey string
+}
+
+// GenerateVAPIDKeys returns a new key pair, the private key is the base64url
+// encoded 32 bytes scalar
+func GenerateVAPIDKeys() (*VAPIDKeys, string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	private := base64.RawURLEncoding.EncodeToString(key.Bytes())
+	keys, err := LoadVAPIDKeys(private)
+	return keys, private, err
+}
+
+// LoadVAPIDKeys parses a base64url private key as printed by GenerateVAPIDKeys,
+// the public key is derived from it
+func LoadVAPIDKeys(private string) (*VAPIDKeys, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(private)
+	if err != nil {
+		return nil, fmt.Errorf("VAPID private key must be base64url encoded: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	// round trip through PKCS #8 to get the ecdsa key used for signing
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signing, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid VAPID private key")
+	}
+	public := key.PublicKey().Bytes()
+	return &VAPIDKeys{private: signing, PublicKey: base64.RawURLEncoding.EncodeToString(public)}, nil
+}
+
+// LoadOrGenerateVAPIDKeys falls back to throwaway keys when none are configured,
+// subscriptions made with them stop working on restart
+func LoadOrGenerateVAPIDKeys(private string) (*VAPIDKeys, error) {
+	if private != "" {
+		return LoadVAPIDKeys(private)
+	}
+	log.Println("VAPID_PRIVATE_KEY is not set, using temporary keys (run `go run cmd/main.go generate-vapid-keys`)")
+	keys, _, err := GenerateVAPIDKeys()
+	return keys, err
+}
+
+// Authorization returns the header value for a request to the push service of
+// endpoint, subject is a mailto: or https: contact for the push service operator
+func (k *VAPIDKeys) Authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, k.PublicKey), nil
+}
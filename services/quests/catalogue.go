@@ -0,0 +1,98 @@
+package quests
+
+import (
+	"backend/types"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// quests given to each user per period
+const (
+	DailyQuests  = 3
+	WeeklyQuests = 2
+)
+
+// Catalogue lists every quest, codes are stored in user_quests and must never
+// change. Times are UTC, like the periods.
+var Catalogue = []types.Quest{
+	{Code: "morning_focus", Period: types.QuestDaily, Title: "Morning focus", Description: "Complete 3 focus sessions before noon", Type: "pomodoro", Measure: types.QuestMeasureSessions, BeforeHour: 12, Target: 3, XPReward: 40},
+	{Code: "daily_focus", Period: types.QuestDaily, Title: "Four in a row", Description: "Complete 4 focus sessions today", Type: "pomodoro", Measure: types.QuestMeasureSessions, Target: 4, XPReward: 40},
+	{Code: "daily_minutes", Period: types.QuestDaily, Title: "Deep work", Description: "Focus for 90 minutes today", Type: "pomodoro", Measure: types.QuestMeasureMinutes, Target: 90, XPReward: 50},
+	{Code: "daily_breaks", Period: types.QuestDaily, Title: "Breathe", Description: "Take 3 short breaks today", Type: "short break", Measure: types.QuestMeasureSessions, Target: 3, XPReward: 20},
+	{Code: "early_start", Period: types.QuestDaily, Title: "Early start", Description: "Complete a focus session before 9am", Type: "pomodoro", Measure: types.QuestMeasureSessions, BeforeHour: 9, Target: 1, XPReward: 25},
+	{Code: "weekly_long_breaks", Period: types.QuestWeekly, Title: "Recharge", Description: "Take 2 long breaks this week", Type: "long break", Measure: types.QuestMeasureSessions, Target: 2, XPReward: 60},
+	{Code: "weekly_focus", Period: types.QuestWeekly, Title: "Steady week", Description: "Complete 20 focus sessions this week", Type: "pomodoro", Measure: types.QuestMeasureSessions, Target: 20, XPReward: 150},
+	{Code: "weekly_minutes", Period: types.QuestWeekly, Title: "Ten hours", Description: "Focus for 600 minutes this week", Type: "pomodoro", Measure: types.QuestMeasureMinutes, Target: 600, XPReward: 200},
+	{Code: "weekly_mornings", Period: types.QuestWeekly, Title: "Morning person", Description: "Complete 10 focus sessions before noon this week", Type: "pomodoro", Measure: types.QuestMeasureSessions, BeforeHour: 12, Target: 10, XPReward: 120},
+}
+
+// Find returns the quest with code, nil if there is none
+func Find(code string) *types.Quest {
+	for i := range Catalogue {
+		if Catalogue[i].Code == code {
+			return &Catalogue[i]
+		}
+	}
+	return nil
+}
+
+// Period returns the start and the end of the day (UTC) or the week (from
+// Monday, UTC) containing t
+func Period(period string, t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == types.QuestWeekly {
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+	return day, day.AddDate(0, 0, 1)
+}
+
+// Pick chooses the quests of a user for the period starting at start. The
+// choice only depends on its arguments, so it is the same on every call and
+// every server, and differs between users and periods.
+func Pick(userID int, period string, start time.Time, count int) []types.Quest {
+	pool := make([]types.Quest, 0)
+	for _, q := range Catalogue {
+		if q.Period == period {
+			pool = append(pool, q)
+		}
+	}
+	key := func(q types.Quest) uint64 {
+		sum := sha256.Sum256(fmt.Appendf(nil, "%d|%s|%s", userID, start.Format(time.DateOnly), q.Code))
+		return binary.BigEndian.Uint64(sum[:8])
+	}
+	sort.Slice(pool, func(i, j int) bool { return key(pool[i]) < key(pool[j]) })
+	return pool[:min(count, len(pool))]
+}
+
+// Counts returns what a pomodoro adds to the quest, 0 if it does not count
+func Counts(q types.Quest, p *types.Pomodoro) int {
+	if !p.Completed || p.Type != q.Type {
+		return 0
+	}
+	if q.BeforeHour > 0 && p.EndTime.UTC().Hour() >= q.BeforeHour {
+		return 0
+	}
+	if q.Measure == types.QuestMeasureMinutes {
+		return p.SessionDuration
+	}
+	return 1
+}
+
+// Status tells where a user quest stands at now
+func Status(q *types.UserQuest, now time.Time) string {
+	switch {
+	case q.ClaimedAt != nil:
+		return types.QuestClaimed
+	case !now.Before(q.ExpiresAt):
+		return types.QuestExpired
+	case q.CompletedAt != nil:
+		return types.QuestCompleted
+	default:
+		return types.QuestActive
+	}
+}
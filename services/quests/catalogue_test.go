@@ -0,0 +1,177 @@
+package quests
+
+import (
+	"backend/types"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		period    string
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"day", types.QuestDaily, date("2026-03-11T15:04:05Z"), date("2026-03-11T00:00:00Z"), date("2026-03-12T00:00:00Z")},
+		{"last second of the day", types.QuestDaily, date("2026-03-11T23:59:59Z"), date("2026-03-11T00:00:00Z"), date("2026-03-12T00:00:00Z")},
+		{"midnight opens the next day", types.QuestDaily, date("2026-03-12T00:00:00Z"), date("2026-03-12T00:00:00Z"), date("2026-03-13T00:00:00Z")},
+		{"days are UTC", types.QuestDaily, date("2026-03-12T01:00:00+02:00"), date("2026-03-11T00:00:00Z"), date("2026-03-12T00:00:00Z")},
+		{"new year", types.QuestDaily, date("2026-12-31T23:30:00Z"), date("2026-12-31T00:00:00Z"), date("2027-01-01T00:00:00Z")},
+		{"week from a wednesday", types.QuestWeekly, date("2026-03-11T12:00:00Z"), date("2026-03-09T00:00:00Z"), date("2026-03-16T00:00:00Z")},
+		{"monday starts the week", types.QuestWeekly, date("2026-03-09T00:00:00Z"), date("2026-03-09T00:00:00Z"), date("2026-03-16T00:00:00Z")},
+		{"sunday ends the week", types.QuestWeekly, date("2026-03-15T23:59:59Z"), date("2026-03-09T00:00:00Z"), date("2026-03-16T00:00:00Z")},
+		{"week across months", types.QuestWeekly, date("2026-04-01T08:00:00Z"), date("2026-03-30T00:00:00Z"), date("2026-04-06T00:00:00Z")},
+		{"week across years", types.QuestWeekly, date("2027-01-01T08:00:00Z"), date("2026-12-28T00:00:00Z"), date("2027-01-04T00:00:00Z")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Period(tt.period, tt.at)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("Period(%s, %s) = %s, %s, want %s, %s", tt.period, tt.at, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func codes(quests []types.Quest) []string {
+	list := make([]string, len(quests))
+	for i, q := range quests {
+		list[i] = q.Code
+	}
+	return list
+}
+
+func TestPick(t *testing.T) {
+	day, _ := Period(types.QuestDaily, date("2026-03-11T10:00:00Z"))
+	first := Pick(1, types.QuestDaily, day, DailyQuests)
+	if len(first) != DailyQuests {
+		t.Fatalf("got %d quests, want %d", len(first), DailyQuests)
+	}
+	seen := map[string]bool{}
+	for _, q := range first {
+		if q.Period != types.QuestDaily {
+			t.Errorf("picked the %s quest %s for a day", q.Period, q.Code)
+		}
+		if seen[q.Code] {
+			t.Errorf("picked %s twice", q.Code)
+		}
+		seen[q.Code] = true
+	}
+	// the same arguments always give the same quests
+	if got := codes(Pick(1, types.QuestDaily, day, DailyQuests)); !equal(got, codes(first)) {
+		t.Errorf("got %v then %v for the same day", codes(first), got)
+	}
+
+	// over a few weeks the picks move with the day and the user
+	sameAsNextDay, sameAsOtherUser := 0, 0
+	for i := 0; i < 28; i++ {
+		d := day.AddDate(0, 0, i)
+		picked := codes(Pick(1, types.QuestDaily, d, DailyQuests))
+		if equal(picked, codes(Pick(1, types.QuestDaily, d.AddDate(0, 0, 1), DailyQuests))) {
+			sameAsNextDay++
+		}
+		if equal(picked, codes(Pick(2, types.QuestDaily, d, DailyQuests))) {
+			sameAsOtherUser++
+		}
+	}
+	if sameAsNextDay == 28 || sameAsOtherUser == 28 {
+		t.Errorf("the picks never change: %d same as the next day, %d same as another user", sameAsNextDay, sameAsOtherUser)
+	}
+
+	week, _ := Period(types.QuestWeekly, day)
+	weekly := Pick(1, types.QuestWeekly, week, WeeklyQuests)
+	if len(weekly) != WeeklyQuests {
+		t.Fatalf("got %d weekly quests, want %d", len(weekly), WeeklyQuests)
+	}
+	for _, q := range weekly {
+		if q.Period != types.QuestWeekly {
+			t.Errorf("picked the %s quest %s for a week", q.Period, q.Code)
+		}
+	}
+	// asking for more than the pool gives the whole pool
+	if got := Pick(1, types.QuestWeekly, week, 100); len(got) != 4 {
+		t.Errorf("got %d weekly quests, want the 4 of the catalogue", len(got))
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCounts(t *testing.T) {
+	morning := *Find("morning_focus")
+	minutes := *Find("daily_minutes")
+	breaks := *Find("daily_breaks")
+	session := func(kind string, completed bool, end string, duration int) *types.Pomodoro {
+		return &types.Pomodoro{Type: kind, Completed: completed, EndTime: date(end), SessionDuration: duration}
+	}
+	tests := []struct {
+		name  string
+		quest types.Quest
+		p     *types.Pomodoro
+		want  int
+	}{
+		{"focus session before noon", morning, session("pomodoro", true, "2026-03-11T11:59:59Z", 25), 1},
+		{"focus session at noon", morning, session("pomodoro", true, "2026-03-11T12:00:00Z", 25), 0},
+		{"before noon in UTC only", morning, session("pomodoro", true, "2026-03-11T11:30:00-02:00", 25), 0},
+		{"abandoned session", morning, session("pomodoro", false, "2026-03-11T08:00:00Z", 25), 0},
+		{"break for a focus quest", morning, session("short break", true, "2026-03-11T08:00:00Z", 5), 0},
+		{"minutes", minutes, session("pomodoro", true, "2026-03-11T22:00:00Z", 50), 50},
+		{"short break", breaks, session("short break", true, "2026-03-11T22:00:00Z", 5), 1},
+		{"long break for a short break quest", breaks, session("long break", true, "2026-03-11T22:00:00Z", 15), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Counts(tt.quest, tt.p); got != tt.want {
+				t.Errorf("Counts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	start, end := Period(types.QuestDaily, date("2026-03-11T10:00:00Z"))
+	completed := date("2026-03-11T09:00:00Z")
+	claimed := date("2026-03-11T09:30:00Z")
+	tests := []struct {
+		name      string
+		completed *time.Time
+		claimed   *time.Time
+		now       time.Time
+		want      string
+	}{
+		{"active", nil, nil, date("2026-03-11T10:00:00Z"), types.QuestActive},
+		{"active on the last second", nil, nil, end.Add(-time.Second), types.QuestActive},
+		{"expired at midnight", nil, nil, end, types.QuestExpired},
+		{"completed", &completed, nil, date("2026-03-11T10:00:00Z"), types.QuestCompleted},
+		{"completed and not claimed in time", &completed, nil, end, types.QuestExpired},
+		{"claimed", &completed, &claimed, date("2026-03-11T10:00:00Z"), types.QuestClaimed},
+		{"claimed stays claimed", &completed, &claimed, end.AddDate(0, 0, 7), types.QuestClaimed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &types.UserQuest{PeriodStart: start, ExpiresAt: end, CompletedAt: tt.completed, ClaimedAt: tt.claimed}
+			if got := Status(q, tt.now); got != tt.want {
+				t.Errorf("Status() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
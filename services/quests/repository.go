@@ -0,0 +1,126 @@
+package quests
+
+import (
+	"backend/types"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type QuestRepoImpl struct {
+	db *sql.DB
+}
+
+func NewQuestRepoImpl(db *sql.DB) *QuestRepoImpl {
+	return &QuestRepoImpl{db: db}
+}
+
+const questColumns = "id, user_id, code, period, period_start, expires_at, progress, target, xp_reward, completed_at, claimed_at"
+
+func (q *QuestRepoImpl) AssignQuests(quests []types.UserQuest) error {
+	if len(quests) == 0 {
+		return nil
+	}
+	placeholders := make([]string, 0, len(quests))
+	args := make([]any, 0, len(quests)*7)
+	for _, uq := range quests {
+		placeholders = append(placeholders, "(?,?,?,?,?,?,?)")
+		args = append(args, uq.UserId, uq.Code, uq.Period, uq.PeriodStart, uq.ExpiresAt, uq.Target, uq.XPReward)
+	}
+	// the unique key keeps the quests already assigned, with their progress
+	_, err := q.db.Exec(
+		"INSERT IGNORE INTO user_quests(user_id, code, period, period_start, expires_at, target, xp_reward) VALUES "+strings.Join(placeholders, ","),
+		args...,
+	)
+	return err
+}
+
+func (q *QuestRepoImpl) ListQuests(userID int, since time.Time) ([]types.UserQuest, error) {
+	rows, err := q.db.Query(
+		"SELECT "+questColumns+" FROM user_quests WHERE user_id = ? AND expires_at > ? ORDER BY expires_at, id",
+		userID, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.UserQuest, 0)
+	for rows.Next() {
+		var uq types.UserQuest
+		if err := scanRowIntoQuest(rows, &uq); err != nil {
+			return nil, err
+		}
+		list = append(list, uq)
+	}
+	return list, rows.Err()
+}
+
+func (q *QuestRepoImpl) GetQuest(userID int, id int64) (*types.UserQuest, error) {
+	var uq types.UserQuest
+	row := q.db.QueryRow("SELECT "+questColumns+" FROM user_quests WHERE id = ? AND user_id = ?", id, userID)
+	if err := scanRowIntoQuest(row, &uq); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &uq, nil
+}
+
+func (q *QuestRepoImpl) AddQuestProgress(id int64, amount int, at time.Time) (bool, error) {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	// the row lock makes sure a single update reaches the target
+	var progress, target int
+	err = tx.QueryRow("SELECT progress, target FROM user_quests WHERE id = ? AND completed_at IS NULL FOR UPDATE", id).
+		Scan(&progress, &target)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	progress = min(progress+amount, target)
+	var completedAt *time.Time
+	if progress == target {
+		completedAt = &at
+	}
+	if _, err := tx.Exec("UPDATE user_quests SET progress = ?, completed_at = ? WHERE id = ?", progress, completedAt, id); err != nil {
+		return false, err
+	}
+	return completedAt != nil, tx.Commit()
+}
+
+func (q *QuestRepoImpl) MarkQuestClaimed(id int64, at time.Time) (bool, error) {
+	res, err := q.db.Exec(
+		"UPDATE user_quests SET claimed_at = ? WHERE id = ? AND completed_at IS NOT NULL AND claimed_at IS NULL",
+		at, id,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+func (q *QuestRepoImpl) PurgeExpiredQuests(before time.Time) (int64, error) {
+	res, err := q.db.Exec("DELETE FROM user_quests WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoQuest(row scanner, uq *types.UserQuest) error {
+	return row.Scan(
+		&uq.Id, &uq.UserId, &uq.Code, &uq.Period, &uq.PeriodStart, &uq.ExpiresAt,
+		&uq.Progress, &uq.Target, &uq.XPReward, &uq.CompletedAt, &uq.ClaimedAt,
+	)
+}
@@ -0,0 +1,118 @@
+package quests
+
+import (
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store   types.QuestRepo
+	service *Service
+}
+
+func NewHandler(store types.QuestRepo, service *Service) *Handler {
+	return &Handler{store: store, service: service}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	authRouter.HandleFunc("/me/quests", h.HandleListQuests).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/quests/{id}/claim", h.HandleClaimQuest).Methods(http.MethodPost)
+}
+
+// HandleListQuests godoc
+//
+// @Summary 			List my quests
+// @Description 		The daily and weekly quests of the authenticated user with their progress and status (active, completed, claimed). Quests are assigned on the first call of each day and week (UTC) and expire at its end.
+// @Tags 				Quests
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.UserQuest
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/quests [get]
+func (h *Handler) HandleListQuests(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.service.Current(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleClaimQuest godoc
+//
+// @Summary 			Claim a quest reward
+// @Description 		Credit the XP of a completed quest, once, before the quest expires
+// @Tags 				Quests
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Quest ID"
+// @Success 			200 {object} types.ClaimQuestResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			410 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/quests/{id}/claim [post]
+func (h *Handler) HandleClaimQuest(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid quest id"))
+		return
+	}
+	quest, err := h.store.GetQuest(userID, id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if quest == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("quest not found"))
+		return
+	}
+	now := h.service.Now()
+	switch Status(quest, now) {
+	case types.QuestActive:
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("this quest is not completed yet"))
+		return
+	case types.QuestClaimed:
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("this quest was already claimed"))
+		return
+	case types.QuestExpired:
+		utils.WriteError(w, http.StatusGone, fmt.Errorf("this quest expired"))
+		return
+	}
+	claimed, err := h.store.MarkQuestClaimed(id, now)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !claimed {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("this quest was already claimed"))
+		return
+	}
+	quest.ClaimedAt = &now
+	describe(quest, now)
+	awarded, err := h.service.Reward(quest)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.ClaimQuestResponse{Quest: quest, XPAwarded: awarded})
+}
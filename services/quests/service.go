@@ -0,0 +1,147 @@
+package quests
+
+import (
+	"backend/services/notifications"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"time"
+)
+
+// Service assigns quests, tracks them and grants their rewards. It reads the
+// time from its clock only, so tests can run it on any day.
+type Service struct {
+	store    types.QuestRepo
+	ledger   types.XPLedger
+	notifier types.Notifier
+	clock    utils.Clock
+}
+
+func NewService(store types.QuestRepo, ledger types.XPLedger, notifier types.Notifier, clock utils.Clock) *Service {
+	return &Service{store: store, ledger: ledger, notifier: notifier, clock: clock}
+}
+
+func (s *Service) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Current assigns the quests of the current day and week if needed and
+// returns them with their status
+func (s *Service) Current(userID int) ([]types.UserQuest, error) {
+	now := s.clock.Now()
+	if err := s.assign(userID, now); err != nil {
+		return nil, err
+	}
+	list, err := s.store.ListQuests(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		describe(&list[i], now)
+	}
+	return list, nil
+}
+
+func (s *Service) assign(userID int, now time.Time) error {
+	quests := make([]types.UserQuest, 0, DailyQuests+WeeklyQuests)
+	periods := []struct {
+		name  string
+		count int
+	}{{types.QuestDaily, DailyQuests}, {types.QuestWeekly, WeeklyQuests}}
+	for _, period := range periods {
+		start, end := Period(period.name, now)
+		for _, q := range Pick(userID, period.name, start, period.count) {
+			quests = append(quests, types.UserQuest{
+				UserId:      userID,
+				Code:        q.Code,
+				Period:      period.name,
+				Target:      q.Target,
+				XPReward:    q.XPReward,
+				PeriodStart: start,
+				ExpiresAt:   end,
+			})
+		}
+	}
+	return s.store.AssignQuests(quests)
+}
+
+// TrackPomodoro adds a saved pomodoro to the current quests it counts for.
+// Sessions ending outside of a quest's period are ignored.
+func (s *Service) TrackPomodoro(p *types.Pomodoro) error {
+	if !p.Completed {
+		return nil
+	}
+	now := s.clock.Now()
+	if err := s.assign(p.UserId, now); err != nil {
+		return err
+	}
+	list, err := s.store.ListQuests(p.UserId, now)
+	if err != nil {
+		return err
+	}
+	ended := p.EndTime
+	if ended.IsZero() {
+		ended = now
+	}
+	session := *p
+	session.EndTime = ended
+	for i := range list {
+		uq := &list[i]
+		q := Find(uq.Code)
+		if q == nil || uq.CompletedAt != nil || ended.Before(uq.PeriodStart) || !ended.Before(uq.ExpiresAt) {
+			continue
+		}
+		amount := Counts(*q, &session)
+		if amount == 0 {
+			continue
+		}
+		completed, err := s.store.AddQuestProgress(uq.Id, amount, now)
+		if err != nil {
+			return err
+		}
+		if completed {
+			notifications.Send(s.notifier, p.UserId, types.NotificationQuest,
+				fmt.Sprintf("Quest complete: %s", q.Title),
+				fmt.Sprintf("Claim your %d XP before the quest expires.", uq.XPReward),
+				map[string]any{"quest_id": uq.Id, "code": uq.Code, "expires_at": uq.ExpiresAt},
+			)
+		}
+	}
+	return nil
+}
+
+// Reward credits the XP of a claimed quest and returns what was granted
+func (s *Service) Reward(q *types.UserQuest) (int, error) {
+	if q.XPReward <= 0 {
+		return 0, nil
+	}
+	source := types.XPSourceQuest
+	t := &types.XPTransaction{
+		UserId:     q.UserId,
+		Delta:      q.XPReward,
+		Reason:     types.XPReasonQuest,
+		SourceType: &source,
+		SourceId:   &q.Id,
+		Note:       q.Title,
+		CreatedAt:  s.clock.Now(),
+	}
+	recorded, err := s.ledger.Record(t)
+	if err != nil || !recorded {
+		return 0, err
+	}
+	return t.Delta, nil
+}
+
+// PurgeExpired deletes the quests expired for more than keep
+func (s *Service) PurgeExpired(keep time.Duration) (int64, error) {
+	return s.store.PurgeExpiredQuests(s.clock.Now().Add(-keep))
+}
+
+// describe fills the fields that come from the catalogue and the status
+func describe(uq *types.UserQuest, now time.Time) {
+	if q := Find(uq.Code); q != nil {
+		uq.Title = q.Title
+		uq.Description = q.Description
+	}
+	uq.Status = Status(uq, now)
+}
@@ -0,0 +1,243 @@
+package quests
+
+import (
+	"backend/types"
+	"backend/utils"
+	"testing"
+	"time"
+)
+
+// memoryQuestRepo keeps the quests in memory with the semantics of QuestRepoImpl
+type memoryQuestRepo struct {
+	quests []types.UserQuest
+}
+
+func (m *memoryQuestRepo) AssignQuests(quests []types.UserQuest) error {
+	for _, q := range quests {
+		exists := false
+		for _, existing := range m.quests {
+			if existing.UserId == q.UserId && existing.Code == q.Code && existing.PeriodStart.Equal(q.PeriodStart) {
+				exists = true
+			}
+		}
+		if !exists {
+			q.Id = int64(len(m.quests) + 1)
+			m.quests = append(m.quests, q)
+		}
+	}
+	return nil
+}
+
+func (m *memoryQuestRepo) ListQuests(userID int, since time.Time) ([]types.UserQuest, error) {
+	list := make([]types.UserQuest, 0)
+	for _, q := range m.quests {
+		if q.UserId == userID && q.ExpiresAt.After(since) {
+			list = append(list, q)
+		}
+	}
+	return list, nil
+}
+
+func (m *memoryQuestRepo) GetQuest(userID int, id int64) (*types.UserQuest, error) {
+	for _, q := range m.quests {
+		if q.UserId == userID && q.Id == id {
+			return &q, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryQuestRepo) AddQuestProgress(id int64, amount int, at time.Time) (bool, error) {
+	q := &m.quests[id-1]
+	if q.CompletedAt != nil {
+		return false, nil
+	}
+	q.Progress = min(q.Progress+amount, q.Target)
+	if q.Progress < q.Target {
+		return false, nil
+	}
+	q.CompletedAt = &at
+	return true, nil
+}
+
+func (m *memoryQuestRepo) MarkQuestClaimed(id int64, at time.Time) (bool, error) {
+	q := &m.quests[id-1]
+	if q.ClaimedAt != nil {
+		return false, nil
+	}
+	q.ClaimedAt = &at
+	return true, nil
+}
+
+func (m *memoryQuestRepo) PurgeExpiredQuests(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// progress returns the progress of the quests of a period by code
+func (m *memoryQuestRepo) progress(period string, start time.Time) map[string]int {
+	progress := map[string]int{}
+	for _, q := range m.quests {
+		if q.Period == period && q.PeriodStart.Equal(start) {
+			progress[q.Code] = q.Progress
+		}
+	}
+	return progress
+}
+
+type memoryNotifier struct {
+	sent []types.Notification
+}
+
+func (m *memoryNotifier) Notify(n *types.Notification) error {
+	m.sent = append(m.sent, *n)
+	return nil
+}
+
+func focusSession(end time.Time, minutes int) *types.Pomodoro {
+	return &types.Pomodoro{UserId: 1, Type: "pomodoro", Completed: true, SessionDuration: minutes, StartTime: end.Add(-time.Duration(minutes) * time.Minute), EndTime: end}
+}
+
+// expected returns what the sessions add to each quest picked for the period
+func expected(period string, start time.Time, count int, sessions ...*types.Pomodoro) map[string]int {
+	progress := map[string]int{}
+	for _, q := range Pick(1, period, start, count) {
+		progress[q.Code] = 0
+		for _, p := range sessions {
+			progress[q.Code] = min(progress[q.Code]+Counts(q, p), q.Target)
+		}
+	}
+	return progress
+}
+
+func checkProgress(t *testing.T, store *memoryQuestRepo, period string, start time.Time, want map[string]int) {
+	t.Helper()
+	got := store.progress(period, start)
+	if len(got) != len(want) {
+		t.Fatalf("got %d %s quests from %s, want %d", len(got), period, start.Format(time.DateOnly), len(want))
+	}
+	for code, progress := range want {
+		if got[code] != progress {
+			t.Errorf("%s quest %s from %s: got progress %d, want %d", period, code, start.Format(time.DateOnly), got[code], progress)
+		}
+	}
+}
+
+func TestTrackPomodoroAcrossDays(t *testing.T) {
+	store := &memoryQuestRepo{}
+	clock := utils.NewManualClock(date("2026-03-10T08:30:00Z"))
+	service := NewService(store, nil, &memoryNotifier{}, clock)
+
+	// Tuesday morning, counted by the quests of the day and the week
+	morning := focusSession(date("2026-03-10T08:25:00Z"), 25)
+	if err := service.TrackPomodoro(morning); err != nil {
+		t.Fatal(err)
+	}
+	tuesday, _ := Period(types.QuestDaily, clock.Now())
+	week, _ := Period(types.QuestWeekly, clock.Now())
+	checkProgress(t, store, types.QuestDaily, tuesday, expected(types.QuestDaily, tuesday, DailyQuests, morning))
+	checkProgress(t, store, types.QuestWeekly, week, expected(types.QuestWeekly, week, WeeklyQuests, morning))
+
+	// a session ending before midnight but saved after it only counts for the week
+	late := focusSession(date("2026-03-10T23:58:00Z"), 50)
+	clock.Set(date("2026-03-11T00:05:00Z"))
+	if err := service.TrackPomodoro(late); err != nil {
+		t.Fatal(err)
+	}
+	wednesday, _ := Period(types.QuestDaily, clock.Now())
+	checkProgress(t, store, types.QuestDaily, tuesday, expected(types.QuestDaily, tuesday, DailyQuests, morning))
+	checkProgress(t, store, types.QuestDaily, wednesday, expected(types.QuestDaily, wednesday, DailyQuests))
+	checkProgress(t, store, types.QuestWeekly, week, expected(types.QuestWeekly, week, WeeklyQuests, morning, late))
+
+	// a session without end time ends now
+	now := focusSession(time.Time{}, 25)
+	if err := service.TrackPomodoro(now); err != nil {
+		t.Fatal(err)
+	}
+	counted := focusSession(clock.Now(), 25)
+	checkProgress(t, store, types.QuestDaily, wednesday, expected(types.QuestDaily, wednesday, DailyQuests, counted))
+	checkProgress(t, store, types.QuestWeekly, week, expected(types.QuestWeekly, week, WeeklyQuests, morning, late, counted))
+}
+
+func TestTrackPomodoroAcrossWeeks(t *testing.T) {
+	store := &memoryQuestRepo{}
+	clock := utils.NewManualClock(date("2026-03-15T23:00:00Z"))
+	service := NewService(store, nil, &memoryNotifier{}, clock)
+
+	sunday := focusSession(date("2026-03-15T22:55:00Z"), 25)
+	if err := service.TrackPomodoro(sunday); err != nil {
+		t.Fatal(err)
+	}
+	lastWeek, _ := Period(types.QuestWeekly, clock.Now())
+	checkProgress(t, store, types.QuestWeekly, lastWeek, expected(types.QuestWeekly, lastWeek, WeeklyQuests, sunday))
+
+	// on Monday the quests of last week are expired, a late Sunday session is lost
+	clock.Set(date("2026-03-16T00:01:00Z"))
+	late := focusSession(date("2026-03-15T23:59:00Z"), 25)
+	if err := service.TrackPomodoro(late); err != nil {
+		t.Fatal(err)
+	}
+	week, _ := Period(types.QuestWeekly, clock.Now())
+	if !week.Equal(date("2026-03-16T00:00:00Z")) {
+		t.Fatalf("the week starts %s", week)
+	}
+	checkProgress(t, store, types.QuestWeekly, lastWeek, expected(types.QuestWeekly, lastWeek, WeeklyQuests, sunday))
+	checkProgress(t, store, types.QuestWeekly, week, expected(types.QuestWeekly, week, WeeklyQuests))
+
+	monday := focusSession(date("2026-03-16T07:00:00Z"), 25)
+	clock.Set(date("2026-03-16T07:01:00Z"))
+	if err := service.TrackPomodoro(monday); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, store, types.QuestWeekly, week, expected(types.QuestWeekly, week, WeeklyQuests, monday))
+
+	// every quest is listed with its status at the time of the clock
+	current, err := service.Current(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(current) != DailyQuests+WeeklyQuests {
+		t.Errorf("got %d current quests, want %d", len(current), DailyQuests+WeeklyQuests)
+	}
+	for _, q := range current {
+		if q.Title == "" || (q.Status != types.QuestActive && q.Status != types.QuestCompleted) {
+			t.Errorf("quest %s has title %q and status %s", q.Code, q.Title, q.Status)
+		}
+	}
+}
+
+func TestTrackPomodoroNotifiesCompletion(t *testing.T) {
+	store := &memoryQuestRepo{}
+	notifier := &memoryNotifier{}
+	clock := utils.NewManualClock(date("2026-03-10T06:00:00Z"))
+	service := NewService(store, nil, notifier, clock)
+
+	// enough early focus sessions to complete every session quest picked
+	// for the day that they count for
+	for i := 0; i < 20; i++ {
+		end := date("2026-03-10T06:00:00Z").Add(time.Duration(i) * time.Minute)
+		clock.Set(end)
+		if err := service.TrackPomodoro(focusSession(end, 30)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	completed := 0
+	for _, q := range store.quests {
+		if q.CompletedAt != nil {
+			completed++
+			if q.Progress != q.Target {
+				t.Errorf("quest %s completed with progress %d of %d", q.Code, q.Progress, q.Target)
+			}
+		}
+	}
+	if completed == 0 {
+		t.Fatal("no quest was completed")
+	}
+	if len(notifier.sent) != completed {
+		t.Errorf("got %d notifications for %d completed quests", len(notifier.sent), completed)
+	}
+	for _, n := range notifier.sent {
+		if n.Category != types.NotificationQuest {
+			t.Errorf("got a %s notification", n.Category)
+		}
+	}
+}
@@ -9,16 +9,29 @@ type RankingRepoImpl struct {
 	db *sql.DB
 }
 
+// RankedUsers is the condition on the users table, under alias when it has
+// one, that leaves accounts waiting for deletion, anonymized accounts and
+// unclaimed guests out of every ranking, season and league
+func RankedUsers(alias string) string {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+	return prefix + "deletion_scheduled_at IS NULL AND " + prefix + "anonymized_at IS NULL AND " + prefix + "is_guest = FALSE"
+}
+
+var rankedUsers = RankedUsers("")
+
 func NewRankingRepoImpl(db *sql.DB) *RankingRepoImpl {
 	return &RankingRepoImpl{db: db}
 }
 
 func (r *RankingRepoImpl) GetGlobalRanking() ([]types.RankEntry, error) {
 	rows, err := r.db.Query(
-		`SELECT  id, username, xp, rank_id,
-		RANK() OVER (ORDER BY xp DESC)
-		FROM users
-		ORDER BY xp DESC;`,
+		`SELECT  id, username, xp, rank_id, prestige,
+		RANK() OVER (ORDER BY prestige DESC, xp DESC)
+		FROM users WHERE ` + rankedUsers + `
+		ORDER BY prestige DESC, xp DESC;`,
 	)
 	if err != nil {
 		return nil, err
@@ -26,7 +39,7 @@ func (r *RankingRepoImpl) GetGlobalRanking() ([]types.RankEntry, error) {
 	list := make([]types.RankEntry, 0)
 	for rows.Next() {
 		var e types.RankEntry
-		rows.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Rank)
+		rows.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Prestige, &e.Rank)
 		list = append(list, e)
 	}
 	return list, nil
@@ -36,15 +49,15 @@ func (r *RankingRepoImpl) GetUserGlobalRank(userID int) (*types.RankEntry, error
 	row := r.db.QueryRow(`
         SELECT *
         FROM (
-            SELECT id, username, xp, rank_id, RANK() OVER (ORDER BY xp DESC)
-            FROM users
+            SELECT id, username, xp, rank_id, prestige, RANK() OVER (ORDER BY prestige DESC, xp DESC)
+            FROM users WHERE `+rankedUsers+`
         ) AS ranked
         WHERE id = ?;`,
 		userID,
 	)
 
 	var e types.RankEntry
-	if err := row.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Rank); err != nil {
+	if err := row.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Prestige, &e.Rank); err != nil {
 		return nil, err
 	}
 
@@ -53,10 +66,10 @@ func (r *RankingRepoImpl) GetUserGlobalRank(userID int) (*types.RankEntry, error
 
 func (r *RankingRepoImpl) GetLocalRanking(country string) ([]types.RankEntry, error) {
 	rows, err := r.db.Query(
-		`SELECT  id, username, xp, rank_id,
-		RANK() OVER (ORDER BY xp DESC)
-		FROM users where country = ?
-		ORDER BY xp DESC;`,
+		`SELECT  id, username, xp, rank_id, prestige,
+		RANK() OVER (ORDER BY prestige DESC, xp DESC)
+		FROM users where country = ? AND `+rankedUsers+`
+		ORDER BY prestige DESC, xp DESC;`,
 		country,
 	)
 	if err != nil {
@@ -65,7 +78,7 @@ func (r *RankingRepoImpl) GetLocalRanking(country string) ([]types.RankEntry, er
 	list := make([]types.RankEntry, 0)
 	for rows.Next() {
 		var e types.RankEntry
-		rows.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Rank)
+		rows.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Prestige, &e.Rank)
 		list = append(list, e)
 	}
 	return list, nil
@@ -75,8 +88,8 @@ func (r *RankingRepoImpl) GetUserLocalRank(userID int, country string) (*types.R
 	row := r.db.QueryRow(`
         SELECT *
         FROM (
-            SELECT id, username, xp, rank_id, RANK() OVER (ORDER BY xp DESC)
-            FROM users where country = ?
+            SELECT id, username, xp, rank_id, prestige, RANK() OVER (ORDER BY prestige DESC, xp DESC)
+            FROM users where country = ? AND `+rankedUsers+`
         ) AS ranked
         WHERE id = ?;`,
 		country,
@@ -84,7 +97,7 @@ func (r *RankingRepoImpl) GetUserLocalRank(userID int, country string) (*types.R
 	)
 
 	var e types.RankEntry
-	if err := row.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Rank); err != nil {
+	if err := row.Scan(&e.UserID, &e.Username, &e.XP, &e.RankId, &e.Prestige, &e.Rank); err != nil {
 		return nil, err
 	}
 
@@ -1,6 +1,9 @@
 package ranking
 
 import (
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/services/ranks"
 	"backend/types"
 	"backend/utils"
 	"net/http"
@@ -11,23 +14,24 @@ import (
 
 type Handler struct {
 	store types.RankingRepo
+	ranks types.RankRepo
 }
 
-func NewHandler(store types.RankingRepo) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.RankingRepo, rankRepo types.RankRepo) *Handler {
+	return &Handler{store: store, ranks: rankRepo}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/ranking/global", h.GetGlobalRanking).Methods(http.MethodGet)
-	router.HandleFunc("/ranking/global/{id}", h.GetUserGlobalRanking).Methods(http.MethodGet)
-	router.HandleFunc("/ranking/{country}", h.GetLocalRanking).Methods(http.MethodGet)
-	router.HandleFunc("/ranking/{country}/{id}", h.GetUserLocalRanking).Methods(http.MethodGet)
+	router.Handle("/ranking/global", middleware.RequireScope(auth.ScopeRankingRead, h.GetGlobalRanking)).Methods(http.MethodGet)
+	router.Handle("/ranking/global/{id}", middleware.RequireScope(auth.ScopeRankingRead, h.GetUserGlobalRanking)).Methods(http.MethodGet)
+	router.Handle("/ranking/{country}", middleware.RequireScope(auth.ScopeRankingRead, h.GetLocalRanking)).Methods(http.MethodGet)
+	router.Handle("/ranking/{country}/{id}", middleware.RequireScope(auth.ScopeRankingRead, h.GetUserLocalRanking)).Methods(http.MethodGet)
 }
 
 // GetGlobalRanking docs
 //
 // @Summary             Get global ranking
-// @Description         Retrieve the global ranking list ordered by XP descending
+// @Description         Retrieve the global ranking list ordered by prestige level then XP descending, with each user's progress toward the next rank
 // @Tags                ranking
 // @Accept              json
 // @Produce             json
@@ -42,13 +46,21 @@ func (h *Handler) GetGlobalRanking(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	entries := make([]types.Ranked, len(rankingList))
+	for i := range rankingList {
+		entries[i] = &rankingList[i]
+	}
+	if err := ranks.FillProgress(h.ranks, entries...); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 	utils.WriteJSON(w, http.StatusOK, rankingList)
 }
 
 // GetLocalRanking docs
 //
 // @Summary             Get local ranking by country
-// @Description         Retrieve ranking for users in a specific country ordered by XP descending
+// @Description         Retrieve ranking for users in a specific country ordered by prestige level then XP descending, with each user's progress toward the next rank
 // @Tags                ranking
 // @Accept              json
 // @Produce             json
@@ -64,6 +76,14 @@ func (h *Handler) GetLocalRanking(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	entries := make([]types.Ranked, len(rankingList))
+	for i := range rankingList {
+		entries[i] = &rankingList[i]
+	}
+	if err := ranks.FillProgress(h.ranks, entries...); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 	utils.WriteJSON(w, http.StatusOK, rankingList)
 }
 
@@ -93,6 +113,10 @@ func (h *Handler) GetUserGlobalRanking(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if err := ranks.FillProgress(h.ranks, rankEntry); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 	utils.WriteJSON(w, http.StatusOK, rankEntry)
 }
 
@@ -124,5 +148,9 @@ func (h *Handler) GetUserLocalRanking(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if err := ranks.FillProgress(h.ranks, rankEntry); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 	utils.WriteJSON(w, http.StatusOK, rankEntry)
 }
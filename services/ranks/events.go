@@ -0,0 +1,95 @@
+package ranks
+
+import (
+	"backend/services/notifications"
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Ledger records XP with next and announces the rank changes and prestiges it
+// causes, as a notification to the user and a webhook
+type Ledger struct {
+	next     types.XPLedger
+	notifier types.Notifier
+	webhook  types.Webhook
+}
+
+func NewLedger(next types.XPLedger, notifier types.Notifier, webhook types.Webhook) *Ledger {
+	return &Ledger{next: next, notifier: notifier, webhook: webhook}
+}
+
+func (l *Ledger) Record(t *types.XPTransaction) (bool, error) {
+	recorded, err := l.next.Record(t)
+	if err == nil && recorded && t.RankChange != nil {
+		l.announce(t.RankChange)
+	}
+	return recorded, err
+}
+
+func (l *Ledger) Spend(t *types.XPTransaction, buy func(tx *sql.Tx) error) error {
+	err := l.next.Spend(t, buy)
+	if err == nil && t.RankChange != nil {
+		l.announce(t.RankChange)
+	}
+	return err
+}
+
+func (l *Ledger) Reverse(sourceType string, sourceID int64, reason string, at time.Time) (*types.XPTransaction, error) {
+	t, err := l.next.Reverse(sourceType, sourceID, reason, at)
+	if err == nil && t != nil && t.RankChange != nil {
+		l.announce(t.RankChange)
+	}
+	return t, err
+}
+
+func (l *Ledger) Prestige(userID int, at time.Time) (*types.Prestige, error) {
+	p, err := l.next.Prestige(userID, at)
+	if err != nil || p == nil {
+		return p, err
+	}
+	badge := Badge(p.Level)
+	notifications.Send(l.notifier, userID, types.NotificationRank,
+		fmt.Sprintf("Prestige %d! You earned the %s badge", p.Level, badge.Name),
+		fmt.Sprintf("You start again from %s, your %d lifetime XP are kept", p.RankChange.ToRankName, p.LifetimeXP),
+		map[string]any{
+			"prestige_id":     p.Id,
+			"level":           p.Level,
+			"badge":           badge.Name,
+			"rank_history_id": p.RankChange.Id,
+		},
+	)
+	if err := l.webhook.Send(types.WebhookRankChanged, p.RankChange); err != nil {
+		log.Printf("failed to queue rank change webhook for user %d: %v", userID, err)
+	}
+	return p, nil
+}
+
+// Announce sends the rank changes recorded outside of the ledger, by edits of
+// the rank catalogue and XP recomputes
+func (l *Ledger) Announce(changes []types.RankChange) {
+	for i := range changes {
+		l.announce(&changes[i])
+	}
+}
+
+func (l *Ledger) announce(c *types.RankChange) {
+	title := fmt.Sprintf("Rank up! You reached %s", c.ToRankName)
+	body := fmt.Sprintf("%d XP, keep going to reach the next rank", c.XP)
+	if c.Direction == types.RankChangeDown {
+		title = fmt.Sprintf("You are back to %s", c.ToRankName)
+		body = fmt.Sprintf("Your XP went down to %d", c.XP)
+	}
+	notifications.Send(l.notifier, c.UserId, types.NotificationRank, title, body, map[string]any{
+		"rank_history_id": c.Id,
+		"direction":       c.Direction,
+		"from_rank_id":    c.FromRankId,
+		"to_rank_id":      c.ToRankId,
+		"xp":              c.XP,
+	})
+	if err := l.webhook.Send(types.WebhookRankChanged, c); err != nil {
+		log.Printf("failed to queue rank change webhook for user %d: %v", c.UserId, err)
+	}
+}
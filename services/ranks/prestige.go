@@ -0,0 +1,33 @@
+package ranks
+
+import "backend/types"
+
+// PrestigeBadges are the badges of the prestige levels from the first one,
+// levels past the last badge keep it
+var PrestigeBadges = []string{
+	"Bronze Laurel",
+	"Silver Laurel",
+	"Gold Laurel",
+	"Platinum Laurel",
+	"Diamond Laurel",
+	"Master Laurel",
+}
+
+// Badge returns the badge of a prestige level, nil before the first prestige
+func Badge(level int) *types.PrestigeBadge {
+	if level < 1 {
+		return nil
+	}
+	return &types.PrestigeBadge{Level: level, Name: PrestigeBadges[min(level, len(PrestigeBadges))-1]}
+}
+
+// CanPrestige tells if a user holding rankID with xp is at the top of a
+// catalogue ordered by min_xp. A catalogue of a single rank has nothing to
+// climb again.
+func CanPrestige(catalogue []types.Rank, rankID int, xp int) bool {
+	if len(catalogue) == 0 {
+		return false
+	}
+	top := catalogue[len(catalogue)-1]
+	return top.MinXP > 0 && top.Id == rankID && xp >= top.MinXP
+}
@@ -0,0 +1,53 @@
+package ranks
+
+import "backend/types"
+
+// Progress places xp in a catalogue ordered by min_xp, nil for an empty catalogue
+func Progress(catalogue []types.Rank, xp int) *types.RankProgress {
+	current := -1
+	for i, rank := range catalogue {
+		if rank.MinXP > xp {
+			break
+		}
+		current = i
+	}
+	if current < 0 {
+		return nil
+	}
+	progress := &types.RankProgress{Current: catalogue[current], Percent: 100}
+	if current+1 < len(catalogue) {
+		next := catalogue[current+1]
+		progress.Next = &next
+		progress.XPToNextRank = next.MinXP - xp
+		progress.Percent = (xp - progress.Current.MinXP) * 100 / (next.MinXP - progress.Current.MinXP)
+	}
+	return progress
+}
+
+// FillProgress fills the rank progress and prestige badge of the users from
+// the current catalogue, nil users are skipped
+func FillProgress(store types.RankRepo, users ...types.Ranked) error {
+	catalogue, err := store.ListRanks()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if xp, prestige, ok := user.RankStanding(); ok {
+			user.SetRankStanding(Progress(catalogue, xp), Badge(prestige))
+		}
+	}
+	return nil
+}
+
+// Tiers groups a catalogue ordered by min_xp, keeping the order
+func Tiers(catalogue []types.Rank) []types.RankTier {
+	tiers := make([]types.RankTier, 0)
+	for _, rank := range catalogue {
+		if len(tiers) == 0 || tiers[len(tiers)-1].Name != rank.Tier {
+			tiers = append(tiers, types.RankTier{Name: rank.Tier, MinXP: rank.MinXP, Ranks: []types.Rank{}})
+		}
+		last := &tiers[len(tiers)-1]
+		last.Ranks = append(last.Ranks, rank)
+	}
+	return tiers
+}
@@ -0,0 +1,220 @@
+package ranks
+
+import (
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type RankRepoImpl struct {
+	db *sql.DB
+}
+
+func NewRankRepoImpl(db *sql.DB) *RankRepoImpl {
+	return &RankRepoImpl{db: db}
+}
+
+func (r *RankRepoImpl) ListRanks() ([]types.Rank, error) {
+	rows, err := r.db.Query("SELECT id, name, tier, min_xp FROM ranks ORDER BY min_xp")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.Rank, 0)
+	for rows.Next() {
+		var rank types.Rank
+		if err := rows.Scan(&rank.Id, &rank.Name, &rank.Tier, &rank.MinXP); err != nil {
+			return nil, err
+		}
+		list = append(list, rank)
+	}
+	return list, rows.Err()
+}
+
+func (r *RankRepoImpl) GetRank(id int) (*types.Rank, error) {
+	var rank types.Rank
+	err := r.db.QueryRow("SELECT id, name, tier, min_xp FROM ranks WHERE id = ?", id).
+		Scan(&rank.Id, &rank.Name, &rank.Tier, &rank.MinXP)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rank, nil
+}
+
+func (r *RankRepoImpl) CreateRank(rank *types.Rank) ([]types.RankChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO ranks(name, tier, min_xp) VALUES (?,?,?)", rank.Name, rank.Tier, rank.MinXP)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	rank.Id = int(id)
+	changes, err := ReassignUsers(tx, 0, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return changes, tx.Commit()
+}
+
+func (r *RankRepoImpl) UpdateRank(rank *types.Rank) ([]types.RankChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("UPDATE ranks SET name = ?, tier = ?, min_xp = ? WHERE id = ?", rank.Name, rank.Tier, rank.MinXP, rank.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStartingRank(tx); err != nil {
+		return nil, err
+	}
+	changes, err := ReassignUsers(tx, 0, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return changes, tx.Commit()
+}
+
+// DeleteRank moves the users out of the rank before deleting it, the users
+// table cascades on rank deletion. The starting rank cannot be deleted.
+func (r *RankRepoImpl) DeleteRank(id int) ([]types.RankChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	changes, err := ReassignUsers(tx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM ranks WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return changes, tx.Commit()
+}
+
+// checkStartingRank keeps a rank at 0 XP so every user has one
+func checkStartingRank(tx *sql.Tx) error {
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM ranks WHERE min_xp = 0").Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("the catalogue needs a rank starting at 0 XP")
+	}
+	return nil
+}
+
+// ReassignUsers moves every user to the rank matching their XP, leaving out
+// the rank excluded (0 for none), records the moves in rank_history and
+// returns them for the caller to announce once committed
+func ReassignUsers(tx *sql.Tx, excluded int, at time.Time) ([]types.RankChange, error) {
+	const rankForUser = "(SELECT rr.id FROM ranks rr WHERE rr.min_xp <= u.xp AND rr.id <> ? ORDER BY rr.min_xp DESC LIMIT 1)"
+	rows, err := tx.Query(`
+		SELECT u.id, u.rank_id, f.name, r.id, r.name, IF(r.min_xp > COALESCE(f.min_xp, -1), 'up', 'down'), u.xp
+		FROM users u
+		JOIN ranks r ON r.id = `+rankForUser+`
+		LEFT JOIN ranks f ON f.id = u.rank_id
+		WHERE r.id <> u.rank_id
+		FOR UPDATE`,
+		excluded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]types.RankChange, 0)
+	for rows.Next() {
+		c := types.RankChange{CreatedAt: at}
+		if err := rows.Scan(&c.UserId, &c.FromRankId, &c.FromRankName, &c.ToRankId, &c.ToRankName, &c.Direction, &c.XP); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		c := &changes[i]
+		res, err := tx.Exec(
+			"INSERT INTO rank_history(user_id, from_rank_id, from_rank_name, to_rank_id, to_rank_name, direction, xp, created_at) VALUES (?,?,?,?,?,?,?,?)",
+			c.UserId, c.FromRankId, c.FromRankName, c.ToRankId, c.ToRankName, c.Direction, c.XP, c.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if c.Id, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE users SET rank_id = ? WHERE id = ?", c.ToRankId, c.UserId); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func (r *RankRepoImpl) ListRankHistory(userID int, limit, offset int) ([]types.RankChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_rank_id, from_rank_name, to_rank_id, to_rank_name, direction, xp, xp_transaction_id, created_at
+		FROM rank_history WHERE user_id = ? ORDER BY created_at, id LIMIT ? OFFSET ?`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.RankChange, 0)
+	for rows.Next() {
+		var c types.RankChange
+		err := rows.Scan(&c.Id, &c.UserId, &c.FromRankId, &c.FromRankName, &c.ToRankId, &c.ToRankName, &c.Direction, &c.XP, &c.XPTransactionId, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (r *RankRepoImpl) GetPrestigeStatus(userID int) (*types.PrestigeStatus, error) {
+	var s types.PrestigeStatus
+	err := r.db.QueryRow("SELECT prestige, xp, lifetime_xp, rank_id FROM users WHERE id = ?", userID).
+		Scan(&s.Level, &s.XP, &s.LifetimeXP, &s.RankId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+	rows, err := r.db.Query(`
+		SELECT id, user_id, level, from_rank_id, from_rank_name, xp, lifetime_xp, created_at
+		FROM prestige_history WHERE user_id = ? ORDER BY level`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	s.History = make([]types.Prestige, 0)
+	for rows.Next() {
+		var p types.Prestige
+		err := rows.Scan(&p.Id, &p.UserId, &p.Level, &p.FromRankId, &p.FromRankName, &p.XP, &p.LifetimeXP, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.History = append(s.History, p)
+	}
+	return &s, rows.Err()
+}
@@ -0,0 +1,332 @@
+package ranks
+
+import (
+	"backend/middleware"
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.RankRepo
+	ledger   *Ledger
+	auditLog types.AuditLogger
+}
+
+func NewHandler(store types.RankRepo, ledger *Ledger, auditLog types.AuditLogger) *Handler {
+	return &Handler{store: store, ledger: ledger, auditLog: auditLog}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
+	router.HandleFunc("/ranks", h.HandleListRanks).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/rank-history", h.HandleListRankHistory).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/prestige", h.HandleGetPrestige).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/prestige", h.HandlePrestige).Methods(http.MethodPost)
+
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+	authRouter.Handle("/admin/ranks", adminOnly(http.HandlerFunc(h.HandleCreateRank))).Methods(http.MethodPost)
+	authRouter.Handle("/admin/ranks/{id}", adminOnly(http.HandlerFunc(h.HandleUpdateRank))).Methods(http.MethodPut)
+	authRouter.Handle("/admin/ranks/{id}", adminOnly(http.HandlerFunc(h.HandleDeleteRank))).Methods(http.MethodDelete)
+}
+
+// HandleListRanks godoc
+//
+// @Summary 			List the ranks
+// @Description 		The rank catalogue grouped by tier, from Wood I upwards, with the XP needed for each rank
+// @Tags 				Ranks
+// @Produce 			json
+// @Success 			200 {array} types.RankTier
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/ranks [get]
+func (h *Handler) HandleListRanks(w http.ResponseWriter, r *http.Request) {
+	catalogue, err := h.store.ListRanks()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, Tiers(catalogue))
+}
+
+// HandleListRankHistory godoc
+//
+// @Summary 			List my rank changes
+// @Description 		Every rank reached or lost by the authenticated user, oldest first, with the XP at that moment, for a progression chart. The first entry is the rank held when the history started.
+// @Tags 				Ranks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.RankChange
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/rank-history [get]
+func (h *Handler) HandleListRankHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListRankHistory(userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleGetPrestige godoc
+//
+// @Summary 			Get my prestige
+// @Description 		The prestige level and badge of the authenticated user, their XP and lifetime XP, whether they can prestige now and their past prestiges, oldest first
+// @Tags 				Ranks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.PrestigeStatus
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/prestige [get]
+func (h *Handler) HandleGetPrestige(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	status, err := h.store.GetPrestigeStatus(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	catalogue, err := h.store.ListRanks()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status.Badge = Badge(status.Level)
+	status.Eligible = CanPrestige(catalogue, status.RankId, status.XP)
+	for i := range status.History {
+		status.History[i].Badge = Badge(status.History[i].Level)
+	}
+	utils.WriteJSON(w, http.StatusOK, status)
+}
+
+// HandlePrestige godoc
+//
+// @Summary 			Prestige
+// @Description 		At the top rank, start again from the first rank: XP goes back to 0, the prestige level goes up for good with its badge and lifetime XP are kept. Rankings order by prestige level, then XP.
+// @Tags 				Ranks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.Prestige
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/prestige [post]
+func (h *Handler) HandlePrestige(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	prestige, err := h.ledger.Prestige(userID, time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if prestige == nil {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("only users at the top rank can prestige"))
+		return
+	}
+	prestige.Badge = Badge(prestige.Level)
+	audit.Log(h.auditLog, r, types.AuditPrestigeReset, userID, userID, map[string]any{
+		"level":       prestige.Level,
+		"xp":          prestige.XP,
+		"lifetime_xp": prestige.LifetimeXP,
+	})
+	utils.WriteJSON(w, http.StatusOK, prestige)
+}
+
+// HandleCreateRank godoc
+//
+// @Summary 			Add a rank
+// @Description 		Add a rank to the catalogue, users are moved to their new rank right away (admin only)
+// @Tags 				Ranks
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.RankPayload true "Rank"
+// @Success 			201 {object} types.Rank
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/ranks [post]
+func (h *Handler) HandleCreateRank(w http.ResponseWriter, r *http.Request) {
+	rank, ok := h.parseRank(w, r, 0)
+	if !ok {
+		return
+	}
+	changes, err := h.store.CreateRank(rank)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.ledger.Announce(changes)
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminRankChanged, actorID, 0, map[string]any{"action": "create", "rank": rank})
+	utils.WriteJSON(w, http.StatusCreated, rank)
+}
+
+// HandleUpdateRank godoc
+//
+// @Summary 			Change a rank
+// @Description 		Rename a rank, move it to another tier or change its threshold, users are moved to their new rank right away. The starting rank must stay at 0 XP (admin only).
+// @Tags 				Ranks
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Rank ID"
+// @Param 				request body types.RankPayload true "Rank"
+// @Success 			200 {object} types.Rank
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/ranks/{id} [put]
+func (h *Handler) HandleUpdateRank(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid rank id"))
+		return
+	}
+	existing, err := h.store.GetRank(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if existing == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("rank not found"))
+		return
+	}
+	rank, ok := h.parseRank(w, r, id)
+	if !ok {
+		return
+	}
+	if existing.MinXP == 0 && rank.MinXP != 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the starting rank must stay at 0 XP"))
+		return
+	}
+	changes, err := h.store.UpdateRank(rank)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.ledger.Announce(changes)
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminRankChanged, actorID, 0, map[string]any{"action": "update", "before": existing, "rank": rank})
+	utils.WriteJSON(w, http.StatusOK, rank)
+}
+
+// HandleDeleteRank godoc
+//
+// @Summary 			Delete a rank
+// @Description 		Remove a rank from the catalogue, its users fall back to the rank below. The starting rank cannot be deleted (admin only).
+// @Tags 				Ranks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Rank ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/admin/ranks/{id} [delete]
+func (h *Handler) HandleDeleteRank(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid rank id"))
+		return
+	}
+	existing, err := h.store.GetRank(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if existing == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("rank not found"))
+		return
+	}
+	if existing.MinXP == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("the starting rank cannot be deleted"))
+		return
+	}
+	changes, err := h.store.DeleteRank(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.ledger.Announce(changes)
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminRankChanged, actorID, 0, map[string]any{"action": "delete", "rank": existing})
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Rank deleted"})
+}
+
+// parseRank validates the payload and checks that the name and the threshold
+// are not used by another rank than id, it writes the error itself
+func (h *Handler) parseRank(w http.ResponseWriter, r *http.Request, id int) (*types.Rank, bool) {
+	var payload types.RankPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	rank := &types.Rank{Id: id, Name: strings.TrimSpace(payload.Name), Tier: strings.TrimSpace(payload.Tier), MinXP: payload.MinXP}
+	if rank.Name == "" || len(rank.Name) > 255 || rank.MinXP < 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("a name and a non negative min_xp are required"))
+		return nil, false
+	}
+	if rank.Tier == "" {
+		// "Gold III" belongs to the Gold tier
+		rank.Tier = strings.Fields(rank.Name)[0]
+	}
+	if len(rank.Tier) > 64 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("tier is too long"))
+		return nil, false
+	}
+	catalogue, err := h.store.ListRanks()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	for _, other := range catalogue {
+		if other.Id == id {
+			continue
+		}
+		if other.Name == rank.Name || other.MinXP == rank.MinXP {
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("rank %q already uses this name or threshold", other.Name))
+			return nil, false
+		}
+	}
+	return rank, true
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,247 @@
+package seasons
+
+import (
+	"backend/services/ranking"
+	"backend/types"
+	"database/sql"
+	"time"
+)
+
+type SeasonRepoImpl struct {
+	db *sql.DB
+}
+
+func NewSeasonRepoImpl(db *sql.DB) *SeasonRepoImpl {
+	return &SeasonRepoImpl{db: db}
+}
+
+// the users are aliased u in every query
+var rankedUsers = ranking.RankedUsers("u")
+
+const seasonColumns = "id, name, starts_at, ends_at, closed_at"
+
+const resultColumns = "r.id, r.season_id, s.name, r.user_id, r.username, r.country, r.xp, r.global_placement, r.country_placement, r.reward_xp, r.rewarded_at"
+
+func (s *SeasonRepoImpl) EnsureSeason(name string, start, end time.Time) (*types.Season, error) {
+	_, err := s.db.Exec("INSERT IGNORE INTO seasons(name, starts_at, ends_at) VALUES (?,?,?)", name, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var season types.Season
+	row := s.db.QueryRow("SELECT "+seasonColumns+" FROM seasons WHERE starts_at = ?", start)
+	if err := scanRowIntoSeason(row, &season); err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+func (s *SeasonRepoImpl) GetSeason(id int) (*types.Season, error) {
+	var season types.Season
+	row := s.db.QueryRow("SELECT "+seasonColumns+" FROM seasons WHERE id = ?", id)
+	if err := scanRowIntoSeason(row, &season); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &season, nil
+}
+
+func (s *SeasonRepoImpl) ListSeasons(limit, offset int) ([]types.Season, error) {
+	return s.listSeasons("SELECT "+seasonColumns+" FROM seasons ORDER BY starts_at DESC LIMIT ? OFFSET ?", limit, offset)
+}
+
+func (s *SeasonRepoImpl) ListSeasonsToClose(now time.Time) ([]types.Season, error) {
+	return s.listSeasons("SELECT "+seasonColumns+" FROM seasons WHERE closed_at IS NULL AND ends_at <= ? ORDER BY starts_at", now)
+}
+
+func (s *SeasonRepoImpl) listSeasons(query string, args ...any) ([]types.Season, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.Season, 0)
+	for rows.Next() {
+		var season types.Season
+		if err := scanRowIntoSeason(rows, &season); err != nil {
+			return nil, err
+		}
+		list = append(list, season)
+	}
+	return list, rows.Err()
+}
+
+func (s *SeasonRepoImpl) CloseSeason(id int, rewards []types.SeasonReward, at time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	// the row lock waits for the ledger transactions adding to the season
+	var closedAt *time.Time
+	if err := tx.QueryRow("SELECT closed_at FROM seasons WHERE id = ? FOR UPDATE", id).Scan(&closedAt); err != nil {
+		return false, err
+	}
+	if closedAt != nil {
+		return false, nil
+	}
+	_, err = tx.Exec(`
+		INSERT INTO season_results (season_id, user_id, username, country, xp, global_placement, country_placement)
+		SELECT sx.season_id, u.id, u.username, NULLIF(u.country, ''), sx.xp,
+			RANK() OVER (ORDER BY sx.xp DESC),
+			IF(NULLIF(u.country, '') IS NULL, NULL, RANK() OVER (PARTITION BY NULLIF(u.country, '') ORDER BY sx.xp DESC))
+		FROM season_xp sx
+		JOIN users u ON u.id = sx.user_id
+		WHERE sx.season_id = ? AND sx.xp > 0 AND `+rankedUsers,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	// the tiers are sorted, each placement keeps the best one it reached
+	for _, reward := range rewards {
+		_, err := tx.Exec(
+			"UPDATE season_results SET reward_xp = ? WHERE season_id = ? AND global_placement <= ? AND reward_xp = 0",
+			reward.XP, id, reward.Top,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE seasons SET closed_at = ? WHERE id = ?", at, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *SeasonRepoImpl) ListSeasonRanking(season *types.Season, country string, limit, offset int) ([]types.SeasonEntry, error) {
+	var rows *sql.Rows
+	var err error
+	switch {
+	case season.ClosedAt != nil && country == "":
+		rows, err = s.db.Query(
+			`SELECT user_id, username, country, xp, global_placement, reward_xp
+			FROM season_results WHERE season_id = ?
+			ORDER BY global_placement, user_id LIMIT ? OFFSET ?`,
+			season.Id, limit, offset,
+		)
+	case season.ClosedAt != nil:
+		rows, err = s.db.Query(
+			`SELECT user_id, username, country, xp, country_placement, reward_xp
+			FROM season_results WHERE season_id = ? AND country = ?
+			ORDER BY country_placement, user_id LIMIT ? OFFSET ?`,
+			season.Id, country, limit, offset,
+		)
+	case country == "":
+		rows, err = s.db.Query(
+			`SELECT u.id, u.username, u.country, sx.xp, RANK() OVER (ORDER BY sx.xp DESC), 0
+			FROM season_xp sx JOIN users u ON u.id = sx.user_id
+			WHERE sx.season_id = ? AND sx.xp > 0 AND `+rankedUsers+`
+			ORDER BY sx.xp DESC, u.id LIMIT ? OFFSET ?`,
+			season.Id, limit, offset,
+		)
+	default:
+		rows, err = s.db.Query(
+			`SELECT u.id, u.username, u.country, sx.xp, RANK() OVER (ORDER BY sx.xp DESC), 0
+			FROM season_xp sx JOIN users u ON u.id = sx.user_id
+			WHERE sx.season_id = ? AND sx.xp > 0 AND u.country = ? AND `+rankedUsers+`
+			ORDER BY sx.xp DESC, u.id LIMIT ? OFFSET ?`,
+			season.Id, country, limit, offset,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.SeasonEntry, 0)
+	for rows.Next() {
+		var e types.SeasonEntry
+		if err := rows.Scan(&e.UserID, &e.Username, &e.Country, &e.XP, &e.Rank, &e.RewardXP); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (s *SeasonRepoImpl) GetSeasonEntry(season *types.Season, userID int) (*types.SeasonEntry, error) {
+	var row *sql.Row
+	if season.ClosedAt != nil {
+		row = s.db.QueryRow(
+			"SELECT user_id, username, country, xp, global_placement, reward_xp FROM season_results WHERE season_id = ? AND user_id = ?",
+			season.Id, userID,
+		)
+	} else {
+		row = s.db.QueryRow(`
+			SELECT *
+			FROM (
+				SELECT u.id, u.username, u.country, sx.xp, RANK() OVER (ORDER BY sx.xp DESC), 0
+				FROM season_xp sx JOIN users u ON u.id = sx.user_id
+				WHERE sx.season_id = ? AND sx.xp > 0 AND `+rankedUsers+`
+			) AS ranked
+			WHERE id = ?;`,
+			season.Id, userID,
+		)
+	}
+	var e types.SeasonEntry
+	if err := row.Scan(&e.UserID, &e.Username, &e.Country, &e.XP, &e.Rank, &e.RewardXP); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *SeasonRepoImpl) ListUserResults(userID int, limit, offset int) ([]types.SeasonResult, error) {
+	return s.listResults(
+		"SELECT "+resultColumns+" FROM season_results r JOIN seasons s ON s.id = r.season_id WHERE r.user_id = ? ORDER BY s.starts_at DESC LIMIT ? OFFSET ?",
+		userID, limit, offset,
+	)
+}
+
+func (s *SeasonRepoImpl) ListUnrewardedResults() ([]types.SeasonResult, error) {
+	return s.listResults(
+		"SELECT " + resultColumns + " FROM season_results r JOIN seasons s ON s.id = r.season_id WHERE r.reward_xp > 0 AND r.rewarded_at IS NULL ORDER BY r.id",
+	)
+}
+
+func (s *SeasonRepoImpl) listResults(query string, args ...any) ([]types.SeasonResult, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.SeasonResult, 0)
+	for rows.Next() {
+		var r types.SeasonResult
+		err := rows.Scan(
+			&r.Id, &r.SeasonId, &r.SeasonName, &r.UserId, &r.Username, &r.Country, &r.XP,
+			&r.GlobalPlacement, &r.CountryPlacement, &r.RewardXP, &r.RewardedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *SeasonRepoImpl) MarkResultRewarded(id int64, at time.Time) error {
+	_, err := s.db.Exec("UPDATE season_results SET rewarded_at = ? WHERE id = ? AND rewarded_at IS NULL", at, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoSeason(row scanner, season *types.Season) error {
+	err := row.Scan(&season.Id, &season.Name, &season.StartsAt, &season.EndsAt, &season.ClosedAt)
+	if err != nil {
+		return err
+	}
+	describe(season)
+	return nil
+}
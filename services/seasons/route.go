@@ -0,0 +1,192 @@
+package seasons
+
+import (
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store   types.SeasonRepo
+	service *Service
+}
+
+func NewHandler(store types.SeasonRepo, service *Service) *Handler {
+	return &Handler{store: store, service: service}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	authRouter.Handle("/seasons", middleware.RequireScope(auth.ScopeRankingRead, h.HandleListSeasons)).Methods(http.MethodGet)
+	authRouter.Handle("/seasons/current", middleware.RequireScope(auth.ScopeRankingRead, h.HandleGetCurrentSeason)).Methods(http.MethodGet)
+	authRouter.Handle("/seasons/{id:[0-9]+}/ranking/global", middleware.RequireScope(auth.ScopeRankingRead, h.HandleGetGlobalRanking)).Methods(http.MethodGet)
+	authRouter.Handle("/seasons/{id:[0-9]+}/ranking/{country}", middleware.RequireScope(auth.ScopeRankingRead, h.HandleGetLocalRanking)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/seasons", h.HandleListMyResults).Methods(http.MethodGet)
+}
+
+// HandleListSeasons godoc
+//
+// @Summary 			List seasons
+// @Description 		The running and past seasons, newest first. Seasons are calendar months (UTC).
+// @Tags 				Seasons
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.Season
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/seasons [get]
+func (h *Handler) HandleListSeasons(w http.ResponseWriter, r *http.Request) {
+	// the running season is listed even before anyone earned XP in it
+	if _, err := h.service.Current(); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListSeasons(limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleGetCurrentSeason godoc
+//
+// @Summary 			Get the current season
+// @Description 		The running season, the rewards granted by global placement when it ends and the standing of the authenticated user
+// @Tags 				Seasons
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.CurrentSeasonResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/seasons/current [get]
+func (h *Handler) HandleGetCurrentSeason(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	season, err := h.service.Current()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	me, err := h.store.GetSeasonEntry(season, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.CurrentSeasonResponse{Season: *season, Rewards: Rewards, Me: me})
+}
+
+// HandleGetGlobalRanking godoc
+//
+// @Summary 			Get a season's global ranking
+// @Description 		The live ranking of a running season by season XP, or the final placements and rewards of a closed one
+// @Tags 				Seasons
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Season ID"
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.SeasonEntry
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/seasons/{id}/ranking/global [get]
+func (h *Handler) HandleGetGlobalRanking(w http.ResponseWriter, r *http.Request) {
+	h.ranking(w, r, "")
+}
+
+// HandleGetLocalRanking godoc
+//
+// @Summary 			Get a season's ranking by country
+// @Description 		The live ranking of a running season in a country, or the final country placements of a closed one. Rewards are granted by global placement.
+// @Tags 				Seasons
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Season ID"
+// @Param 				country path string true "Country code"
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.SeasonEntry
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/seasons/{id}/ranking/{country} [get]
+func (h *Handler) HandleGetLocalRanking(w http.ResponseWriter, r *http.Request) {
+	h.ranking(w, r, mux.Vars(r)["country"])
+}
+
+func (h *Handler) ranking(w http.ResponseWriter, r *http.Request, country string) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid season id"))
+		return
+	}
+	season, err := h.store.GetSeason(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if season == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("season not found"))
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListSeasonRanking(season, country, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleListMyResults godoc
+//
+// @Summary 			List my season results
+// @Description 		The final placements and rewards of the authenticated user in the closed seasons they played, newest first
+// @Tags 				Seasons
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.SeasonResult
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/seasons [get]
+func (h *Handler) HandleListMyResults(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListUserResults(userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
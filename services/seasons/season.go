@@ -0,0 +1,126 @@
+package seasons
+
+import (
+	"backend/services/notifications"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Rewards are granted by global placement when a season closes, the best
+// tier reached only. Keep them sorted by Top.
+var Rewards = []types.SeasonReward{
+	{Top: 1, XP: 1000},
+	{Top: 3, XP: 500},
+	{Top: 10, XP: 250},
+	{Top: 100, XP: 100},
+}
+
+// Bounds returns the season t falls in: the calendar month (UTC)
+func Bounds(t time.Time) (start time.Time, end time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Name returns the name of the season starting at start
+func Name(start time.Time) string {
+	return start.Format("January 2006")
+}
+
+// describe sets the fields computed when a season is read
+func describe(s *types.Season) {
+	s.Status = types.SeasonOpen
+	if s.ClosedAt != nil {
+		s.Status = types.SeasonClosed
+	}
+}
+
+// Service opens and closes the seasons and grants their rewards. It reads the
+// time from its clock only.
+type Service struct {
+	store    types.SeasonRepo
+	ledger   types.XPLedger
+	notifier types.Notifier
+	clock    utils.Clock
+}
+
+func NewService(store types.SeasonRepo, ledger types.XPLedger, notifier types.Notifier, clock utils.Clock) *Service {
+	return &Service{store: store, ledger: ledger, notifier: notifier, clock: clock}
+}
+
+// Current returns the running season, created if needed
+func (s *Service) Current() (*types.Season, error) {
+	start, end := Bounds(s.clock.Now())
+	season, err := s.store.EnsureSeason(Name(start), start, end)
+	if err != nil {
+		return nil, err
+	}
+	describe(season)
+	return season, nil
+}
+
+// CloseEnded saves the results of the seasons that ended, opens the running
+// one and credits the rewards not credited yet. It is idempotent and meant to
+// run regularly.
+func (s *Service) CloseEnded() error {
+	now := s.clock.Now()
+	ended, err := s.store.ListSeasonsToClose(now)
+	if err != nil {
+		return err
+	}
+	for _, season := range ended {
+		closed, err := s.store.CloseSeason(season.Id, Rewards, now)
+		if err != nil {
+			return err
+		}
+		if closed {
+			log.Printf("closed season %q", season.Name)
+		}
+	}
+	if _, err := s.Current(); err != nil {
+		return err
+	}
+	return s.payRewards()
+}
+
+// payRewards credits the rewards of closed seasons through the ledger, the
+// result is the source so a reward is never credited twice
+func (s *Service) payRewards() error {
+	results, err := s.store.ListUnrewardedResults()
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		result := &results[i]
+		now := s.clock.Now()
+		source := types.XPSourceSeason
+		t := &types.XPTransaction{
+			UserId:     result.UserId,
+			Delta:      result.RewardXP,
+			Reason:     types.XPReasonSeasonReward,
+			SourceType: &source,
+			SourceId:   &result.Id,
+			Note:       result.SeasonName,
+			CreatedAt:  now,
+		}
+		recorded, err := s.ledger.Record(t)
+		if err != nil {
+			return err
+		}
+		if err := s.store.MarkResultRewarded(result.Id, now); err != nil {
+			return err
+		}
+		if !recorded {
+			continue
+		}
+		notifications.Send(s.notifier, result.UserId, types.NotificationSeason,
+			fmt.Sprintf("Season %s is over", result.SeasonName),
+			fmt.Sprintf("You finished #%d with %d XP and earned %d XP.", result.GlobalPlacement, result.XP, result.RewardXP),
+			map[string]any{"season_id": result.SeasonId, "placement": result.GlobalPlacement, "reward_xp": result.RewardXP},
+		)
+	}
+	return nil
+}
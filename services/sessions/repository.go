@@ -0,0 +1,137 @@
+package sessions
+
+import (
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type SessionRepoImpl struct {
+	db *sql.DB
+}
+
+func NewSessionRepoImpl(db *sql.DB) *SessionRepoImpl {
+	return &SessionRepoImpl{db: db}
+}
+
+const sessionColumns = "id, user_id, device_name, user_agent, ip, created_at, last_seen_at, revoked_at"
+
+func (s *SessionRepoImpl) CreateSession(session *types.Session) error {
+	res, err := s.db.Exec(
+		"INSERT INTO sessions(user_id, device_name, user_agent, ip, created_at, last_seen_at) VALUES (?,?,?,?,?,?)",
+		session.UserId,
+		session.DeviceName,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt,
+		session.LastSeenAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.Id = int(id)
+	return nil
+}
+
+func (s *SessionRepoImpl) GetSession(id int) (*types.Session, error) {
+	var session types.Session
+	// roles change without a new login, they are read on every request, and
+	// the sessions of anonymized users are never found
+	row := s.db.QueryRow(`
+		SELECT s.id, s.user_id, s.device_name, s.user_agent, s.ip, s.created_at, s.last_seen_at, s.revoked_at, u.role
+		FROM sessions s JOIN users u ON u.id = s.user_id WHERE s.id = ? AND u.anonymized_at IS NULL`,
+		id,
+	)
+	err := row.Scan(
+		&session.Id, &session.UserId, &session.DeviceName, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt, &session.Role,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions returns the active sessions of a user, most recently used first
+func (s *SessionRepoImpl) ListSessions(userID int) ([]types.Session, error) {
+	rows, err := s.db.Query(
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? AND revoked_at IS NULL ORDER BY last_seen_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.Session, 0)
+	for rows.Next() {
+		var session types.Session
+		if err := scanRowIntoSession(rows, &session); err != nil {
+			return nil, err
+		}
+		list = append(list, session)
+	}
+	return list, rows.Err()
+}
+
+func (s *SessionRepoImpl) RevokeSession(userID int, id int) error {
+	res, err := s.db.Exec(
+		"UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// TouchSession updates last_seen_at at most once a minute to avoid a write on every request
+func (s *SessionRepoImpl) TouchSession(id int) error {
+	now := time.Now()
+	_, err := s.db.Exec(
+		"UPDATE sessions SET last_seen_at = ? WHERE id = ? AND last_seen_at < ?",
+		now, id, now.Add(-time.Minute),
+	)
+	return err
+}
+
+// IsKnownDevice reports whether the user already logged in from this device before
+func (s *SessionRepoImpl) IsKnownDevice(userID int, deviceName, userAgent string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM sessions WHERE user_id = ? AND device_name = ? AND user_agent = ?)",
+		userID, deviceName, userAgent,
+	).Scan(&exists)
+	return exists, err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoSession(row scanner, session *types.Session) error {
+	return row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.RevokedAt,
+	)
+}
@@ -0,0 +1,86 @@
+package sessions
+
+import (
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.SessionRepo
+	auditLog types.AuditLogger
+}
+
+func NewHandler(store types.SessionRepo, auditLog types.AuditLogger) *Handler {
+	return &Handler{store: store, auditLog: auditLog}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me/sessions", h.HandleListSessions).Methods(http.MethodGet)
+	router.HandleFunc("/me/sessions/{id}", h.HandleRevokeSession).Methods(http.MethodDelete)
+}
+
+// HandleListSessions godoc
+//
+// @Summary 			List active sessions
+// @Description 		List the devices the authenticated user is logged in on. The session of the current token is flagged with current=true.
+// @Tags 				Sessions
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.Session
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/sessions [get]
+func (h *Handler) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.CurrentPrincipal(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.store.ListSessions(principal.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range list {
+		list[i].Current = list[i].Id == principal.SessionID
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleRevokeSession godoc
+//
+// @Summary 			Sign out a device
+// @Description 		Revoke a session, every token issued for it stops working immediately
+// @Tags 				Sessions
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Session ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Router 				/me/sessions/{id} [delete]
+func (h *Handler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.store.RevokeSession(userID, id); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditSessionRevoked, userID, userID, map[string]any{"session_id": id})
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Session revoked successfully"})
+}
@@ -1,7 +1,6 @@
 package stats
 
 import (
-	"backend/config"
 	"backend/types"
 	"database/sql"
 	"fmt"
@@ -30,7 +29,6 @@ func (s *StatsRepoImpl) GetUserStats(id int) (*types.ExtendedStats, error) {
 	extendedStats.CreatedAt = stats.CreatedAt
 	extendedStats.LastUpdated = stats.LastUpdated
 	// here do all the aggregation needed to get
-	extendedStats.XPMultiplier = config.BaseMultiplier + config.GrowthFactor*float64(stats.CurrentStreak-1)
 	// TotalPomodoros
 	if err := s.getUserTotalPomodoros(id, &extendedStats.TotalPomodoros); err != nil {
 		return nil, err
@@ -93,7 +91,7 @@ func scanRowIntoStats(row *sql.Row, stats *types.Stats) error {
 }
 func (s *StatsRepoImpl) AddUserStats(payload *types.Stats) (*types.Stats, error) {
 	// insert into stats table
-	_, err := s.db.Exec("Insert into stats values (?, ?, ?, ?, ?)",
+	_, err := s.db.Exec("Insert into stats (user_id, longest_streak, current_streak, last_updated, created_at) values (?, ?, ?, ?, ?)",
 		payload.UserID,
 		payload.LongestStreak,
 		payload.CurrentStreak,
@@ -105,22 +103,6 @@ func (s *StatsRepoImpl) AddUserStats(payload *types.Stats) (*types.Stats, error)
 	}
 	return payload, nil
 }
-func (s *StatsRepoImpl) UpdateUserStats(payload *types.Stats) (*types.Stats, error) {
-	_, err := s.db.Exec("Update stats set longest_streak = ?, current_streak = ?, last_updated = ? where user_id = ?",
-		payload.LongestStreak,
-		payload.CurrentStreak,
-		payload.LastUpdated,
-		payload.UserID,
-	)
-	if err != nil {
-		return nil, err
-	}
-	err = s.GetUserStatsRow(payload.UserID, payload)
-	if err != nil {
-		return nil, err
-	}
-	return payload, nil
-}
 func (s *StatsRepoImpl) GetUserHeatmap(p *types.HeatMapPayload) ([]types.HeatMapEntry, error) {
 	var list []types.HeatMapEntry
 	rows, err := s.db.Query(`
@@ -149,12 +131,3 @@ func (s *StatsRepoImpl) GetUserHeatmap(p *types.HeatMapPayload) ([]types.HeatMap
 	}
 	return list, nil
 }
-func (s *StatsRepoImpl) UpsertUserHeatmapEntry(p *types.HeatMap) error {
-	_, err := s.db.Exec(`
-	INSERT INTO heatmap (user_id, date, count)
-	VALUES (?, ?, ?)
-	ON DUPLICATE KEY UPDATE count = VALUES(count);`,
-		p.UserID, p.Date, p.Count,
-	)
-	return err
-}
@@ -1,6 +1,9 @@
 package stats
 
 import (
+	"backend/middleware"
+	"backend/services/auth"
+	"backend/services/xp"
 	"backend/types"
 	"backend/utils"
 	"net/http"
@@ -12,18 +15,17 @@ import (
 
 type Handler struct {
 	store types.StatsRepo
+	rules *xp.Rules
 }
 
-func NewHandler(store types.StatsRepo) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.StatsRepo, rules *xp.Rules) *Handler {
+	return &Handler{store: store, rules: rules}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/stats/heatmap", h.GetUserHeatMap).Methods(http.MethodGet)
-	router.HandleFunc("/stats/heatmap", h.UpsertUserHeatmapEntry).Methods(http.MethodPut)
-	router.HandleFunc("/stats/{id}", h.GetUserStats).Methods(http.MethodGet)
-	router.HandleFunc("/stats", h.UpdateUserStats).Methods(http.MethodPut)
-	router.HandleFunc("/stats", h.AddUserStats).Methods(http.MethodPost)
+	router.Handle("/stats/heatmap", middleware.RequireScope(auth.ScopeStatsRead, h.GetUserHeatMap)).Methods(http.MethodGet)
+	router.Handle("/stats/{id}", middleware.RequireScope(auth.ScopeStatsRead, h.GetUserStats)).Methods(http.MethodGet)
+	router.Handle("/stats", middleware.RequireScope(auth.ScopeStatsWrite, h.AddUserStats)).Methods(http.MethodPost)
 }
 
 // GetUserStats docs
@@ -52,49 +54,16 @@ func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	// the multiplier the next focus session would get under the rules in force
+	rules := h.rules.Current()
+	userCompleteStats.XPMultiplier = xp.Multiplier(&rules, userCompleteStats.CurrentStreak)
 	utils.WriteJSON(w, http.StatusOK, userCompleteStats)
 }
 
-// UpdateUserStats docs
-//
-// @Summary 			Update user statistics
-// @Description 		Update statistics for a user by ID
-// @Tags 				stats
-// @Accept 				json
-// @Produce 			json
-// @Security 			ApiKeyAuth
-// @Success 			200 {object} types.Stats
-// @Failure 			400 {object} types.ErrorResponse
-// @Failure 			401 {object} types.ErrorResponse
-// @Failure 			500 {object} types.ErrorResponse
-// @Param 				stats body types.StatsPayload true "Stats update payload"
-// @Router 				/stats [put]
-func (h *Handler) UpdateUserStats(w http.ResponseWriter, r *http.Request) {
-	var payload types.StatsPayload
-	var stats types.Stats
-	if err := utils.ParseJson(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-	// copy the payload into the stats
-	stats.UserID = payload.UserID
-	stats.LongestStreak = payload.LongestStreak
-	stats.CurrentStreak = payload.CurrentStreak
-	// set the last updated to the current time
-	stats.LastUpdated = time.Now()
-
-	_, err := h.store.UpdateUserStats(&stats)
-	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
-	utils.WriteJSON(w, http.StatusOK, stats)
-}
-
 // AddUserStats docs
 //
 // @Summary 			Add user statistics
-// @Description 		Add statistics for a user by ID
+// @Description 		Create the statistics row of a user by ID. Streaks start at 0 and are only kept by the server, from the focus sessions saved with POST /pomodoro.
 // @Tags 				stats
 // @Accept 				json
 // @Produce 			json
@@ -102,8 +71,9 @@ func (h *Handler) UpdateUserStats(w http.ResponseWriter, r *http.Request) {
 // @Success 			201 {object} types.Stats
 // @Failure 			400 {object} types.ErrorResponse
 // @Failure 			401 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
 // @Failure 			500 {object} types.ErrorResponse
-// @Param 				stats body types.Stats true "Stats update payload"
+// @Param 				stats body types.StatsPayload true "Stats payload"
 // @Router 				/stats [post]
 func (h *Handler) AddUserStats(w http.ResponseWriter, r *http.Request) {
 	var payload types.StatsPayload
@@ -112,10 +82,12 @@ func (h *Handler) AddUserStats(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
-	// copy values of payload into stats
+	if err := auth.CheckCanActOn(r, payload.UserID); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+	// streaks are written by the streak engine only
 	stats.UserID = payload.UserID
-	stats.CurrentStreak = payload.CurrentStreak
-	stats.LongestStreak = payload.LongestStreak
 	stats.CreatedAt = time.Now()
 	stats.LastUpdated = time.Now()
 	_, err := h.store.AddUserStats(&stats)
@@ -129,7 +101,7 @@ func (h *Handler) AddUserStats(w http.ResponseWriter, r *http.Request) {
 // GetUserHeatMap docs
 //
 // @Summary 			Get user heatmap data
-// @Description 		Retrieve heatmap data for a user within a specified date range. Returns daily pomodoro counts including days with zero activity.
+// @Description 		Retrieve heatmap data for a user within a specified date range. Returns daily pomodoro counts including days with zero activity. The counts are kept by the server from the completed focus sessions.
 // @Tags 				stats
 // @Accept 				json
 // @Produce 			json
@@ -159,33 +131,3 @@ func (h *Handler) GetUserHeatMap(w http.ResponseWriter, r *http.Request) {
 		types.HeatMapResponse{UserID: payload.UserID, Data: rows},
 	)
 }
-
-// UpsertUserHeatmapEntry docs
-//
-// @Summary 			Create or update heatmap entry
-// @Description 		Create a new heatmap entry for a user on a specific date, or update the count if an entry already exists. Uses UPSERT operation.
-// @Tags 				stats
-// @Accept 				json
-// @Produce 			json
-// @Security 			ApiKeyAuth
-// @Success 			200 {object} types.HeatMap "Updated heatmap entry"
-// @Failure 			400 {object} types.ErrorResponse "Invalid request payload"
-// @Failure 			401 {object} types.ErrorResponse "Unauthorized"
-// @Failure 			500 {object} types.ErrorResponse "Internal server error"
-// @Param 				payload body types.HeatMap true "Heatmap entry with user ID, date, and pomodoro count"
-// @Router 				/stats/heatmap [put]
-// @Example 			Request body: {"user_id": 1, "date": "2024-01-15T00:00:00Z", "count": 3}
-// @Example 			Response: {"user_id": 1, "date": "2024-01-15T00:00:00Z", "count": 3}
-func (h *Handler) UpsertUserHeatmapEntry(w http.ResponseWriter, r *http.Request) {
-	var payload types.HeatMap
-	if err := utils.ParseJson(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
-		return
-	}
-	if err := h.store.UpsertUserHeatmapEntry(&payload); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
-	}
-	utils.WriteJSON(w, http.StatusOK, payload)
-
-}
@@ -0,0 +1,280 @@
+package streaks
+
+import (
+	"backend/config"
+	"backend/services/notifications"
+	"backend/types"
+	"backend/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Engine keeps the streaks: a completed focus session extends the streak once
+// per day (UTC), a missed day consumes a held freeze or breaks the streak.
+// It reads the time from its clock only.
+type Engine struct {
+	store        types.StreakRepo
+	ledger       types.XPLedger
+	notifier     types.Notifier
+	achievements types.AchievementEvaluator
+	clock        utils.Clock
+}
+
+func NewEngine(store types.StreakRepo, ledger types.XPLedger, notifier types.Notifier, achievements types.AchievementEvaluator, clock utils.Clock) *Engine {
+	return &Engine{store: store, ledger: ledger, notifier: notifier, achievements: achievements, clock: clock}
+}
+
+func (e *Engine) Now() time.Time {
+	return e.clock.Now()
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// settle goes through the days missed since the last streak day, before
+// today. Each one consumes a freeze, the first one without freeze breaks the
+// streak.
+func settle(s *types.StreakState, today time.Time, now time.Time) []types.StreakEvent {
+	events := make([]types.StreakEvent, 0)
+	if s.CurrentStreak == 0 || s.LastStreakDay == nil {
+		return events
+	}
+	for day := s.LastStreakDay.AddDate(0, 0, 1); day.Before(today); day = day.AddDate(0, 0, 1) {
+		missed := day
+		if s.Freezes > 0 {
+			s.Freezes--
+			s.LastStreakDay = &missed
+			events = append(events, types.StreakEvent{UserId: s.UserId, Kind: types.StreakFrozen, Day: &missed, Streak: s.CurrentStreak, CreatedAt: now})
+			continue
+		}
+		s.BrokenStreak = s.CurrentStreak
+		s.BrokenDay = &missed
+		s.BrokenAt = &now
+		s.CurrentStreak = 0
+		events = append(events, types.StreakEvent{UserId: s.UserId, Kind: types.StreakBroken, Day: &missed, Streak: s.BrokenStreak, CreatedAt: now})
+		break
+	}
+	return events
+}
+
+// RecordSession counts today in the streak of the user, after settling the
+// days missed since the last session
+func (e *Engine) RecordSession(userID int) ([]types.UnlockedAchievement, error) {
+	now := e.clock.Now()
+	today := startOfDay(now)
+	var events []types.StreakEvent
+	var state types.StreakState
+	err := e.store.UpdateStreak(userID, func(s *types.StreakState) ([]types.StreakEvent, error) {
+		events = settle(s, today, now)
+		if s.LastStreakDay == nil || s.LastStreakDay.Before(today) {
+			s.CurrentStreak++
+			s.LastStreakDay = &today
+			s.LongestStreak = max(s.LongestStreak, s.CurrentStreak)
+			if s.CurrentStreak%config.StreakFreezeEvery == 0 && s.Freezes < config.MaxStreakFreezes {
+				s.Freezes++
+				events = append(events, types.StreakEvent{UserId: userID, Kind: types.StreakFreezeEarned, Day: &today, Streak: s.CurrentStreak, CreatedAt: now})
+			}
+		}
+		state = *s
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.announce(&state, events)
+	return e.evaluate(userID, state.CurrentStreak, now), nil
+}
+
+// SettleAll settles the streaks of the users who missed yesterday, it is
+// idempotent and meant to run regularly
+func (e *Engine) SettleAll() error {
+	now := e.clock.Now()
+	today := startOfDay(now)
+	users, err := e.store.ListStreaksToSettle(today.AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+	for _, userID := range users {
+		var events []types.StreakEvent
+		var state types.StreakState
+		err := e.store.UpdateStreak(userID, func(s *types.StreakState) ([]types.StreakEvent, error) {
+			events = settle(s, today, now)
+			state = *s
+			return events, nil
+		})
+		if err != nil {
+			return err
+		}
+		e.announce(&state, events)
+	}
+	return nil
+}
+
+// BuyFreeze trades XP for a freeze, up to the maximum held
+func (e *Engine) BuyFreeze(userID int) (*types.StreakEvent, error) {
+	now := e.clock.Now()
+	return e.buy(userID, config.StreakFreezeCost, types.XPReasonStreakFreeze, now, func(s *types.StreakState) (*types.StreakEvent, error) {
+		if err := CheckFreezePurchase(s); err != nil {
+			return nil, err
+		}
+		s.Freezes++
+		return &types.StreakEvent{UserId: userID, Kind: types.StreakFreezePurchased, Streak: s.CurrentStreak, XPCost: config.StreakFreezeCost, CreatedAt: now}, nil
+	})
+}
+
+// Repair gives back a broken streak for XP, once, within the repair window
+func (e *Engine) Repair(userID int) (*types.StreakEvent, error) {
+	now := e.clock.Now()
+	event, err := e.buy(userID, config.StreakRepairCost, types.XPReasonStreakRepair, now, func(s *types.StreakState) (*types.StreakEvent, error) {
+		if err := CheckRepair(s, now); err != nil {
+			return nil, err
+		}
+		// the sessions done since the break carry on from the repaired streak
+		s.CurrentStreak += s.BrokenStreak
+		s.LongestStreak = max(s.LongestStreak, s.CurrentStreak)
+		if s.LastStreakDay == nil || s.LastStreakDay.Before(*s.BrokenDay) {
+			s.LastStreakDay = s.BrokenDay
+		}
+		event := &types.StreakEvent{UserId: userID, Kind: types.StreakRepaired, Day: s.BrokenDay, Streak: s.CurrentStreak, XPCost: config.StreakRepairCost, CreatedAt: now}
+		s.BrokenStreak = 0
+		s.BrokenDay = nil
+		s.BrokenAt = nil
+		return event, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.evaluate(userID, event.Streak, now)
+	return event, nil
+}
+
+// buy applies a purchase to the streak with change, which returns the
+// purchase event, and charges cost XP in the same database transaction
+func (e *Engine) buy(userID int, cost int, reason string, now time.Time, change func(*types.StreakState) (*types.StreakEvent, error)) (*types.StreakEvent, error) {
+	source := types.XPSourceStreak
+	t := &types.XPTransaction{
+		UserId:     userID,
+		Delta:      -cost,
+		Reason:     reason,
+		SourceType: &source,
+		CreatedAt:  now,
+	}
+	var events []types.StreakEvent
+	err := e.ledger.Spend(t, func(tx *sql.Tx) error {
+		err := e.store.UpdateStreakTx(tx, userID, func(s *types.StreakState) ([]types.StreakEvent, error) {
+			event, err := change(s)
+			if err != nil {
+				return nil, err
+			}
+			events = []types.StreakEvent{*event}
+			return events, nil
+		})
+		if err != nil {
+			return err
+		}
+		// the charge points to the purchase event
+		t.SourceId = &events[0].Id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+// CheckFreezePurchase tells why the user cannot buy a freeze, nil if they can
+func CheckFreezePurchase(s *types.StreakState) error {
+	if s.Freezes >= config.MaxStreakFreezes {
+		return fmt.Errorf("you already hold %d streak freezes", s.Freezes)
+	}
+	if s.XP < config.StreakFreezeCost {
+		return fmt.Errorf("a streak freeze costs %d XP", config.StreakFreezeCost)
+	}
+	return nil
+}
+
+// CheckRepair tells why the user cannot repair a streak at now, nil if they can
+func CheckRepair(s *types.StreakState, now time.Time) error {
+	expires := RepairExpiresAt(s)
+	if expires == nil || !now.Before(*expires) {
+		return fmt.Errorf("there is no broken streak to repair")
+	}
+	if s.XP < config.StreakRepairCost {
+		return fmt.Errorf("a streak repair costs %d XP", config.StreakRepairCost)
+	}
+	return nil
+}
+
+// RepairExpiresAt returns the end of the repair window, nil without broken streak
+func RepairExpiresAt(s *types.StreakState) *time.Time {
+	if s.BrokenStreak == 0 || s.BrokenAt == nil || s.BrokenDay == nil {
+		return nil
+	}
+	expires := s.BrokenAt.Add(time.Duration(config.StreakRepairHours) * time.Hour)
+	return &expires
+}
+
+// Status returns the streak of the user with the days frozen in the last 30 days
+func (e *Engine) Status(userID int) (*types.StreakResponse, error) {
+	now := e.clock.Now()
+	s, err := e.store.GetStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	frozen, err := e.store.ListFrozenDays(userID, startOfDay(now).AddDate(0, 0, -30))
+	if err != nil {
+		return nil, err
+	}
+	status := &types.StreakResponse{
+		CurrentStreak: s.CurrentStreak,
+		LongestStreak: s.LongestStreak,
+		Freezes:       s.Freezes,
+		MaxFreezes:    config.MaxStreakFreezes,
+		FreezeCost:    config.StreakFreezeCost,
+		FrozenDays:    frozen,
+	}
+	if expires := RepairExpiresAt(s); expires != nil && now.Before(*expires) {
+		status.Repair = &types.StreakRepairOffer{Streak: s.BrokenStreak, Cost: config.StreakRepairCost, ExpiresAt: *expires}
+	}
+	return status, nil
+}
+
+// evaluate unlocks the streak achievements of the streak the engine kept,
+// failures are only logged since the streak is already recorded
+func (e *Engine) evaluate(userID int, streak int, at time.Time) []types.UnlockedAchievement {
+	unlocked, err := e.achievements.EvaluateStreak(userID, streak, at)
+	if err != nil {
+		log.Printf("failed to evaluate the streak achievements of user %d: %v", userID, err)
+	}
+	return unlocked
+}
+
+func (e *Engine) announce(s *types.StreakState, events []types.StreakEvent) {
+	for _, event := range events {
+		switch event.Kind {
+		case types.StreakFrozen:
+			notifications.Send(e.notifier, event.UserId, types.NotificationStreak,
+				"A streak freeze saved your streak",
+				fmt.Sprintf("You missed %s but your %d-day streak is safe. %d freezes left.", event.Day.Format(time.DateOnly), event.Streak, s.Freezes),
+				map[string]any{"day": event.Day, "streak": event.Streak, "freezes": s.Freezes},
+			)
+		case types.StreakBroken:
+			expires := RepairExpiresAt(s)
+			notifications.Send(e.notifier, event.UserId, types.NotificationStreak,
+				"Your streak was broken",
+				fmt.Sprintf("Your %d-day streak ended. Repair it for %d XP within %d hours.", event.Streak, config.StreakRepairCost, config.StreakRepairHours),
+				map[string]any{"previous_streak": event.Streak, "repair_expires_at": expires},
+			)
+		case types.StreakFreezeEarned:
+			notifications.Send(e.notifier, event.UserId, types.NotificationStreak,
+				"You earned a streak freeze",
+				fmt.Sprintf("%d days in a row! A freeze will keep your streak safe on a day you miss.", event.Streak),
+				map[string]any{"streak": event.Streak, "freezes": s.Freezes},
+			)
+		}
+	}
+}
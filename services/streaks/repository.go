@@ -0,0 +1,150 @@
+package streaks
+
+import (
+	"backend/types"
+	"database/sql"
+	"time"
+)
+
+type StreakRepoImpl struct {
+	db *sql.DB
+}
+
+func NewStreakRepoImpl(db *sql.DB) *StreakRepoImpl {
+	return &StreakRepoImpl{db: db}
+}
+
+// the streak columns of stats are nullable
+const streakColumns = `COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0), s.last_streak_day,
+	COALESCE(s.streak_freezes, 0), COALESCE(s.broken_streak, 0), s.broken_day, s.broken_at, u.xp`
+
+func scanRowIntoStreak(row *sql.Row, s *types.StreakState) error {
+	return row.Scan(&s.CurrentStreak, &s.LongestStreak, &s.LastStreakDay, &s.Freezes, &s.BrokenStreak, &s.BrokenDay, &s.BrokenAt, &s.XP)
+}
+
+func (r *StreakRepoImpl) UpdateStreak(userID int, change func(*types.StreakState) ([]types.StreakEvent, error)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := r.UpdateStreakTx(tx, userID, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *StreakRepoImpl) UpdateStreakTx(tx *sql.Tx, userID int, change func(*types.StreakState) ([]types.StreakEvent, error)) error {
+	// the user row is locked first, like the XP ledger does, so streak
+	// updates and XP transactions of a user queue up instead of deadlocking
+	var id int
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&id); err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err := tx.Exec(
+		"INSERT IGNORE INTO stats (user_id, longest_streak, current_streak, last_updated, created_at) VALUES (?, 0, 0, ?, ?)",
+		userID, now, now,
+	)
+	if err != nil {
+		return err
+	}
+	s := types.StreakState{UserId: userID}
+	row := tx.QueryRow("SELECT "+streakColumns+" FROM stats s JOIN users u ON u.id = s.user_id WHERE s.user_id = ? FOR UPDATE", userID)
+	if err := scanRowIntoStreak(row, &s); err != nil {
+		return err
+	}
+	events, err := change(&s)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE stats SET current_streak = ?, longest_streak = ?, last_streak_day = ?, streak_freezes = ?,
+			broken_streak = ?, broken_day = ?, broken_at = ?, last_updated = ?
+		WHERE user_id = ?`,
+		s.CurrentStreak, s.LongestStreak, s.LastStreakDay, s.Freezes, s.BrokenStreak, s.BrokenDay, s.BrokenAt, now, userID,
+	)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		e := &events[i]
+		res, err := tx.Exec(
+			"INSERT IGNORE INTO streak_events(user_id, kind, day, streak, xp_cost, created_at) VALUES (?,?,?,?,?,?)",
+			e.UserId, e.Kind, e.Day, e.Streak, e.XPCost, e.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if e.Id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StreakRepoImpl) GetStreak(userID int) (*types.StreakState, error) {
+	s := types.StreakState{UserId: userID}
+	row := r.db.QueryRow("SELECT "+streakColumns+" FROM users u LEFT JOIN stats s ON s.user_id = u.id WHERE u.id = ?", userID)
+	if err := scanRowIntoStreak(row, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *StreakRepoImpl) ListStreakEvents(userID int, limit, offset int) ([]types.StreakEvent, error) {
+	rows, err := r.db.Query(
+		"SELECT id, user_id, kind, day, streak, xp_cost, created_at FROM streak_events WHERE user_id = ? ORDER BY id DESC LIMIT ? OFFSET ?",
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.StreakEvent, 0)
+	for rows.Next() {
+		var e types.StreakEvent
+		if err := rows.Scan(&e.Id, &e.UserId, &e.Kind, &e.Day, &e.Streak, &e.XPCost, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (r *StreakRepoImpl) ListFrozenDays(userID int, from time.Time) ([]time.Time, error) {
+	rows, err := r.db.Query(
+		"SELECT day FROM streak_events WHERE user_id = ? AND kind IN (?, ?) AND day >= ? ORDER BY day",
+		userID, types.StreakFrozen, types.StreakRepaired, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	days := make([]time.Time, 0)
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+func (r *StreakRepoImpl) ListStreaksToSettle(day time.Time) ([]int, error) {
+	rows, err := r.db.Query("SELECT user_id FROM stats WHERE current_streak > 0 AND last_streak_day < ?", day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
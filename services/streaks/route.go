@@ -0,0 +1,161 @@
+package streaks
+
+import (
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store  types.StreakRepo
+	engine *Engine
+}
+
+func NewHandler(store types.StreakRepo, engine *Engine) *Handler {
+	return &Handler{store: store, engine: engine}
+}
+
+func (h *Handler) RegisterRoutes(authRouter *mux.Router) {
+	authRouter.HandleFunc("/me/streak", h.HandleGetStreak).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/streak/events", h.HandleListEvents).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me/streak/freezes", h.HandleBuyFreeze).Methods(http.MethodPost)
+	authRouter.HandleFunc("/me/streak/repair", h.HandleRepair).Methods(http.MethodPost)
+}
+
+// HandleGetStreak godoc
+//
+// @Summary 			Get my streak
+// @Description 		The current and longest streak of the authenticated user, the freezes held, the days frozen or repaired in the last 30 days and the repair offer when a streak broke less than 24 hours ago
+// @Tags 				Streaks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.StreakResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/streak [get]
+func (h *Handler) HandleGetStreak(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	status, err := h.engine.Status(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, status)
+}
+
+// HandleListEvents godoc
+//
+// @Summary 			List my streak events
+// @Description 		Freezes earned, bought and used, broken and repaired streaks of the authenticated user, newest first
+// @Tags 				Streaks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50, max 200)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.StreakEvent
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/streak/events [get]
+func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListStreakEvents(userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleBuyFreeze godoc
+//
+// @Summary 			Buy a streak freeze
+// @Description 		Trade XP for a streak freeze, consumed automatically on the next day without focus session. A limited number of freezes can be held.
+// @Tags 				Streaks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.StreakResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/streak/freezes [post]
+func (h *Handler) HandleBuyFreeze(w http.ResponseWriter, r *http.Request) {
+	h.purchase(w, r, CheckFreezePurchase, h.engine.BuyFreeze)
+}
+
+// HandleRepair godoc
+//
+// @Summary 			Repair my broken streak
+// @Description 		Give back a streak broken less than 24 hours ago for XP, once. Sessions done since the break carry on from the repaired streak.
+// @Tags 				Streaks
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.StreakResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/streak/repair [post]
+func (h *Handler) HandleRepair(w http.ResponseWriter, r *http.Request) {
+	check := func(s *types.StreakState) error { return CheckRepair(s, h.engine.Now()) }
+	h.purchase(w, r, check, h.engine.Repair)
+}
+
+// purchase checks the request against the current streak, refusals are
+// conflicts, then buys and answers with the updated streak
+func (h *Handler) purchase(w http.ResponseWriter, r *http.Request, check func(*types.StreakState) error, buy func(userID int) (*types.StreakEvent, error)) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	s, err := h.store.GetStreak(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := check(s); err != nil {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if _, err := buy(userID); err != nil {
+		// the balance may have gone down since the check
+		if errors.Is(err, types.ErrNotEnoughXP) {
+			utils.WriteError(w, http.StatusConflict, err)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status, err := h.engine.Status(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, status)
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,127 @@
+package tokens
+
+import (
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AccessTokenRepoImpl struct {
+	db *sql.DB
+}
+
+func NewAccessTokenRepoImpl(db *sql.DB) *AccessTokenRepoImpl {
+	return &AccessTokenRepoImpl{db: db}
+}
+
+func (a *AccessTokenRepoImpl) CreateAccessToken(token *types.AccessToken) error {
+	res, err := a.db.Exec(
+		"INSERT INTO personal_access_tokens(user_id, name, token_hash, scopes, expires_at, created_at) VALUES (?,?,?,?,?,?)",
+		token.UserId,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.Id = int(id)
+	return nil
+}
+
+func (a *AccessTokenRepoImpl) ListAccessTokens(userID int) ([]types.AccessToken, error) {
+	rows, err := a.db.Query(`
+		SELECT t.id, t.user_id, u.username, u.role, t.name, t.token_hash, t.scopes, t.last_used_at, t.expires_at, t.revoked_at, t.created_at
+		FROM personal_access_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.user_id = ?
+		ORDER BY t.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.AccessToken, 0)
+	for rows.Next() {
+		var t types.AccessToken
+		if err := scanRowIntoAccessToken(rows, &t); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func (a *AccessTokenRepoImpl) RevokeAccessToken(userID int, id int) error {
+	res, err := a.db.Exec(
+		"UPDATE personal_access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("token not found")
+	}
+	return nil
+}
+
+func (a *AccessTokenRepoImpl) GetAccessTokenByHash(hash string) (*types.AccessToken, error) {
+	row := a.db.QueryRow(`
+		SELECT t.id, t.user_id, u.username, u.role, t.name, t.token_hash, t.scopes, t.last_used_at, t.expires_at, t.revoked_at, t.created_at
+		FROM personal_access_tokens t JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ? AND u.anonymized_at IS NULL`,
+		hash,
+	)
+	var t types.AccessToken
+	if err := scanRowIntoAccessToken(row, &t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (a *AccessTokenRepoImpl) TouchAccessToken(id int) error {
+	_, err := a.db.Exec("UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRowIntoAccessToken(row scanner, t *types.AccessToken) error {
+	var scopes string
+	err := row.Scan(
+		&t.Id,
+		&t.UserId,
+		&t.Username,
+		&t.Role,
+		&t.Name,
+		&t.TokenHash,
+		&scopes,
+		&t.LastUsedAt,
+		&t.ExpiresAt,
+		&t.RevokedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	t.Scopes = strings.Split(scopes, ",")
+	return nil
+}
@@ -0,0 +1,148 @@
+package tokens
+
+import (
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.AccessTokenRepo
+	auditLog types.AuditLogger
+}
+
+func NewHandler(store types.AccessTokenRepo, auditLog types.AuditLogger) *Handler {
+	return &Handler{store: store, auditLog: auditLog}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me/tokens", h.HandleCreateToken).Methods(http.MethodPost)
+	router.HandleFunc("/me/tokens", h.HandleListTokens).Methods(http.MethodGet)
+	router.HandleFunc("/me/tokens/{id}", h.HandleRevokeToken).Methods(http.MethodDelete)
+}
+
+// HandleCreateToken godoc
+//
+// @Summary 			Create a personal access token
+// @Description 		Create a scoped token for scripts and integrations. The plain token is only returned once.
+// @Tags 				Tokens
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.CreateAccessTokenPayload true "Token payload"
+// @Success 			201 {object} types.CreatedAccessTokenResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/tokens [post]
+func (h *Handler) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.CreateAccessTokenPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Name == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("token name is required"))
+		return
+	}
+	if len(payload.Scopes) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("at least one scope is required"))
+		return
+	}
+	for _, scope := range payload.Scopes {
+		if !auth.IsValidScope(scope) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown scope %q", scope))
+			return
+		}
+	}
+	plain := auth.AccessTokenPrefix + utils.GenerateToken()
+	token := types.AccessToken{
+		UserId:    userID,
+		Name:      payload.Name,
+		TokenHash: auth.HashAccessToken(plain),
+		Scopes:    payload.Scopes,
+		CreatedAt: time.Now(),
+	}
+	if payload.ExpiresInDays > 0 {
+		expiresAt := token.CreatedAt.AddDate(0, 0, payload.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := h.store.CreateAccessToken(&token); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditAccessTokenCreated, userID, userID, map[string]any{"token_id": token.Id, "name": token.Name, "scopes": token.Scopes})
+	utils.WriteJSON(w, http.StatusCreated, types.CreatedAccessTokenResponse{
+		Token:       plain,
+		AccessToken: token,
+	})
+}
+
+// HandleListTokens godoc
+//
+// @Summary 			List personal access tokens
+// @Description 		List the personal access tokens of the authenticated user, including revoked ones
+// @Tags 				Tokens
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {array} types.AccessToken
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/tokens [get]
+func (h *Handler) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := h.store.ListAccessTokens(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleRevokeToken godoc
+//
+// @Summary 			Revoke a personal access token
+// @Description 		Revoke one of the authenticated user's personal access tokens
+// @Tags 				Tokens
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				id path int true "Token ID"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
+// @Router 				/me/tokens/{id} [delete]
+func (h *Handler) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.store.RevokeAccessToken(userID, id); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditAccessTokenRevoked, userID, userID, map[string]any{"token_id": id})
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Token revoked successfully"})
+}
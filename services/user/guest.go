@@ -0,0 +1,231 @@
+package user
+
+import (
+	"backend/config"
+	"backend/helpers"
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/services/ranks"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// guests never have a usable password until they claim the account
+const guestPasswordHash = "!"
+
+// HandleCreateGuest godoc
+//
+// @Summary 			Start as a guest
+// @Description 		Create an anonymous guest account with a generated name. The returned device token signs the guest in again when the JWT expires and is only shown once. Guests can log pomodoros and earn XP but are left out of the rankings until they claim the account.
+// @Tags 				Auth
+// @Accept 				json
+// @Produce 			json
+// @Param 				request body types.GuestPayload true "Guest payload"
+// @Success 			201 {object} types.GuestResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			429 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/guest [post]
+func (h *Handler) HandleCreateGuest(w http.ResponseWriter, r *http.Request) {
+	var payload types.GuestPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now()
+	count, err := h.store.CountGuestsCreatedFromIP(utils.ClientIP(r), now.Add(-time.Hour))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if count >= int(config.Envs.GuestHourlyLimit) {
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many guest accounts created, try again later"))
+		return
+	}
+	deviceToken := utils.GenerateToken()
+	user := types.User{
+		Username:     "guest-" + utils.GenerateToken()[:12],
+		PasswordHash: guestPasswordHash,
+		XP:           0,
+		RankId:       1,
+		Role:         auth.RoleUser,
+		Language:     helpers.LocaleFromAcceptLanguage(r.Header.Get("Accept-Language")),
+	}
+	if err := h.store.CreateGuestUser(&user, utils.HashToken(deviceToken)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	token, err := h.startSession(r, &user, payload.DeviceName)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditGuestCreated, user.Id, user.Id, nil)
+	created, err := h.store.GetUserById(user.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, created); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, types.GuestResponse{User: created, DeviceToken: deviceToken, Token: token})
+}
+
+// HandleGuestLogin godoc
+//
+// @Summary 			Sign a guest in again
+// @Description 		Exchange the device token of an unclaimed guest account for a new JWT
+// @Tags 				Auth
+// @Accept 				json
+// @Produce 			json
+// @Param 				request body types.GuestLoginPayload true "Guest login payload"
+// @Success 			200 {object} types.TokenResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/guest/login [post]
+func (h *Handler) HandleGuestLogin(w http.ResponseWriter, r *http.Request) {
+	var payload types.GuestLoginPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.DeviceToken == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing device token"))
+		return
+	}
+	user, err := h.store.GetUserByGuestToken(utils.HashToken(payload.DeviceToken))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid device token"))
+		return
+	}
+	token, err := h.startSession(r, user, payload.DeviceName)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditGuestLogin, user.Id, user.Id, nil)
+	utils.WriteJSON(w, http.StatusOK, types.TokenResponse{Token: token})
+}
+
+// HandleClaimAccount godoc
+//
+// @Summary 			Claim my guest account
+// @Description 		Turn the authenticated guest into a regular account by choosing a username and a password and/or an email (a verification email is sent). Sessions, pomodoros, stats and XP are kept and the user appears in the rankings. The device token stops working and a new JWT is returned.
+// @Tags 				User
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.ClaimAccountPayload true "Claim payload"
+// @Success 			200 {object} types.ClaimAccountResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/claim [post]
+func (h *Handler) HandleClaimAccount(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.CurrentPrincipal(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.ClaimAccountPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.GetUserById(principal.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+	if !user.IsGuest {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("this account is already claimed"))
+		return
+	}
+	if payload.Password == "" && payload.Email == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("a password or an email is required to claim the account"))
+		return
+	}
+	username := user.Username
+	if payload.Username != "" {
+		if err := validateUsername(payload.Username); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		available, err := h.store.IsUsernameAvailable(payload.Username, user.Id, time.Now())
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !available {
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("username %q is not available", payload.Username))
+			return
+		}
+		username = payload.Username
+	}
+	passwordHash := user.PasswordHash
+	if payload.Password != "" {
+		if err := auth.ValidatePassword(payload.Password); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if passwordHash, err = auth.HashPassword(payload.Password); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	// the email is only set once verified, through the usual email update flow
+	if payload.Email != "" {
+		if !utils.IsValidEmail(payload.Email) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid email format"))
+			return
+		}
+		token, err := h.store.UpdateUserEmail(user.Id, payload.Email)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := helpers.SendVerificationEmail(h.mailer, user.Language, payload.Email, token); err != nil {
+			log.Printf("failed to send verification email to user %d: %v", user.Id, err)
+		}
+	}
+	if err := h.store.ClaimGuestUser(user.Id, username, passwordHash); err != nil {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditGuestClaimed, user.Id, user.Id, map[string]any{
+		"username":     username,
+		"password_set": payload.Password != "",
+		"email":        payload.Email,
+	})
+	user, err = h.store.GetUserById(user.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	token, err := auth.CreateToken(user.Id, user.Username, user.Role, principal.SessionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.ClaimAccountResponse{User: user, Token: token})
+}
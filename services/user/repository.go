@@ -1,10 +1,11 @@
 package user
 
 import (
-	"backend/helpers"
+	"backend/services/audit"
 	"backend/services/auth"
 	"backend/types"
 	"backend/utils"
+	"crypto/subtle"
 	"database/sql"
 	"fmt"
 	"time"
@@ -14,31 +15,24 @@ type UserRepoImpl struct {
 	db *sql.DB
 }
 
+const userColumns = "id, username, email, country, rank_id, xp, password_hash, role, created_at, deletion_scheduled_at, username_changed_at, is_guest, language, prestige, lifetime_xp"
+
 func NewUserRepoImpl(db *sql.DB) *UserRepoImpl {
 	return &UserRepoImpl{db: db}
 }
 
 func (u *UserRepoImpl) CreateUser(user types.User) error {
 	_, err := u.db.Exec(
-		"Insert into users(username,email,country,password_hash,xp,rank_id) values(?,?,?,?,?,?)",
-		user.Username, user.Email, user.Country, user.PasswordHash, user.XP, user.RankId,
+		"Insert into users(username,email,country,password_hash,xp,rank_id,role,language) values(?,?,?,?,?,?,?,?)",
+		user.Username, user.Email, user.Country, user.PasswordHash, user.XP, user.RankId, user.Role, user.Language,
 	)
 	return err
 }
 
 func (u *UserRepoImpl) GetUserByUsername(username string) (*types.User, error) {
 	var user types.User
-	row := u.db.QueryRow("Select * from users where username = ?", username)
-	err := row.Scan(
-		&user.Id,
-		&user.Username,
-		&user.Email,
-		&user.Country,
-		&user.RankId,
-		&user.XP,
-		&user.PasswordHash,
-		&user.CreatedAt,
-	)
+	row := u.db.QueryRow("Select "+userColumns+" from users where username = ?", username)
+	err := scanRowIntoUser(row, &user)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -50,18 +44,42 @@ func (u *UserRepoImpl) GetUserByUsername(username string) (*types.User, error) {
 
 }
 
-func (u *UserRepoImpl) UpdateUserEmail(id int, newEmail string) error {
+func (u *UserRepoImpl) GetUserById(id int) (*types.User, error) {
+	var user types.User
+	row := u.db.QueryRow("Select "+userColumns+" from users where id = ?", id)
+	if err := scanRowIntoUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *UserRepoImpl) GetUserByEmail(email string) (*types.User, error) {
+	var user types.User
+	row := u.db.QueryRow("Select "+userColumns+" from users where email = ?", email)
+	if err := scanRowIntoUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *UserRepoImpl) UpdateUserEmail(id int, newEmail string) (string, error) {
 	if !utils.IsValidEmail(newEmail) {
-		return fmt.Errorf("invalid email format")
+		return "", fmt.Errorf("invalid email format")
 	}
 	// Check if email already exists
 	var exists bool
 	err := u.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", newEmail).Scan(&exists)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if exists {
-		return fmt.Errorf("email already in use")
+		return "", fmt.Errorf("email already in use")
 	}
 	// Generate verification token
 	token := utils.GenerateToken()
@@ -72,25 +90,25 @@ func (u *UserRepoImpl) UpdateUserEmail(id int, newEmail string) error {
         ON DUPLICATE KEY UPDATE new_email = VALUES(new_email), token = VALUES(token), created_at = NOW()
     `, id, newEmail, token)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return helpers.SendVerificationEmail(newEmail, token)
+	return token, nil
 }
 
-func (u *UserRepoImpl) VerifyEmailUpdate(token string) error {
+func (u *UserRepoImpl) VerifyEmailUpdate(token string) (int, error) {
 	var e types.PendingEmailUpdate
 	row := u.db.QueryRow("Select * from pending_email_updates where token = ?", token)
 	if err := row.Scan(&e.Id, &e.UserId, &e.NewEmail, &e.Token, &e.CreatedAt, &e.ExpiresAt); err != nil {
-		return err
+		return 0, err
 	}
 	if e.ExpiresAt.Before(time.Now()) {
-		return fmt.Errorf("token expired")
+		return 0, fmt.Errorf("token expired")
 	}
 	if _, err := u.db.Exec("update users set email = ? where id = ?", e.NewEmail, e.UserId); err != nil {
-		return err
+		return 0, err
 	}
 	_, err := u.db.Exec("delete from pending_email_updates where id = ?", e.Id)
-	return err
+	return e.UserId, err
 }
 
 func (u *UserRepoImpl) UpdateUserCountry(id string, country string) (*types.User, error) {
@@ -99,22 +117,18 @@ func (u *UserRepoImpl) UpdateUserCountry(id string, country string) (*types.User
 		return nil, err
 	}
 	var user types.User
-	row := u.db.QueryRow("Select * from users where id = ?", id)
-	if err := row.Scan(
-		&user.Id,
-		&user.Username,
-		&user.Email,
-		&user.Country,
-		&user.RankId,
-		&user.XP,
-		&user.PasswordHash,
-		&user.CreatedAt,
-	); err != nil {
+	row := u.db.QueryRow("Select "+userColumns+" from users where id = ?", id)
+	if err := scanRowIntoUser(row, &user); err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (u *UserRepoImpl) UpdateUserLanguage(id int, language string) error {
+	_, err := u.db.Exec("UPDATE users SET language = ? WHERE id = ?", language, id)
+	return err
+}
+
 func (u *UserRepoImpl) RequestPasswordReset(id int, code string) error {
 	now := time.Now()
 	_, err := u.db.Exec(`
@@ -152,3 +166,256 @@ func (u *UserRepoImpl) ResetPasswordWithCode(id int, code string, newPassword st
 	_, err = u.db.Exec("UPDATE password_reset_tokens SET used = TRUE WHERE id = ?", e.Id)
 	return err
 }
+
+func (u *UserRepoImpl) UpdatePasswordHash(id int, hash string) error {
+	_, err := u.db.Exec("UPDATE users SET password_hash = ? WHERE id = ?", hash, id)
+	return err
+}
+
+// ScheduleDeletion hides the user from the rankings until the account is purged
+// at the given time. Every session and access token is revoked with it, so
+// cancelling needs a new login.
+func (u *UserRepoImpl) ScheduleDeletion(id int, at time.Time) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE users SET deletion_scheduled_at = ? WHERE id = ?", at, id); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, table := range []string{"sessions", "personal_access_tokens"} {
+		if _, err := tx.Exec("UPDATE "+table+" SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", now, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (u *UserRepoImpl) CancelDeletion(id int) error {
+	_, err := u.db.Exec("UPDATE users SET deletion_scheduled_at = NULL WHERE id = ?", id)
+	return err
+}
+
+// PurgeDeletedUsers hard deletes the accounts whose grace period is over,
+// their pomodoros, stats, heatmap and tokens go with them through the FK cascades
+func (u *UserRepoImpl) PurgeDeletedUsers(now time.Time) (int64, error) {
+	return u.deleteUsers("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now)
+}
+
+// deleteUsers deletes the users matching where and redacts the audit events
+// about them, which the FK cascades do not reach
+func (u *UserRepoImpl) deleteUsers(where string, args ...any) (int64, error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query("SELECT id FROM users WHERE "+where+" FOR UPDATE", args...)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	for _, id := range ids {
+		if err := audit.RedactUserEvents(tx, id, now); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("DELETE FROM users WHERE id = ?", id); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(ids)), tx.Commit()
+}
+
+func (u *UserRepoImpl) CreateMagicLink(link *types.MagicLink) error {
+	_, err := u.db.Exec(`
+		INSERT INTO magic_link_tokens(user_id, email, token_hash, nonce_hash, device_name, created_at, expires_at)
+		VALUES (?,?,?,?,?,?,?)`,
+		link.UserId, link.Email, link.TokenHash, link.NonceHash, link.DeviceName, link.CreatedAt, link.ExpiresAt,
+	)
+	return err
+}
+
+func (u *UserRepoImpl) CountMagicLinksSince(email string, since time.Time) (int, error) {
+	var count int
+	err := u.db.QueryRow("SELECT COUNT(*) FROM magic_link_tokens WHERE email = ? AND created_at >= ?", email, since).Scan(&count)
+	return count, err
+}
+
+// ConsumeMagicLink marks the link as used and returns it. The link must be
+// redeemed with the nonce of the device that requested it, and the update only
+// succeeds once so a link can never be redeemed twice even concurrently.
+func (u *UserRepoImpl) ConsumeMagicLink(tokenHash string, nonceHash string, now time.Time) (*types.MagicLink, error) {
+	var link types.MagicLink
+	row := u.db.QueryRow(`
+		SELECT id, user_id, email, token_hash, nonce_hash, device_name, created_at, expires_at, used_at
+		FROM magic_link_tokens WHERE token_hash = ?`,
+		tokenHash,
+	)
+	err := row.Scan(&link.Id, &link.UserId, &link.Email, &link.TokenHash, &link.NonceHash,
+		&link.DeviceName, &link.CreatedAt, &link.ExpiresAt, &link.UsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invalid link")
+		}
+		return nil, err
+	}
+	if link.ExpiresAt.Before(now) {
+		return nil, fmt.Errorf("link expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(link.NonceHash), []byte(nonceHash)) != 1 {
+		return nil, fmt.Errorf("this link must be opened on the device that requested it")
+	}
+	res, err := u.db.Exec("UPDATE magic_link_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", now, link.Id)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, fmt.Errorf("link already used")
+	}
+	link.UsedAt = &now
+	return &link, nil
+}
+
+// IsUsernameAvailable reports whether nobody else uses the name or holds it
+// after a recent rename. A user can always take back their own old name.
+func (u *UserRepoImpl) IsUsernameAvailable(username string, forUserID int, now time.Time) (bool, error) {
+	var taken bool
+	err := u.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM users WHERE username = ? AND id <> ?)
+			OR EXISTS(SELECT 1 FROM username_history WHERE old_username = ? AND user_id <> ? AND held_until > ?)`,
+		username, forUserID, username, forUserID, now,
+	).Scan(&taken)
+	return !taken, err
+}
+
+// ChangeUsername renames the user and keeps the old name in the history, held until heldUntil
+func (u *UserRepoImpl) ChangeUsername(id int, newUsername string, now time.Time, heldUntil time.Time) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var oldUsername string
+	if err := tx.QueryRow("SELECT username FROM users WHERE id = ? FOR UPDATE", id).Scan(&oldUsername); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO username_history(user_id, old_username, changed_at, held_until) VALUES (?,?,?,?)",
+		id, oldUsername, now, heldUntil,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE users SET username = ?, username_changed_at = ? WHERE id = ?", newUsername, now, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (u *UserRepoImpl) CreateGuestUser(user *types.User, tokenHash string) error {
+	res, err := u.db.Exec(
+		"INSERT INTO users(username, password_hash, xp, rank_id, role, language, is_guest, guest_token_hash) VALUES (?,?,?,?,?,?,TRUE,?)",
+		user.Username, user.PasswordHash, user.XP, user.RankId, user.Role, user.Language, tokenHash,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.Id = int(id)
+	user.IsGuest = true
+	return nil
+}
+
+func (u *UserRepoImpl) GetUserByGuestToken(tokenHash string) (*types.User, error) {
+	var user types.User
+	row := u.db.QueryRow("Select "+userColumns+" from users where is_guest = TRUE AND guest_token_hash = ?", tokenHash)
+	if err := scanRowIntoUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CountGuestsCreatedFromIP counts the guests whose first session came from the IP
+func (u *UserRepoImpl) CountGuestsCreatedFromIP(ip string, since time.Time) (int, error) {
+	var count int
+	err := u.db.QueryRow(`
+		SELECT COUNT(DISTINCT u.id) FROM users u
+		JOIN sessions s ON s.user_id = u.id
+		WHERE u.is_guest = TRUE AND u.created_at >= ? AND s.ip = ?`,
+		since, ip,
+	).Scan(&count)
+	return count, err
+}
+
+// ClaimGuestUser turns the guest into a regular account, the user id does not
+// change so sessions, pomodoros, stats and XP are kept. The device token stops working.
+func (u *UserRepoImpl) ClaimGuestUser(id int, username string, passwordHash string) error {
+	res, err := u.db.Exec(
+		"UPDATE users SET username = ?, password_hash = ?, is_guest = FALSE, guest_token_hash = NULL WHERE id = ? AND is_guest = TRUE",
+		username, passwordHash, id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("this account is not a guest account")
+	}
+	return nil
+}
+
+// PurgeInactiveGuests deletes the unclaimed guests that have not been seen
+// since before, their data goes with them through the FK cascades
+func (u *UserRepoImpl) PurgeInactiveGuests(before time.Time) (int64, error) {
+	return u.deleteUsers(`is_guest = TRUE AND created_at < ?
+		AND NOT EXISTS (SELECT 1 FROM sessions s WHERE s.user_id = users.id AND s.last_seen_at >= ?)`,
+		before, before,
+	)
+}
+
+func scanRowIntoUser(row *sql.Row, user *types.User) error {
+	return row.Scan(
+		&user.Id,
+		&user.Username,
+		&user.Email,
+		&user.Country,
+		&user.RankId,
+		&user.XP,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.DeletionScheduledAt,
+		&user.UsernameChangedAt,
+		&user.IsGuest,
+		&user.Language,
+		&user.Prestige,
+		&user.LifetimeXP,
+	)
+}
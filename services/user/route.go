@@ -1,31 +1,44 @@
 package user
 
 import (
+	"backend/config"
 	"backend/helpers"
+	"backend/services/audit"
 	"backend/services/auth"
+	"backend/services/ranks"
 	"backend/types"
 	"backend/utils"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
-	store types.UserRepo
+	store    types.UserRepo
+	sessions types.SessionRepo
+	auditLog types.AuditLogger
+	mailer   types.Mailer
+	ranks    types.RankRepo
 }
 
 // simulate the constructor in others languages
-func NewHandler(store types.UserRepo) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.UserRepo, sessions types.SessionRepo, auditLog types.AuditLogger, mailer types.Mailer, rankRepo types.RankRepo) *Handler {
+	return &Handler{store: store, sessions: sessions, auditLog: auditLog, mailer: mailer, ranks: rankRepo}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
 	// Public routes
 	router.HandleFunc("/login", h.HandleLogin).Methods(http.MethodPost)
+	router.HandleFunc("/login/magic", h.HandleRequestMagicLink).Methods(http.MethodPost)
+	router.HandleFunc("/login/magic/redeem", h.HandleRedeemMagicLink).Methods(http.MethodGet)
 	router.HandleFunc("/register", h.HandleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/guest", h.HandleCreateGuest).Methods(http.MethodPost)
+	router.HandleFunc("/guest/login", h.HandleGuestLogin).Methods(http.MethodPost)
 	router.HandleFunc("/verify", h.HandleVerifyEmail).Methods(http.MethodGet)
 	router.HandleFunc("/password/forgot", h.HandleForgotPassword).Methods(http.MethodPost)
 	router.HandleFunc("/password/reset", h.HandleVerifyResetCode).Methods(http.MethodPost)
@@ -33,6 +46,12 @@ func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
 	// Protected routes
 	authRouter.HandleFunc("/users/email", h.HandleUpdateEmail).Methods(http.MethodPut)
 	authRouter.HandleFunc("/users/{id}/country", h.HandleUpdateCountry).Methods(http.MethodPatch)
+	authRouter.HandleFunc("/me", h.HandleGetMe).Methods(http.MethodGet)
+	authRouter.HandleFunc("/me", h.HandleDeleteAccount).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/me/username", h.HandleChangeUsername).Methods(http.MethodPatch)
+	authRouter.HandleFunc("/me/language", h.HandleUpdateLanguage).Methods(http.MethodPatch)
+	authRouter.HandleFunc("/me/deletion/cancel", h.HandleCancelDeletion).Methods(http.MethodPost)
+	authRouter.HandleFunc("/me/claim", h.HandleClaimAccount).Methods(http.MethodPost)
 }
 
 //		HandleLogin godoc
@@ -53,32 +72,163 @@ func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	var payload types.AuthPayload
 	if err := utils.ParseJson(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
 	// see if the username exist
 	user, err := h.store.GetUserByUsername(payload.Username)
 	if err != nil {
-		log.Fatal(err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 	if user == nil {
 		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user with the specified username was not found"))
 		return
 	}
 	// now that the user exist we need to compare password hash with the one stored in the database
-	if !auth.ComparePasswords(user.PasswordHash, []byte(payload.Password)) {
+	ok, needsRehash := auth.VerifyPassword(user.PasswordHash, []byte(payload.Password))
+	if !ok {
+		audit.Log(h.auditLog, r, types.AuditLoginFailed, 0, user.Id, nil)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid password"))
 		return
 	}
+	// the plain password is only known now, upgrade hashes made with an older algorithm or cost
+	if needsRehash {
+		h.rehashPassword(user.Id, payload.Password)
+	}
 	// user + password => valid
-	// generate jwt tocken now and return it to the client
-	token, err := auth.CreateToken(payload.Username)
+	// open a session and return a jwt token bound to it to the client
+	token, err := h.startSession(r, user, payload.DeviceName)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	audit.Log(h.auditLog, r, types.AuditLogin, user.Id, user.Id, nil)
 	utils.WriteJSON(w, http.StatusOK, types.TokenResponse{Token: token})
 
 }
 
+// the magic link can only be redeemed by the browser holding this cookie
+const magicLinkCookie = "magic_link_nonce"
+
+// HandleRequestMagicLink godoc
+//
+// @Summary 			Request a sign-in link
+// @Description 		Email a single-use, short-lived sign-in link to the address and bind it to this browser with a cookie. The answer is the same whether or not the address belongs to an account.
+// @Tags 				Auth
+// @Accept 				json
+// @Produce 			json
+// @Param 				request body types.MagicLinkPayload true "Magic link payload"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			429 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/login/magic [post]
+func (h *Handler) HandleRequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var payload types.MagicLinkPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !utils.IsValidEmail(payload.Email) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid email format"))
+		return
+	}
+	now := time.Now()
+	count, err := h.store.CountMagicLinksSince(payload.Email, now.Add(-time.Hour))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if count >= int(config.Envs.MagicLinkHourlyLimit) {
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many sign-in links requested, try again later"))
+		return
+	}
+	user, err := h.store.GetUserByEmail(payload.Email)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	ttl := time.Duration(config.Envs.MagicLinkTTLMinutes) * time.Minute
+	nonce := utils.GenerateToken()
+	if user != nil {
+		token := utils.GenerateToken()
+		err := h.store.CreateMagicLink(&types.MagicLink{
+			UserId:     user.Id,
+			Email:      payload.Email,
+			TokenHash:  utils.HashToken(token),
+			NonceHash:  utils.HashToken(nonce),
+			DeviceName: truncate(payload.DeviceName, 100),
+			CreatedAt:  now,
+			ExpiresAt:  now.Add(ttl),
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := helpers.SendMagicLinkEmail(h.mailer, user.Language, payload.Email, token, ttl); err != nil {
+			log.Printf("failed to send sign-in link to user %d: %v", user.Id, err)
+		}
+	}
+	// the cookie is set for unknown addresses too so the response does not reveal them
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkCookie,
+		Value:    nonce,
+		Path:     "/api/v1/login/magic",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(config.Envs.PublicHost, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "If this address belongs to an account, a sign-in link has been sent"})
+}
+
+// HandleRedeemMagicLink godoc
+//
+// @Summary 			Redeem a sign-in link
+// @Description 		Exchange the token from a sign-in link for a JWT. Must be called from the browser that requested the link.
+// @Tags 				Auth
+// @Produce 			json
+// @Param 				token query string true "Sign-in token"
+// @Success 			200 {object} types.TokenResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/login/magic/redeem [get]
+func (h *Handler) HandleRedeemMagicLink(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing token"))
+		return
+	}
+	cookie, err := r.Cookie(magicLinkCookie)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("this link must be opened on the device that requested it"))
+		return
+	}
+	link, err := h.store.ConsumeMagicLink(utils.HashToken(token), utils.HashToken(cookie.Value), time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.GetUserById(link.UserId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// the address may have changed since the link was sent
+	if user == nil || user.Email == nil || *user.Email != link.Email {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid link"))
+		return
+	}
+	jwt, err := h.startSession(r, user, link.DeviceName)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditMagicLinkLogin, user.Id, user.Id, nil)
+	http.SetCookie(w, &http.Cookie{Name: magicLinkCookie, Value: "", Path: "/api/v1/login/magic", MaxAge: -1})
+	utils.WriteJSON(w, http.StatusOK, types.TokenResponse{Token: jwt})
+}
+
 //		HandleRegister godoc
 //
 //		@Summary 			Register a user
@@ -96,11 +246,13 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	var payload types.AuthPayload
 	if err := utils.ParseJson(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
+		return
 	}
 	// see if the user already exists (username must be unique)
 	user, err := h.store.GetUserByUsername(payload.Username)
 	if err != nil {
-		log.Fatal(err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 	// if exist return user exist
 	if user != nil {
@@ -108,6 +260,24 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user already exist"))
 		return
 	}
+	if err := validateUsername(payload.Username); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	// names released by a rename stay held for their previous owner for a while
+	available, err := h.store.IsUsernameAvailable(payload.Username, 0, time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !available {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user already exist"))
+		return
+	}
+	if err := auth.ValidatePassword(payload.Password); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 	// hash the password
 	PasswordHash, err := auth.HashPassword(payload.Password)
 	if err != nil {
@@ -120,6 +290,8 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		PasswordHash: PasswordHash,
 		XP:           0,
 		RankId:       1,
+		Role:         auth.RoleUser,
+		Language:     helpers.LocaleFromAcceptLanguage(r.Header.Get("Accept-Language")),
 		Email:        nil,
 		Country:      nil,
 	})
@@ -129,7 +301,12 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 	user, err = h.store.GetUserByUsername(payload.Username)
 	if err != nil {
-		log.Fatal(err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, user)
 }
@@ -145,6 +322,8 @@ func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 // @Param 				request body types.UpdateEmailPayload true "Update email payload"
 // @Success 			200 {object} types.SuccessResponse
 // @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
 // @Failure 			500 {object} types.ErrorResponse
 // @Router 			/users/email [put]
 func (h *Handler) HandleUpdateEmail(w http.ResponseWriter, r *http.Request) {
@@ -159,10 +338,29 @@ func (h *Handler) HandleUpdateEmail(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	if err := h.store.UpdateUserEmail(id, payload.NewEmail); err != nil {
+	if err := auth.CheckCanActOn(r, id); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+	user, err := h.store.GetUserById(id)
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
+		return
+	}
+	token, err := h.store.UpdateUserEmail(id, payload.NewEmail)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := helpers.SendVerificationEmail(h.mailer, user.Language, payload.NewEmail, token); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", id, err)
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditEmailChangeRequest, actorID, id, map[string]any{"new_email": payload.NewEmail})
 	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{
 		Message: "Email send successfully",
 	})
@@ -186,13 +384,48 @@ func (h *Handler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing token"))
 		return
 	}
-	if err := h.store.VerifyEmailUpdate(token); err != nil {
+	userID, err := h.store.VerifyEmailUpdate(token)
+	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	audit.Log(h.auditLog, r, types.AuditEmailChanged, userID, userID, nil)
 	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Email verified successfully"})
 }
 
+// HandleGetMe godoc
+//
+// @Summary 			Get my account
+// @Description 		The authenticated user, with the current rank, the next one and the XP still needed to reach it
+// @Tags 				User
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.User
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me [get]
+func (h *Handler) HandleGetMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	user, err := h.store.GetUserById(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
 // HandleUpdateCountry godoc
 //
 // @Summary 			Update a user's country
@@ -205,12 +438,22 @@ func (h *Handler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
 // @Param 				request body types.UserInfoUpdate true "Country payload"
 // @Success 			200 {object} types.User
 // @Failure 			400 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
 // @Failure 			404 {object} types.ErrorResponse
 // @Failure 			500 {object} types.ErrorResponse
 // @Router 				/users/{id}/country [patch]
 func (h *Handler) HandleUpdateCountry(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := auth.CheckCanActOn(r, userID); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
 	var payload types.UserInfoUpdate
 	if err := utils.ParseJson(r, &payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
@@ -221,10 +464,52 @@ func (h *Handler) HandleUpdateCountry(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditCountryChanged, actorID, userID, map[string]any{"country": payload.Country})
 	utils.WriteJSON(w, http.StatusOK, user)
 
 }
 
+// HandleUpdateLanguage godoc
+//
+// @Summary 			Set my language
+// @Description 		Choose the language of the emails sent to the authenticated user
+// @Tags 				User
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.UpdateLanguagePayload true "Language payload, e.g. en or fr"
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/language [patch]
+func (h *Handler) HandleUpdateLanguage(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.UpdateLanguagePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !helpers.IsSupportedLocale(payload.Language) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unsupported language %q, expected one of %s", payload.Language, strings.Join(helpers.SupportedLocales, ", ")))
+		return
+	}
+	if err := h.store.UpdateUserLanguage(userID, payload.Language); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Language updated successfully"})
+}
+
 // HandleForgotPassword godoc
 //
 // @Summary 			Request password reset code
@@ -235,6 +520,7 @@ func (h *Handler) HandleUpdateCountry(w http.ResponseWriter, r *http.Request) {
 // @Param 				request body types.ForgotPasswordPayload true "Forgot password payload"
 // @Success 			200 {object} types.SuccessResponse
 // @Failure 			400 {object} types.ErrorResponse
+// @Failure 			404 {object} types.ErrorResponse
 // @Failure 			500 {object} types.ErrorResponse
 // @Router 				/password/forgot [post]
 func (h *Handler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
@@ -249,6 +535,10 @@ func (h *Handler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if user == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user with the specified username was not found"))
+		return
+	}
 	// see if the user has an email associated with his account
 	if user.Email == nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("this user account does not have an email associated"))
@@ -261,10 +551,10 @@ func (h *Handler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// send email with the code
-	if err := helpers.SendPasswordResetCode(*user.Email, code); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
-		return
+	if err := helpers.SendPasswordResetCode(h.mailer, user.Language, *user.Email, code); err != nil {
+		log.Printf("failed to send password reset code to user %d: %v", user.Id, err)
 	}
+	audit.Log(h.auditLog, r, types.AuditPasswordResetSent, 0, user.Id, nil)
 	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Code sent successfully"})
 
 }
@@ -287,15 +577,247 @@ func (h *Handler) HandleVerifyResetCode(w http.ResponseWriter, r *http.Request)
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
+	if err := auth.ValidatePassword(payload.NewPassword); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 	user, err := h.store.GetUserByUsername(payload.Username)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	// an unknown user cannot hold a code, answer as for a wrong one
+	if user == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
 	err = h.store.ResetPasswordWithCode(user.Id, payload.Code, payload.NewPassword)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	audit.Log(h.auditLog, r, types.AuditPasswordReset, user.Id, user.Id, nil)
 	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Password updated successfully"})
 }
+
+// HandleDeleteAccount godoc
+//
+// @Summary 			Delete my account
+// @Description 		Schedule the authenticated account for deletion after the grace period (ACCOUNT_DELETION_GRACE_DAYS). The password must be confirmed (guests have none). Every session and access token is revoked; until the purge the user is hidden from the rankings and can log in again to cancel.
+// @Tags 				User
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.DeleteAccountPayload true "Password confirmation"
+// @Success 			202 {object} types.DeletionResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me [delete]
+func (h *Handler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.DeleteAccountPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.GetUserById(userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+	// guests have no password to confirm
+	if !user.IsGuest && !auth.ComparePasswords(user.PasswordHash, []byte(payload.Password)) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid password"))
+		return
+	}
+	deleteAt := time.Now().AddDate(0, 0, int(config.Envs.AccountDeletionGraceDays))
+	if err := h.store.ScheduleDeletion(userID, deleteAt); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditDeletionScheduled, userID, userID, map[string]any{"delete_at": deleteAt})
+	utils.WriteJSON(w, http.StatusAccepted, types.DeletionResponse{
+		Message:             "Account scheduled for deletion",
+		DeletionScheduledAt: deleteAt,
+	})
+}
+
+// HandleCancelDeletion godoc
+//
+// @Summary 			Cancel my account deletion
+// @Description 		Keep the account that was scheduled for deletion and show it in the rankings again
+// @Tags 				User
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.SuccessResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/deletion/cancel [post]
+func (h *Handler) HandleCancelDeletion(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err := h.store.CancelDeletion(userID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditDeletionCancelled, userID, userID, nil)
+	utils.WriteJSON(w, http.StatusOK, types.SuccessResponse{Message: "Account deletion cancelled"})
+}
+
+// HandleChangeUsername godoc
+//
+// @Summary 			Change my username
+// @Description 		Rename the authenticated user. Names must be unique, not reserved and not held by a recent rename, and can only be changed once per cooldown period. A new token carrying the new username is returned.
+// @Tags 				User
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.ChangeUsernamePayload true "New username"
+// @Success 			200 {object} types.ChangeUsernameResponse
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			409 {object} types.ErrorResponse
+// @Failure 			429 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/username [patch]
+func (h *Handler) HandleChangeUsername(w http.ResponseWriter, r *http.Request) {
+	principal, err := auth.CurrentPrincipal(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.ChangeUsernamePayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateUsername(payload.Username); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := h.store.GetUserById(principal.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user not found"))
+		return
+	}
+	if user.IsGuest {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("guests choose a username when claiming their account"))
+		return
+	}
+	if user.Username == payload.Username {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("this is already your username"))
+		return
+	}
+	now := time.Now()
+	if user.UsernameChangedAt != nil {
+		next := user.UsernameChangedAt.AddDate(0, 0, int(config.Envs.UsernameChangeCooldownDays))
+		if now.Before(next) {
+			utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("you can change your username again after %s", next.Format(time.RFC3339)))
+			return
+		}
+	}
+	available, err := h.store.IsUsernameAvailable(payload.Username, user.Id, now)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !available {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("username %q is not available", payload.Username))
+		return
+	}
+	if err := h.store.ChangeUsername(user.Id, payload.Username, now, usernameHeldUntil(now)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	audit.Log(h.auditLog, r, types.AuditUsernameChanged, user.Id, user.Id, map[string]any{"old_username": user.Username, "new_username": payload.Username})
+	user, err = h.store.GetUserById(user.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ranks.FillProgress(h.ranks, user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// identity is the user_id claim, the username claim is only informative, but
+	// hand out a fresh token so clients don't keep showing the old name
+	token, err := auth.CreateToken(user.Id, user.Username, user.Role, principal.SessionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, types.ChangeUsernameResponse{User: user, Token: token})
+}
+
+// usernameHeldUntil keeps an old name out of reach at least as long as tokens
+// issued before the rename, which still carry it in their username claim, are valid
+func usernameHeldUntil(now time.Time) time.Time {
+	hold := time.Duration(config.Envs.UsernameHoldDays) * 24 * time.Hour
+	if tokenLifetime := time.Duration(config.Envs.JWTExpirationInSeconds) * time.Second; tokenLifetime > hold {
+		hold = tokenLifetime
+	}
+	return now.Add(hold)
+}
+
+// startSession records the login as a new session, warns the user by email when
+// the device was never seen before and returns a JWT bound to the session
+func (h *Handler) startSession(r *http.Request, user *types.User, deviceName string) (string, error) {
+	deviceName = truncate(deviceName, 100)
+	userAgent := truncate(r.UserAgent(), 512)
+	known, err := h.sessions.IsKnownDevice(user.Id, deviceName, userAgent)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	session := types.Session{
+		UserId:     user.Id,
+		DeviceName: deviceName,
+		UserAgent:  userAgent,
+		IP:         utils.ClientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := h.sessions.CreateSession(&session); err != nil {
+		return "", err
+	}
+	if !known && user.Email != nil {
+		if err := helpers.SendNewDeviceEmail(h.mailer, user.Language, *user.Email, deviceName, userAgent, session.IP, now); err != nil {
+			log.Printf("failed to send new device email to user %d: %v", user.Id, err)
+		}
+	}
+	return auth.CreateToken(user.Id, user.Username, user.Role, session.Id)
+}
+
+// rehashPassword failures are only logged, the user is logged in anyway
+func (h *Handler) rehashPassword(userID int, password string) {
+	hash, err := auth.HashPassword(password)
+	if err == nil {
+		err = h.store.UpdatePasswordHash(userID, hash)
+	}
+	if err != nil {
+		log.Printf("failed to upgrade password hash of user %d: %v", userID, err)
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"backend/config"
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,30}$`)
+
+// names that could be mistaken for staff or system accounts
+var reservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "help", "staff",
+	"moderator", "mod", "xpomodoro", "official", "security", "api", "null", "undefined",
+}
+
+// reserved prefixes used for generated accounts
+var reservedPrefixes = []string{"deleted-user-", "guest-"}
+
+// forbidden anywhere in a username
+var profanity = []string{"fuck", "shit", "bitch", "cunt", "nigger", "faggot", "whore", "slut"}
+
+var (
+	extraDenyOnce sync.Once
+	extraDeny     []string
+)
+
+// validateUsername checks the format and the reserved/profanity deny-list.
+// Availability is checked separately against the database.
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("username must be 3 to 30 characters of letters, digits, '.', '_' or '-'")
+	}
+	lower := strings.ToLower(username)
+	for _, name := range reservedUsernames {
+		if lower == name {
+			return fmt.Errorf("username %q is reserved", username)
+		}
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return fmt.Errorf("username %q is reserved", username)
+		}
+	}
+	extraDenyOnce.Do(loadExtraDenyList)
+	for _, word := range append(profanity, extraDeny...) {
+		if strings.Contains(lower, word) {
+			return fmt.Errorf("username %q is not allowed", username)
+		}
+	}
+	return nil
+}
+
+// RESERVED_USERNAMES_FILE holds one forbidden word per line, lines starting with # are ignored
+func loadExtraDenyList() {
+	if config.Envs.ReservedUsernamesFile == "" {
+		return
+	}
+	file, err := os.Open(config.Envs.ReservedUsernamesFile)
+	if err != nil {
+		log.Printf("failed to load reserved usernames: %v", err)
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			extraDeny = append(extraDeny, line)
+		}
+	}
+}
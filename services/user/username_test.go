@@ -0,0 +1,194 @@
+package user
+
+import (
+	"backend/config"
+	"backend/services/auth"
+	"backend/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		wantErr  bool
+	}{
+		{"letters", "alice", false},
+		{"digits and symbols", "alice_99.b-c", false},
+		{"shortest", "abc", false},
+		{"longest", strings.Repeat("a", 30), false},
+		{"too short", "ab", true},
+		{"too long", strings.Repeat("a", 31), true},
+		{"space", "alice b", true},
+		{"accent", "alicé", true},
+		{"reserved", "admin", true},
+		{"reserved in capitals", "Support", true},
+		{"reserved word inside a name", "admin_alice", false},
+		{"guest prefix", "guest-1234", true},
+		{"deleted user prefix", "Deleted-User-7", true},
+		{"profanity", "shitstorm", true},
+		{"profanity in capitals", "BigFuck", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateUsername(tt.username); (err != nil) != tt.wantErr {
+				t.Errorf("validateUsername(%q) = %v, want error %v", tt.username, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsernameHeldUntil(t *testing.T) {
+	saved := config.Envs
+	defer func() { config.Envs = saved }()
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		holdDays   int64
+		jwtSeconds int64
+		want       time.Time
+	}{
+		{"hold longer than tokens", 90, 3600, now.AddDate(0, 0, 90)},
+		{"tokens longer than the hold", 7, 30 * 24 * 3600, now.AddDate(0, 0, 30)},
+		{"no hold", 0, 3600, now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Envs.UsernameHoldDays = tt.holdDays
+			config.Envs.JWTExpirationInSeconds = tt.jwtSeconds
+			if got := usernameHeldUntil(now); !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type heldUsername struct {
+	userID int
+	until  time.Time
+}
+
+// memoryUserRepo keeps the users and the username history like UserRepoImpl
+// for the rename flow, the other methods are not used
+type memoryUserRepo struct {
+	types.UserRepo
+	users map[int]*types.User
+	held  map[string]heldUsername
+}
+
+func (m *memoryUserRepo) GetUserById(id int) (*types.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (m *memoryUserRepo) IsUsernameAvailable(username string, forUserID int, now time.Time) (bool, error) {
+	for _, user := range m.users {
+		if user.Username == username && user.Id != forUserID {
+			return false, nil
+		}
+	}
+	held, ok := m.held[username]
+	return !ok || held.userID == forUserID || !held.until.After(now), nil
+}
+
+func (m *memoryUserRepo) ChangeUsername(id int, newUsername string, now time.Time, heldUntil time.Time) error {
+	user := m.users[id]
+	m.held[user.Username] = heldUsername{userID: id, until: heldUntil}
+	user.Username = newUsername
+	user.UsernameChangedAt = &now
+	return nil
+}
+
+type memoryRankRepo struct {
+	types.RankRepo
+}
+
+func (memoryRankRepo) ListRanks() ([]types.Rank, error) {
+	return []types.Rank{{Id: 1, Name: "Wood I", Tier: "Wood"}}, nil
+}
+
+type discardAuditLog struct{}
+
+func (discardAuditLog) Record(*types.AuditEvent) error {
+	return nil
+}
+
+func TestHandleChangeUsername(t *testing.T) {
+	saved := config.Envs
+	defer func() { config.Envs = saved }()
+	config.Envs.JWTAlgorithm = "HS256"
+	config.Envs.JWTSecret = "test-secret"
+	config.Envs.JWTExpirationInSeconds = 3600
+	config.Envs.UsernameChangeCooldownDays = 30
+	config.Envs.UsernameHoldDays = 90
+	if err := auth.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+	tests := []struct {
+		name      string
+		username  string
+		changedAt *time.Time
+		guest     bool
+		want      int
+	}{
+		{"first rename", "alicia", nil, false, http.StatusOK},
+		{"within the cooldown", "alicia", daysAgo(10), false, http.StatusTooManyRequests},
+		{"cooldown over", "alicia", daysAgo(31), false, http.StatusOK},
+		{"taken by another user", "bob", nil, false, http.StatusConflict},
+		{"held for another user", "carol", nil, false, http.StatusConflict},
+		{"hold expired", "dave", nil, false, http.StatusOK},
+		{"own old name", "ally", nil, false, http.StatusOK},
+		{"same name", "alice", nil, false, http.StatusBadRequest},
+		{"invalid name", "al", nil, false, http.StatusBadRequest},
+		{"reserved name", "moderator", nil, false, http.StatusBadRequest},
+		{"guest", "alicia", nil, true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryUserRepo{
+				users: map[int]*types.User{
+					1: {Id: 1, Username: "alice", Role: auth.RoleUser, UsernameChangedAt: tt.changedAt, IsGuest: tt.guest},
+					2: {Id: 2, Username: "bob", Role: auth.RoleUser},
+				},
+				held: map[string]heldUsername{
+					"carol": {userID: 2, until: now.Add(time.Hour)},
+					"dave":  {userID: 2, until: now.Add(-time.Hour)},
+					"ally":  {userID: 1, until: now.Add(time.Hour)},
+				},
+			}
+			h := NewHandler(store, nil, discardAuditLog{}, nil, memoryRankRepo{})
+			req := httptest.NewRequest(http.MethodPatch, "/me/username", strings.NewReader(`{"username":"`+tt.username+`"}`))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{UserID: 1, Username: "alice", Role: auth.RoleUser, SessionID: 1}))
+			rec := httptest.NewRecorder()
+			h.HandleChangeUsername(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("got %d (%s), want %d", rec.Code, strings.TrimSpace(rec.Body.String()), tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			if got := store.users[1].Username; got != tt.username {
+				t.Errorf("renamed to %q, want %q", got, tt.username)
+			}
+			// the old name is held for the hold days, longer than the tokens
+			held := store.held["alice"]
+			if held.userID != 1 || held.until.Before(now.AddDate(0, 0, 90)) {
+				t.Errorf("got hold %+v on the old name, want 90 days for user 1", held)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"backend/types"
+	"backend/utils"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sender posts events to a single endpoint in the background of its retry
+// queue. Bodies are signed with HMAC-SHA256 of the secret in the
+// X-Webhook-Signature header, failed deliveries are retried with an
+// exponential backoff.
+type Sender struct {
+	url    string
+	secret []byte
+	client *http.Client
+	queue  *utils.RetryQueue
+}
+
+func NewSender(url string, secret string, attempts int, queueSize int) *Sender {
+	return &Sender{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 30 * time.Second},
+		queue:  utils.NewRetryQueue("webhook", attempts, queueSize),
+	}
+}
+
+// Send queues the event, it does nothing when no endpoint is configured
+func (s *Sender) Send(event string, data any) error {
+	if s.url == "" {
+		return nil
+	}
+	e := types.WebhookEvent{Id: utils.GenerateToken()[:16], Event: event, CreatedAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.queue.Push(utils.RetryJob{
+		Name:    fmt.Sprintf("%s webhook %s", e.Event, e.Id),
+		Deliver: func() (time.Duration, error) { return 0, s.deliver(&e, body) },
+	})
+}
+
+// Sign returns the signature of body, receivers compute it the same way
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers queued events until the process exits
+func (s *Sender) Run() {
+	s.queue.Run()
+}
+
+// Wait blocks until the events sent so far are delivered or given up
+func (s *Sender) Wait() {
+	s.queue.Wait()
+}
+
+func (s *Sender) deliver(e *types.WebhookEvent, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", e.Event)
+	req.Header.Set("X-Webhook-Id", e.Id)
+	req.Header.Set("X-Webhook-Signature", Sign(s.secret, body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package xp
+
+import (
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Scorer scores focus sessions with the rules in force and records their XP
+type Scorer struct {
+	rules  *Rules
+	store  types.XPRepo
+	ledger types.XPLedger
+	clock  utils.Clock
+}
+
+func NewScorer(rules *Rules, store types.XPRepo, ledger types.XPLedger, clock utils.Clock) *Scorer {
+	return &Scorer{rules: rules, store: store, ledger: ledger, clock: clock}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Award credits a completed focus session or takes the penalty of an
+// abandoned one. The streak must already count the session. The session is
+// scored on the day it is saved, its end time comes from the client.
+func (s *Scorer) Award(pomodoro *types.Pomodoro) *types.XPTransaction {
+	if pomodoro.Type != "pomodoro" {
+		return nil
+	}
+	now := s.clock.Now()
+	day, err := s.store.GetScoringDay(pomodoro.UserId, startOfDay(now), pomodoro.Id)
+	if err != nil {
+		log.Printf("failed to score pomodoro %d: %v", pomodoro.Id, err)
+		return nil
+	}
+	rules := s.rules.Current()
+	score := Score(&rules, pomodoro.SessionDuration, pomodoro.Completed, now, day)
+	if score.Total == 0 {
+		return nil
+	}
+	reason := types.XPReasonFocusSession
+	if !pomodoro.Completed {
+		reason = types.XPReasonSessionAbandoned
+	}
+	source := types.XPSourcePomodoro
+	sourceID := int64(pomodoro.Id)
+	t := &types.XPTransaction{
+		UserId:     pomodoro.UserId,
+		Delta:      score.Total,
+		Reason:     reason,
+		SourceType: &source,
+		SourceId:   &sourceID,
+		Multiplier: score.Multiplier,
+		Note:       note(&score),
+		CreatedAt:  now,
+	}
+	recorded, err := s.ledger.Record(t)
+	if err != nil {
+		log.Printf("failed to award XP for pomodoro %d: %v", pomodoro.Id, err)
+		return nil
+	}
+	if !recorded {
+		return nil
+	}
+	return t
+}
+
+// note sums up the bonuses and the cap applied, for the XP history
+func note(score *types.XPScore) string {
+	parts := make([]string, 0, len(score.Bonuses)+1)
+	for _, bonus := range score.Bonuses {
+		parts = append(parts, fmt.Sprintf("%s +%d", bonus.Code, bonus.XP))
+	}
+	if score.CappedXP > 0 {
+		parts = append(parts, fmt.Sprintf("daily cap -%d", score.CappedXP))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DryRun scores a session of the user as if it were saved now, without
+// recording anything
+func (s *Scorer) DryRun(userID int, payload *types.XPDryRunPayload) (*types.XPScore, error) {
+	end := s.clock.Now()
+	if payload.EndTime != nil {
+		end = *payload.EndTime
+	}
+	today := startOfDay(end)
+	day, err := s.store.GetScoringDay(userID, today, 0)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Streak != nil {
+		day.Streak = *payload.Streak
+	} else if payload.Completed {
+		day.Streak = projectStreak(day, today)
+	}
+	rules := s.rules.Current()
+	score := Score(&rules, payload.SessionDuration, payload.Completed, end, day)
+	return &score, nil
+}
+
+// projectStreak returns the streak once a completed session of today is
+// counted, as the streak engine would
+func projectStreak(day *types.XPScoringDay, today time.Time) int {
+	switch {
+	case day.LastStreakDay == nil || day.Streak == 0:
+		return 1
+	case !day.LastStreakDay.Before(today):
+		return day.Streak
+	case day.LastStreakDay.Equal(today.AddDate(0, 0, -1)):
+		return day.Streak + 1
+	}
+	// missed days are settled first, freezes aside the streak starts over
+	return 1
+}
@@ -0,0 +1,401 @@
+package xp
+
+import (
+	"backend/services/ranks"
+	"backend/services/seasons"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type XPRepoImpl struct {
+	db *sql.DB
+}
+
+func NewXPRepoImpl(db *sql.DB) *XPRepoImpl {
+	return &XPRepoImpl{db: db}
+}
+
+const transactionColumns = "id, user_id, delta, reason, source_type, source_id, multiplier, balance_after, note, created_at"
+
+func (x *XPRepoImpl) Record(t *types.XPTransaction) (bool, error) {
+	tx, err := x.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	balance, rankID, err := lockBalance(tx, t.UserId)
+	if err != nil {
+		return false, err
+	}
+	if t.Delta < -balance {
+		t.Delta = -balance
+	}
+	if err := apply(tx, t, balance, rankID); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (x *XPRepoImpl) Spend(t *types.XPTransaction, buy func(tx *sql.Tx) error) error {
+	tx, err := x.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	balance, rankID, err := lockBalance(tx, t.UserId)
+	if err != nil {
+		return err
+	}
+	if t.Delta < -balance {
+		return types.ErrNotEnoughXP
+	}
+	if err := buy(tx); err != nil {
+		return err
+	}
+	if err := apply(tx, t, balance, rankID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockBalance reads the XP and rank of a user, the row lock serializes the
+// transactions of a user
+func lockBalance(tx *sql.Tx, userID int) (balance int, rankID int, err error) {
+	err = tx.QueryRow("SELECT xp, rank_id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&balance, &rankID)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("user not found")
+	}
+	return balance, rankID, err
+}
+
+// apply appends t to the ledger and updates the cached projection of the
+// user, from the balance and rank locked by lockBalance
+func apply(tx *sql.Tx, t *types.XPTransaction, balance int, rankID int) error {
+	if t.Multiplier == 0 {
+		t.Multiplier = 1
+	}
+	t.BalanceAfter = balance + t.Delta
+	if err := insertTransaction(tx, t); err != nil {
+		return err
+	}
+	to, err := rankFor(tx, t.BalanceAfter)
+	if err != nil {
+		return err
+	}
+	lifetime := 0
+	if types.CountsForLifetimeXP(t.Reason) {
+		lifetime = t.Delta
+	}
+	_, err = tx.Exec(
+		"UPDATE users SET xp = ?, lifetime_xp = lifetime_xp + ?, rank_id = ? WHERE id = ?",
+		t.BalanceAfter, lifetime, to.Id, t.UserId,
+	)
+	if err != nil {
+		return err
+	}
+	if types.IsEarnedXP(t.Reason) {
+		if err := addSeasonXP(tx, t); err != nil {
+			return err
+		}
+	}
+	t.RankChange = nil
+	if to.Id != rankID {
+		// the thresholds only go up so the sign of the delta gives the direction
+		direction := types.RankChangeUp
+		if t.Delta < 0 {
+			direction = types.RankChangeDown
+		}
+		if t.RankChange, err = recordRankChange(tx, t, rankID, to, direction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertTransaction(tx *sql.Tx, t *types.XPTransaction) error {
+	res, err := tx.Exec(
+		"INSERT INTO xp_transactions(user_id, delta, reason, source_type, source_id, multiplier, balance_after, note, created_at) VALUES (?,?,?,?,?,?,?,?,?)",
+		t.UserId, t.Delta, t.Reason, t.SourceType, t.SourceId, t.Multiplier, t.BalanceAfter, t.Note, t.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	t.Id, err = res.LastInsertId()
+	return err
+}
+
+// rankFor returns the highest rank reached with xp
+func rankFor(tx *sql.Tx, xp int) (types.Rank, error) {
+	var rank types.Rank
+	err := tx.QueryRow("SELECT id, name, tier, min_xp FROM ranks WHERE min_xp <= ? ORDER BY min_xp DESC LIMIT 1", xp).
+		Scan(&rank.Id, &rank.Name, &rank.Tier, &rank.MinXP)
+	return rank, err
+}
+
+// recordRankChange writes the move from rank fromID to to caused by t
+func recordRankChange(tx *sql.Tx, t *types.XPTransaction, fromID int, to types.Rank, direction string) (*types.RankChange, error) {
+	c := &types.RankChange{
+		UserId:          t.UserId,
+		FromRankId:      &fromID,
+		ToRankId:        to.Id,
+		ToRankName:      to.Name,
+		Direction:       direction,
+		XP:              t.BalanceAfter,
+		XPTransactionId: &t.Id,
+		CreatedAt:       t.CreatedAt,
+	}
+	// the previous rank may have been deleted since
+	var fromName string
+	err := tx.QueryRow("SELECT name FROM ranks WHERE id = ?", fromID).Scan(&fromName)
+	if err == nil {
+		c.FromRankName = &fromName
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+	res, err := tx.Exec(
+		"INSERT INTO rank_history(user_id, from_rank_id, from_rank_name, to_rank_id, to_rank_name, direction, xp, xp_transaction_id, created_at) VALUES (?,?,?,?,?,?,?,?,?)",
+		c.UserId, c.FromRankId, c.FromRankName, c.ToRankId, c.ToRankName, c.Direction, c.XP, c.XPTransactionId, c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if c.Id, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// addSeasonXP adds the delta of t to the season it was recorded in, opening
+// the season if needed. Closed seasons keep their results, season XP never
+// goes below zero.
+func addSeasonXP(tx *sql.Tx, t *types.XPTransaction) error {
+	start, end := seasons.Bounds(t.CreatedAt)
+	_, err := tx.Exec("INSERT IGNORE INTO seasons(name, starts_at, ends_at) VALUES (?,?,?)", seasons.Name(start), start, end)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO season_xp (season_id, user_id, xp)
+		SELECT id, ?, GREATEST(?, 0) FROM seasons WHERE starts_at = ? AND closed_at IS NULL
+		ON DUPLICATE KEY UPDATE xp = GREATEST(xp + ?, 0)`,
+		t.UserId, t.Delta, start, t.Delta,
+	)
+	return err
+}
+
+func (x *XPRepoImpl) Prestige(userID int, at time.Time) (*types.Prestige, error) {
+	tx, err := x.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var balance, rankID, level, lifetime int
+	err = tx.QueryRow("SELECT xp, rank_id, prestige, lifetime_xp FROM users WHERE id = ? FOR UPDATE", userID).
+		Scan(&balance, &rankID, &level, &lifetime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+	var top types.Rank
+	err = tx.QueryRow("SELECT id, name, tier, min_xp FROM ranks ORDER BY min_xp DESC LIMIT 1").
+		Scan(&top.Id, &top.Name, &top.Tier, &top.MinXP)
+	if err != nil {
+		return nil, err
+	}
+	// with a single rank there is nothing to climb again
+	if rankID != top.Id || top.MinXP <= 0 || balance < top.MinXP {
+		return nil, nil
+	}
+	p := &types.Prestige{
+		UserId:       userID,
+		Level:        level + 1,
+		FromRankId:   top.Id,
+		FromRankName: top.Name,
+		XP:           balance,
+		LifetimeXP:   lifetime,
+		CreatedAt:    at,
+	}
+	res, err := tx.Exec(
+		"INSERT INTO prestige_history(user_id, level, from_rank_id, from_rank_name, xp, lifetime_xp, created_at) VALUES (?,?,?,?,?,?,?)",
+		p.UserId, p.Level, p.FromRankId, p.FromRankName, p.XP, p.LifetimeXP, p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if p.Id, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	source := types.XPSourcePrestige
+	t := &types.XPTransaction{
+		UserId:     userID,
+		Delta:      -balance,
+		Reason:     types.XPReasonPrestigeReset,
+		SourceType: &source,
+		SourceId:   &p.Id,
+		Multiplier: 1,
+		Note:       fmt.Sprintf("prestige %d", p.Level),
+		CreatedAt:  at,
+	}
+	if err := insertTransaction(tx, t); err != nil {
+		return nil, err
+	}
+	to, err := rankFor(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	// lifetime XP is left as it is
+	if _, err := tx.Exec("UPDATE users SET xp = 0, rank_id = ?, prestige = ? WHERE id = ?", to.Id, p.Level, userID); err != nil {
+		return nil, err
+	}
+	if t.RankChange, err = recordRankChange(tx, t, rankID, to, types.RankChangePrestige); err != nil {
+		return nil, err
+	}
+	p.Transaction = t
+	p.RankChange = t.RankChange
+	return p, tx.Commit()
+}
+
+func (x *XPRepoImpl) Reverse(sourceType string, sourceID int64, reason string, at time.Time) (*types.XPTransaction, error) {
+	var userID, granted int
+	err := x.db.QueryRow(
+		"SELECT user_id, delta FROM xp_transactions WHERE source_type = ? AND source_id = ? AND reason = ?",
+		sourceType, sourceID, reason,
+	).Scan(&userID, &granted)
+	if err == sql.ErrNoRows || (err == nil && granted == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &types.XPTransaction{
+		UserId:     userID,
+		Delta:      -granted,
+		Reason:     types.XPReasonSessionReversed,
+		SourceType: &sourceType,
+		SourceId:   &sourceID,
+		CreatedAt:  at,
+	}
+	recorded, err := x.Record(t)
+	if err != nil || !recorded {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (x *XPRepoImpl) ListTransactions(userID int, limit, offset int) ([]types.XPTransaction, error) {
+	rows, err := x.db.Query(
+		"SELECT "+transactionColumns+" FROM xp_transactions WHERE user_id = ? ORDER BY id DESC LIMIT ? OFFSET ?",
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]types.XPTransaction, 0)
+	for rows.Next() {
+		var t types.XPTransaction
+		err := rows.Scan(&t.Id, &t.UserId, &t.Delta, &t.Reason, &t.SourceType, &t.SourceId, &t.Multiplier, &t.BalanceAfter, &t.Note, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func (x *XPRepoImpl) GetScoringDay(userID int, day time.Time, excludeID int) (*types.XPScoringDay, error) {
+	next := day.AddDate(0, 0, 1)
+	var d types.XPScoringDay
+	// the day is the one the ledger credited the sessions in, end times come
+	// from clients and backdating them would reset the daily cap
+	err := x.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(p.session_duration), 0), COALESCE(SUM(t.delta), 0) FROM xp_transactions t
+		JOIN pomodoros p ON p.id = t.source_id
+		WHERE t.user_id = ? AND t.source_type = ? AND t.reason = ? AND t.created_at >= ? AND t.created_at < ? AND p.id <> ?`,
+		userID, types.XPSourcePomodoro, types.XPReasonFocusSession, day, next, excludeID,
+	).Scan(&d.Sessions, &d.Minutes, &d.XP)
+	if err != nil {
+		return nil, err
+	}
+	err = x.db.QueryRow("SELECT current_streak, last_streak_day FROM stats WHERE user_id = ?", userID).
+		Scan(&d.Streak, &d.LastStreakDay)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (x *XPRepoImpl) RecomputeAll() (int64, []types.RankChange, error) {
+	tx, err := x.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	// spending and prestige resets count in the balance only
+	excluded := make([]any, len(types.LifetimeXPExcluded))
+	for i, reason := range types.LifetimeXPExcluded {
+		excluded[i] = reason
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(excluded)), ",")
+	res, err := tx.Exec(`
+		UPDATE users u
+		LEFT JOIN (
+			SELECT user_id, SUM(delta) AS total, SUM(IF(reason IN (`+placeholders+`), 0, delta)) AS lifetime
+			FROM xp_transactions GROUP BY user_id
+		) t ON t.user_id = u.id
+		SET u.xp = GREATEST(COALESCE(t.total, 0), 0), u.lifetime_xp = GREATEST(COALESCE(t.lifetime, 0), 0)`,
+		excluded...,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	// rebuilt balances can cross thresholds either way, keep the history complete
+	changes, err := ranks.ReassignUsers(tx, 0, time.Now())
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := recomputeSeasons(tx); err != nil {
+		return 0, nil, err
+	}
+	return changed, changes, tx.Commit()
+}
+
+// recomputeSeasons rebuilds the XP of the open seasons from the transactions
+// recorded during each of them
+func recomputeSeasons(tx *sql.Tx) error {
+	_, err := tx.Exec("DELETE sx FROM season_xp sx JOIN seasons s ON s.id = sx.season_id WHERE s.closed_at IS NULL")
+	if err != nil {
+		return err
+	}
+	reasons := make([]any, len(types.EarnedXPReasons))
+	for i, reason := range types.EarnedXPReasons {
+		reasons[i] = reason
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(reasons)), ",")
+	_, err = tx.Exec(`
+		INSERT INTO season_xp (season_id, user_id, xp)
+		SELECT s.id, t.user_id, GREATEST(SUM(t.delta), 0)
+		FROM seasons s
+		JOIN xp_transactions t ON t.created_at >= s.starts_at AND t.created_at < s.ends_at
+		WHERE s.closed_at IS NULL AND t.reason IN (`+placeholders+`)
+		GROUP BY s.id, t.user_id`,
+		reasons...,
+	)
+	return err
+}
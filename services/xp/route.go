@@ -0,0 +1,167 @@
+package xp
+
+import (
+	"backend/middleware"
+	"backend/services/audit"
+	"backend/services/auth"
+	"backend/types"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store    types.XPRepo
+	rules    *Rules
+	scorer   *Scorer
+	auditLog types.AuditLogger
+}
+
+func NewHandler(store types.XPRepo, rules *Rules, scorer *Scorer, auditLog types.AuditLogger) *Handler {
+	return &Handler{store: store, rules: rules, scorer: scorer, auditLog: auditLog}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authRouter *mux.Router) {
+	router.HandleFunc("/xp/rules", h.HandleGetRules).Methods(http.MethodGet)
+	authRouter.HandleFunc("/xp/rules/dry-run", h.HandleDryRun).Methods(http.MethodPost)
+	authRouter.HandleFunc("/me/xp/history", h.HandleListHistory).Methods(http.MethodGet)
+
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+	authRouter.Handle("/admin/xp/rules", adminOnly(http.HandlerFunc(h.HandleGetRulesSource))).Methods(http.MethodGet)
+	authRouter.Handle("/admin/xp/rules/reload", adminOnly(http.HandlerFunc(h.HandleReloadRules))).Methods(http.MethodPost)
+}
+
+// HandleGetRules godoc
+//
+// @Summary 			Get the XP rules
+// @Description 		The rules in force scoring focus sessions: XP per minute, streak multiplier, bonuses, penalties and the daily cap
+// @Tags 				XP
+// @Produce 			json
+// @Success 			200 {object} types.XPRules
+// @Router 				/xp/rules [get]
+func (h *Handler) HandleGetRules(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, h.rules.Current())
+}
+
+// HandleDryRun godoc
+//
+// @Summary 			Score a hypothetical session
+// @Description 		Score a focus session of the authenticated user with the rules in force, as if it were saved now, without recording anything. The day's sessions, XP and the streak come from the user's data unless the streak is given.
+// @Tags 				XP
+// @Accept 				json
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				request body types.XPDryRunPayload true "Hypothetical session"
+// @Success 			200 {object} types.XPScore
+// @Failure 			400 {object} types.ErrorResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/xp/rules/dry-run [post]
+func (h *Handler) HandleDryRun(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var payload types.XPDryRunPayload
+	if err := utils.ParseJson(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.SessionDuration < 0 || payload.SessionDuration > 24*60 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("session_duration must be between 0 and 1440 minutes"))
+		return
+	}
+	if payload.Streak != nil && *payload.Streak < 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("streak cannot be negative"))
+		return
+	}
+	score, err := h.scorer.DryRun(userID, &payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, score)
+}
+
+// HandleGetRulesSource godoc
+//
+// @Summary 			Get the XP rules and their source (admin)
+// @Description 		The rules in force with the file they were loaded from (empty for the built-in rules) and when
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.XPRulesResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Router 				/admin/xp/rules [get]
+func (h *Handler) HandleGetRulesSource(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, h.rules.Describe())
+}
+
+// HandleReloadRules godoc
+//
+// @Summary 			Reload the XP rules (admin)
+// @Description 		Read the rules file now instead of waiting for the next check. An invalid file is refused and the rules in force are kept.
+// @Tags 				Admin
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Success 			200 {object} types.XPRulesResponse
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			403 {object} types.ErrorResponse
+// @Failure 			422 {object} types.ErrorResponse
+// @Router 				/admin/xp/rules/reload [post]
+func (h *Handler) HandleReloadRules(w http.ResponseWriter, r *http.Request) {
+	if err := h.rules.Reload(); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	rules := h.rules.Describe()
+	actorID, _ := auth.CurrentUserID(r)
+	audit.Log(h.auditLog, r, types.AuditAdminXPRulesReload, actorID, 0, map[string]any{"source": rules.Source, "rules": rules.Rules})
+	utils.WriteJSON(w, http.StatusOK, rules)
+}
+
+// HandleListHistory godoc
+//
+// @Summary 			List my XP transactions
+// @Description 		Every XP change of the authenticated user, newest first, with the reason, the session or achievement it came from, the multiplier applied and the balance after it
+// @Tags 				XP
+// @Produce 			json
+// @Security 			ApiKeyAuth
+// @Param 				limit query int false "Page size (default 50)"
+// @Param 				offset query int false "Offset"
+// @Success 			200 {array} types.XPTransaction
+// @Failure 			401 {object} types.ErrorResponse
+// @Failure 			500 {object} types.ErrorResponse
+// @Router 				/me/xp/history [get]
+func (h *Handler) HandleListHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.CurrentUserID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	limit, offset := pagination(r)
+	list, err := h.store.ListTransactions(userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+func pagination(r *http.Request) (limit int, offset int) {
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, err = strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,216 @@
+package xp
+
+import (
+	"backend/types"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDailyCap is the XP the focus sessions of a day can earn by default,
+// ten hours of focus at the highest streak multiplier
+const DefaultDailyCap = 1200
+
+// DefaultRules score like before the rules existed: one XP per minute times
+// 1 + 0.01 per streak day after the first, up to twice the XP after 100 days,
+// without bonuses or penalties, and with DefaultDailyCap
+func DefaultRules() types.XPRules {
+	rules := types.XPRules{
+		BaseXPPerMinute: 1,
+		Streak:          types.StreakMultiplier{Base: 1, GrowthPerDay: 0.01, Cap: 2, Steps: []types.StreakStep{}},
+		DailyCap:        DefaultDailyCap,
+	}
+	rules.Bonuses.Cycle.Every = 4
+	rules.Bonuses.EarlyBird.BeforeHour = 8
+	rules.Bonuses.Goal.DailyMinutes = 100
+	return rules
+}
+
+// Rules holds the XP rules in force. They come from the JSON file at path,
+// fields missing from the file keep their default, or the defaults when path
+// is empty.
+type Rules struct {
+	path     string
+	mu       sync.RWMutex
+	current  types.XPRules
+	modTime  time.Time
+	loadedAt time.Time
+}
+
+// NewRules loads the rules, an invalid file is an error so a bad deployment
+// does not start
+func NewRules(path string) (*Rules, error) {
+	r := &Rules{path: path, current: DefaultRules(), loadedAt: time.Now()}
+	if path == "" {
+		return r, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rules) Current() types.XPRules {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+func (r *Rules) Describe() types.XPRulesResponse {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return types.XPRulesResponse{Rules: r.current, Source: r.path, LoadedAt: r.loadedAt}
+}
+
+// Reload reads the file again when it changed since the last load. The rules
+// in force are kept when the new ones are invalid.
+func (r *Rules) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := !info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	if err := r.load(info.ModTime()); err != nil {
+		return err
+	}
+	log.Printf("reloaded the XP rules from %s", r.path)
+	return nil
+}
+
+func (r *Rules) load(modTime time.Time) error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	rules := DefaultRules()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// a misspelled field would silently keep its default
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return fmt.Errorf("xp rules %s: %w", r.path, err)
+	}
+	if err := Validate(&rules); err != nil {
+		return fmt.Errorf("xp rules %s: %w", r.path, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = rules
+	r.modTime = modTime
+	r.loadedAt = time.Now()
+	return nil
+}
+
+// Validate checks the rules and sorts the streak steps
+func Validate(rules *types.XPRules) error {
+	if rules.BaseXPPerMinute < 0 {
+		return fmt.Errorf("base_xp_per_minute cannot be negative")
+	}
+	s := &rules.Streak
+	if s.Base <= 0 || s.GrowthPerDay < 0 {
+		return fmt.Errorf("the streak base must be positive and its growth cannot be negative")
+	}
+	// streaks have no end, the multiplier needs one
+	if s.Cap < s.Base {
+		return fmt.Errorf("the streak cap is required and cannot be below the base multiplier")
+	}
+	sort.Slice(s.Steps, func(i, j int) bool { return s.Steps[i].MinStreak < s.Steps[j].MinStreak })
+	for _, step := range s.Steps {
+		if step.MinStreak < 1 || step.Multiplier <= 0 {
+			return fmt.Errorf("streak steps need a min_streak of 1 or more and a positive multiplier")
+		}
+	}
+	b := &rules.Bonuses
+	if b.Cycle.XP < 0 || b.EarlyBird.XP < 0 || b.Goal.XP < 0 || rules.Penalties.AbandonedSession.XP < 0 {
+		return fmt.Errorf("bonus and penalty XP cannot be negative")
+	}
+	if b.Cycle.XP > 0 && b.Cycle.Every < 1 {
+		return fmt.Errorf("the cycle bonus needs a cycle of 1 session or more")
+	}
+	if b.EarlyBird.BeforeHour < 0 || b.EarlyBird.BeforeHour > 23 {
+		return fmt.Errorf("the early bird hour must be between 0 and 23")
+	}
+	if b.Goal.XP > 0 && b.Goal.DailyMinutes < 1 {
+		return fmt.Errorf("the goal bonus needs a goal of 1 minute or more")
+	}
+	if rules.Penalties.AbandonedSession.GraceMinutes < 0 || rules.DailyCap < 0 {
+		return fmt.Errorf("grace minutes and the daily cap cannot be negative")
+	}
+	return nil
+}
+
+// Multiplier returns the streak multiplier of the rules
+func Multiplier(rules *types.XPRules, streak int) float64 {
+	s := rules.Streak
+	multiplier := s.Base + s.GrowthPerDay*float64(max(streak-1, 0))
+	if len(s.Steps) > 0 {
+		multiplier = s.Base
+		for _, step := range s.Steps {
+			if streak >= step.MinStreak {
+				multiplier = step.Multiplier
+			}
+		}
+	}
+	return min(multiplier, s.Cap)
+}
+
+// Score applies the rules to a focus session saved at end, day describes the
+// rest of its day
+func Score(rules *types.XPRules, minutes int, completed bool, end time.Time, day *types.XPScoringDay) types.XPScore {
+	score := types.XPScore{
+		Completed:  completed,
+		Minutes:    minutes,
+		Streak:     day.Streak,
+		Multiplier: Multiplier(rules, day.Streak),
+		Bonuses:    make([]types.XPBonus, 0),
+	}
+	if !completed {
+		penalty := rules.Penalties.AbandonedSession
+		if penalty.XP > 0 && minutes >= penalty.GraceMinutes {
+			score.Penalty = penalty.XP
+			score.Total = -penalty.XP
+		}
+		return score
+	}
+	score.BaseXP = int(math.Round(float64(minutes) * rules.BaseXPPerMinute * score.Multiplier))
+	score.Total = score.BaseXP
+	bonuses := rules.Bonuses
+	if bonuses.Cycle.XP > 0 && (day.Sessions+1)%bonuses.Cycle.Every == 0 {
+		score.Bonuses = append(score.Bonuses, types.XPBonus{Code: types.XPBonusCycle, XP: bonuses.Cycle.XP})
+	}
+	if bonuses.EarlyBird.XP > 0 && end.UTC().Hour() < bonuses.EarlyBird.BeforeHour {
+		score.Bonuses = append(score.Bonuses, types.XPBonus{Code: types.XPBonusEarlyBird, XP: bonuses.EarlyBird.XP})
+	}
+	goal := bonuses.Goal.DailyMinutes
+	if bonuses.Goal.XP > 0 && day.Minutes < goal && day.Minutes+minutes >= goal {
+		score.Bonuses = append(score.Bonuses, types.XPBonus{Code: types.XPBonusGoal, XP: bonuses.Goal.XP})
+	}
+	for _, bonus := range score.Bonuses {
+		score.Total += bonus.XP
+	}
+	if rules.DailyCap > 0 {
+		allowed := max(rules.DailyCap-day.XP, 0)
+		if score.Total > allowed {
+			score.CappedXP = score.Total - allowed
+			score.Total = allowed
+		}
+	}
+	return score
+}
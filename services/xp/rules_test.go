@@ -0,0 +1,172 @@
+package xp
+
+import (
+	"backend/types"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMultiplier(t *testing.T) {
+	curve := DefaultRules()
+	steps := DefaultRules()
+	steps.Streak = types.StreakMultiplier{Base: 1, Cap: 1.5, Steps: []types.StreakStep{
+		{MinStreak: 3, Multiplier: 1.2},
+		{MinStreak: 7, Multiplier: 1.5},
+		{MinStreak: 30, Multiplier: 3},
+	}}
+	tests := []struct {
+		name   string
+		rules  types.XPRules
+		streak int
+		want   float64
+	}{
+		{"no streak", curve, 0, 1},
+		{"first day", curve, 1, 1},
+		{"second day", curve, 2, 1.01},
+		{"tenth day", curve, 10, 1.09},
+		{"reaches the cap", curve, 101, 2},
+		{"stays at the cap", curve, 1000, 2},
+		{"before the first step", steps, 2, 1},
+		{"first step", steps, 3, 1.2},
+		{"between steps", steps, 6, 1.2},
+		{"second step", steps, 7, 1.5},
+		{"step over the cap", steps, 30, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Multiplier(&tt.rules, tt.streak); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Multiplier(%d) = %v, want %v", tt.streak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	rules := DefaultRules()
+	rules.Bonuses.Cycle.XP = 20
+	rules.Bonuses.EarlyBird.XP = 10
+	rules.Bonuses.Goal.XP = 25
+	rules.Penalties.AbandonedSession.XP = 5
+	rules.Penalties.AbandonedSession.GraceMinutes = 5
+	rules.DailyCap = 600
+	noon := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	early := time.Date(2026, 3, 11, 7, 59, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		minutes   int
+		completed bool
+		end       time.Time
+		day       types.XPScoringDay
+		want      int
+		bonuses   []string
+		capped    int
+	}{
+		{"one XP per minute", 25, true, noon, types.XPScoringDay{}, 25, nil, 0},
+		{"streak multiplier", 50, true, noon, types.XPScoringDay{Streak: 11}, 55, nil, 0},
+		{"fourth session of the day", 25, true, noon, types.XPScoringDay{Sessions: 3}, 45, []string{types.XPBonusCycle}, 0},
+		{"fifth session of the day", 25, true, noon, types.XPScoringDay{Sessions: 4}, 25, nil, 0},
+		{"eighth session of the day", 25, true, noon, types.XPScoringDay{Sessions: 7}, 45, []string{types.XPBonusCycle}, 0},
+		{"early bird", 25, true, early, types.XPScoringDay{}, 35, []string{types.XPBonusEarlyBird}, 0},
+		{"early bird hour is over", 25, true, early.Add(time.Minute), types.XPScoringDay{}, 25, nil, 0},
+		{"early bird in UTC", 25, true, time.Date(2026, 3, 11, 7, 0, 0, 0, time.FixedZone("UTC+2", 2*3600)), types.XPScoringDay{}, 35, []string{types.XPBonusEarlyBird}, 0},
+		{"reaches the goal", 25, true, noon, types.XPScoringDay{Minutes: 75}, 50, []string{types.XPBonusGoal}, 0},
+		{"goal already reached", 25, true, noon, types.XPScoringDay{Minutes: 100}, 25, nil, 0},
+		{"every bonus", 25, true, early, types.XPScoringDay{Sessions: 3, Minutes: 80}, 80, []string{types.XPBonusCycle, types.XPBonusEarlyBird, types.XPBonusGoal}, 0},
+		{"capped", 50, true, noon, types.XPScoringDay{XP: 580}, 20, nil, 30},
+		{"cap reached", 25, true, noon, types.XPScoringDay{XP: 600}, 0, nil, 25},
+		{"bonuses are capped too", 25, true, noon, types.XPScoringDay{Sessions: 3, XP: 560}, 40, []string{types.XPBonusCycle}, 5},
+		{"abandoned", 10, false, noon, types.XPScoringDay{}, -5, nil, 0},
+		{"abandoned at the grace limit", 5, false, noon, types.XPScoringDay{}, -5, nil, 0},
+		{"abandoned within the grace", 4, false, noon, types.XPScoringDay{}, 0, nil, 0},
+		{"abandoned sessions get no bonus", 25, false, early, types.XPScoringDay{Sessions: 3}, -5, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Score(&rules, tt.minutes, tt.completed, tt.end, &tt.day)
+			if score.Total != tt.want || score.CappedXP != tt.capped {
+				t.Errorf("got %d XP with %d capped, want %d with %d capped", score.Total, score.CappedXP, tt.want, tt.capped)
+			}
+			if len(score.Bonuses) != len(tt.bonuses) {
+				t.Fatalf("got bonuses %v, want %v", score.Bonuses, tt.bonuses)
+			}
+			for i, bonus := range score.Bonuses {
+				if bonus.Code != tt.bonuses[i] {
+					t.Errorf("got bonuses %v, want %v", score.Bonuses, tt.bonuses)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreDefaultRulesAreCapped(t *testing.T) {
+	rules := DefaultRules()
+	if rules.DailyCap <= 0 {
+		t.Fatalf("the default rules have no daily cap")
+	}
+	score := Score(&rules, 120, true, time.Now(), &types.XPScoringDay{XP: rules.DailyCap - 10, Streak: 200})
+	if score.Total != 10 {
+		t.Errorf("got %d XP over the default daily cap, want 10", score.Total)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(r *types.XPRules)
+		wantErr bool
+	}{
+		{"default rules", func(r *types.XPRules) {}, false},
+		{"no daily cap", func(r *types.XPRules) { r.DailyCap = 0 }, false},
+		{"negative daily cap", func(r *types.XPRules) { r.DailyCap = -1 }, true},
+		{"negative base XP", func(r *types.XPRules) { r.BaseXPPerMinute = -1 }, true},
+		{"zero streak base", func(r *types.XPRules) { r.Streak.Base = 0 }, true},
+		{"negative streak growth", func(r *types.XPRules) { r.Streak.GrowthPerDay = -0.01 }, true},
+		{"no streak cap", func(r *types.XPRules) { r.Streak.Cap = 0 }, true},
+		{"streak cap below the base", func(r *types.XPRules) { r.Streak.Cap = 0.5 }, true},
+		{"streak steps", func(r *types.XPRules) {
+			r.Streak.Steps = []types.StreakStep{{MinStreak: 7, Multiplier: 1.5}, {MinStreak: 3, Multiplier: 1.2}}
+		}, false},
+		{"step from day 0", func(r *types.XPRules) { r.Streak.Steps = []types.StreakStep{{MinStreak: 0, Multiplier: 1.2}} }, true},
+		{"step without multiplier", func(r *types.XPRules) { r.Streak.Steps = []types.StreakStep{{MinStreak: 3}} }, true},
+		{"negative bonus", func(r *types.XPRules) { r.Bonuses.Cycle.XP = -1 }, true},
+		{"negative penalty", func(r *types.XPRules) { r.Penalties.AbandonedSession.XP = -1 }, true},
+		{"cycle of 0 sessions", func(r *types.XPRules) {
+			r.Bonuses.Cycle.XP = 20
+			r.Bonuses.Cycle.Every = 0
+		}, true},
+		{"unused cycle of 0 sessions", func(r *types.XPRules) { r.Bonuses.Cycle.Every = 0 }, false},
+		{"early bird at 24", func(r *types.XPRules) { r.Bonuses.EarlyBird.BeforeHour = 24 }, true},
+		{"goal of 0 minutes", func(r *types.XPRules) {
+			r.Bonuses.Goal.XP = 25
+			r.Bonuses.Goal.DailyMinutes = 0
+		}, true},
+		{"negative grace", func(r *types.XPRules) { r.Penalties.AbandonedSession.GraceMinutes = -1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules()
+			tt.change(&rules)
+			if err := Validate(&rules); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSortsTheSteps(t *testing.T) {
+	rules := DefaultRules()
+	rules.Streak.Steps = []types.StreakStep{{MinStreak: 30, Multiplier: 2}, {MinStreak: 3, Multiplier: 1.2}, {MinStreak: 7, Multiplier: 1.5}}
+	if err := Validate(&rules); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{3, 7, 30} {
+		if rules.Streak.Steps[i].MinStreak != want {
+			t.Fatalf("got steps %v, want them by min_streak", rules.Streak.Steps)
+		}
+	}
+	// sorted steps give the multiplier of the highest step reached
+	if got := Multiplier(&rules, 10); got != 1.5 {
+		t.Errorf("Multiplier(10) = %v, want 1.5", got)
+	}
+}
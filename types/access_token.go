@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+type AccessTokenRepo interface {
+	CreateAccessToken(*AccessToken) error
+	ListAccessTokens(userID int) ([]AccessToken, error)
+	RevokeAccessToken(userID int, id int) error
+	GetAccessTokenByHash(hash string) (*AccessToken, error)
+	TouchAccessToken(id int) error
+}
+
+type AccessToken struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"user_id"`
+	Username   string     `json:"-"`
+	Role       string     `json:"-"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAccessTokenPayload struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// the plain token is only returned once, at creation time
+type CreatedAccessTokenResponse struct {
+	Token       string      `json:"token"`
+	AccessToken AccessToken `json:"access_token"`
+}
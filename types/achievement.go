@@ -0,0 +1,70 @@
+package types
+
+import "time"
+
+// what an achievement counts
+const (
+	// completed focus sessions
+	AchievementMetricPomodoros = "pomodoros"
+	// longest streak, in days
+	AchievementMetricStreak = "streak"
+	// most focus sessions completed before 9:00 on a single day
+	AchievementMetricEarlyPomodoros = "early_pomodoros"
+	// XP toward the first rank of Tier, the target comes from the rank catalogue
+	AchievementMetricTier = "tier"
+)
+
+type AchievementRepo interface {
+	ListUnlocked(userID int) ([]UnlockedAchievement, error)
+	// Unlock records the achievement and returns false when the user already has it
+	Unlock(*UnlockedAchievement) (bool, error)
+	GetMetrics(userID int) (*AchievementMetrics, error)
+}
+
+// AchievementEvaluator unlocks the achievements a user just reached, it is
+// run after the events that can move a metric
+type AchievementEvaluator interface {
+	Evaluate(userID int, at time.Time) ([]UnlockedAchievement, error)
+	// EvaluateStreak is only called by the streak engine, with the streak it kept
+	EvaluateStreak(userID int, streak int, at time.Time) ([]UnlockedAchievement, error)
+}
+
+// Achievement is an entry of the catalogue
+type Achievement struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	// unused for the tier metric
+	Target   int    `json:"target,omitempty"`
+	Tier     string `json:"tier,omitempty"`
+	XPReward int    `json:"xp_reward"`
+}
+
+type AchievementMetrics struct {
+	Pomodoros int
+	// the current streak, only shown as progress: streak achievements are
+	// unlocked from the streak engine
+	Streak         int
+	EarlyPomodoros int
+	XP             int
+}
+
+type UnlockedAchievement struct {
+	Id          int64     `json:"id"`
+	UserId      int       `json:"user_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	XPReward    int       `json:"xp_reward"`
+	UnlockedAt  time.Time `json:"unlocked_at"`
+}
+
+// AchievementProgress is how far a user is from a locked achievement
+type AchievementProgress struct {
+	Achievement
+	Current int `json:"current"`
+	// Target, or the min_xp of the tier for the tier metric
+	Goal    int `json:"goal"`
+	Percent int `json:"percent"`
+}
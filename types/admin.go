@@ -0,0 +1,42 @@
+package types
+
+import "time"
+
+type AdminRepo interface {
+	ListUsers(limit, offset int, search string) ([]UserSummary, error)
+	GetUser(id int) (*UserSummary, error)
+	// SetUserRole refuses to demote the last admin
+	SetUserRole(id int, role string) (*UserSummary, error)
+	SetUserCountry(id int, country string) (*UserSummary, error)
+	ListUserPomodoros(userID int) ([]Pomodoro, error)
+	DeletePomodoro(id int) error
+	AnonymizeUser(id int) (*UserSummary, error)
+}
+
+// UserSummary is the user as seen by moderators, without credentials
+type UserSummary struct {
+	Id        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     *string   `json:"email"`
+	Country   *string   `json:"country"`
+	XP        int       `json:"xp"`
+	RankId    int       `json:"rank_id"`
+	Role      string    `json:"role"`
+	IsGuest   bool      `json:"is_guest"`
+	CreatedAt time.Time `json:"created_at"`
+	// prestiges go back to 0 XP, lifetime XP keeps counting
+	Prestige   int `json:"prestige"`
+	LifetimeXP int `json:"lifetime_xp"`
+	// only set in responses
+	RankProgress  *RankProgress  `json:"rank_progress,omitempty"`
+	PrestigeBadge *PrestigeBadge `json:"prestige_badge,omitempty"`
+}
+
+type UpdateRolePayload struct {
+	Role string `json:"role"`
+}
+
+type AdjustXPPayload struct {
+	Delta  int    `json:"delta"`
+	Reason string `json:"reason"`
+}
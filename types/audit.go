@@ -0,0 +1,83 @@
+package types
+
+import "time"
+
+// security events recorded in the audit log
+const (
+	AuditLogin               = "login"
+	AuditLoginFailed         = "login_failed"
+	AuditMagicLinkLogin      = "magic_link_login"
+	AuditPasswordResetSent   = "password_reset_requested"
+	AuditPasswordReset       = "password_reset"
+	AuditEmailChangeRequest  = "email_change_requested"
+	AuditEmailChanged        = "email_changed"
+	AuditCountryChanged      = "country_changed"
+	AuditUsernameChanged     = "username_changed"
+	AuditGuestCreated        = "guest_created"
+	AuditGuestLogin          = "guest_login"
+	AuditGuestClaimed        = "guest_claimed"
+	AuditDeletionScheduled   = "account_deletion_scheduled"
+	AuditDeletionCancelled   = "account_deletion_cancelled"
+	AuditSessionRevoked      = "session_revoked"
+	AuditAccessTokenCreated  = "access_token_created"
+	AuditAccessTokenRevoked  = "access_token_revoked"
+	AuditPrestigeReset       = "prestige_reset"
+	AuditAdminRoleChanged    = "admin_role_changed"
+	AuditAdminCountryChanged = "admin_country_changed"
+	AuditAdminXPAdjusted     = "admin_xp_adjusted"
+	AuditAdminUserAnonymized = "admin_user_anonymized"
+	AuditAdminPomodoroDelete = "admin_pomodoro_deleted"
+	AuditAdminRankChanged    = "admin_rank_changed"
+	AuditAdminXPRulesReload  = "admin_xp_rules_reloaded"
+)
+
+type AuditLogger interface {
+	Record(*AuditEvent) error
+}
+
+type AuditRepo interface {
+	AuditLogger
+	ListUserEvents(userID int, limit, offset int) ([]AuditEvent, error)
+	QueryEvents(AuditFilter) ([]AuditEvent, error)
+	VerifyChain() (*AuditVerification, error)
+}
+
+// AuditEvent is an append-only, hash-chained record: Hash covers the event
+// fields and PrevHash, so editing or removing a row breaks every later hash.
+// Hash covers PersonalDataHash rather than IP, UserAgent and Metadata, which
+// lets them be redacted for a deleted or anonymized user and the chain still
+// verify.
+type AuditEvent struct {
+	Id        int64     `json:"id"`
+	EventType string    `json:"event_type"`
+	ActorId   *int      `json:"actor_id"`
+	TargetId  *int      `json:"target_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Metadata  string    `json:"metadata"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+	// empty for the events recorded before redaction existed, whose Hash
+	// covers IP, UserAgent and Metadata themselves
+	PersonalDataHash string     `json:"-"`
+	RedactedAt       *time.Time `json:"redacted_at,omitempty"`
+}
+
+type AuditFilter struct {
+	ActorId   int
+	TargetId  int
+	EventType string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+}
+
+type AuditVerification struct {
+	Valid   bool `json:"valid"`
+	Checked int  `json:"checked"`
+	// redacted events recorded before metadata hashes only prove their place in the chain
+	Unverifiable int    `json:"unverifiable"`
+	BrokenAt     *int64 `json:"broken_at,omitempty"`
+}
@@ -0,0 +1,70 @@
+package types
+
+import "time"
+
+type DigestRepo interface {
+	// SetDigestOptIn also gives the user an unsubscribe token the first time
+	SetDigestOptIn(userID int, enabled bool, unsubscribeToken string) error
+	Unsubscribe(token string) (bool, error)
+	GetDigestRecipient(userID int) (*DigestRecipient, error)
+	// ListDueRecipients returns the opted-in users with an email who did not get the digest of the week yet
+	ListDueRecipients(weekStart time.Time) ([]DigestRecipient, error)
+	WeekActivity(userID int, from, to time.Time) (pomodoros int, focusMinutes int, err error)
+	GetCurrentStreak(userID int) (int, error)
+	GetDelivery(userID int, weekStart time.Time) (*DigestDelivery, error)
+	RecordDelivery(*DigestDelivery) error
+}
+
+type DigestRecipient struct {
+	UserId           int
+	Username         string
+	Email            *string
+	Country          *string
+	Language         string
+	XP               int
+	OptIn            bool
+	UnsubscribeToken *string
+}
+
+// DigestDelivery is the rank snapshot taken when a digest is sent
+type DigestDelivery struct {
+	UserId      int
+	WeekStart   time.Time
+	XP          int
+	GlobalRank  *int
+	CountryRank *int
+	SentAt      time.Time
+}
+
+// WeeklyDigest is the content of a digest email. Ranks are 0 when unknown and
+// the changes are positive when the user climbed.
+type WeeklyDigest struct {
+	UserId               int       `json:"user_id"`
+	Username             string    `json:"username"`
+	WeekStart            time.Time `json:"week_start"`
+	WeekEnd              time.Time `json:"week_end"`
+	Pomodoros            int       `json:"pomodoros"`
+	PreviousPomodoros    int       `json:"previous_pomodoros"`
+	PomodoroChange       int       `json:"pomodoro_change"`
+	FocusMinutes         int       `json:"focus_minutes"`
+	PreviousFocusMinutes int       `json:"previous_focus_minutes"`
+	FocusMinutesChange   int       `json:"focus_minutes_change"`
+	CurrentStreak        int       `json:"current_streak"`
+	XP                   int       `json:"xp"`
+	RankName             string    `json:"rank_name"`
+	NextRankName         string    `json:"next_rank_name"`
+	XPToNextRank         int       `json:"xp_to_next_rank"`
+	RankProgressPercent  int       `json:"rank_progress_percent"`
+	GlobalRank           int       `json:"global_rank"`
+	PreviousGlobalRank   int       `json:"previous_global_rank"`
+	GlobalRankChange     int       `json:"global_rank_change"`
+	Country              string    `json:"country"`
+	CountryRank          int       `json:"country_rank"`
+	PreviousCountryRank  int       `json:"previous_country_rank"`
+	CountryRankChange    int       `json:"country_rank_change"`
+	UnsubscribeURL       string    `json:"-"`
+}
+
+type DigestOptInPayload struct {
+	Enabled bool `json:"enabled"`
+}
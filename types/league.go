@@ -0,0 +1,85 @@
+package types
+
+import "time"
+
+// what happens to a league member at the end of the week
+const (
+	LeaguePromoted  = "promoted"
+	LeagueRelegated = "relegated"
+	LeagueStayed    = "stayed"
+)
+
+type LeagueRepo interface {
+	// GetLatestMembership returns the last week the user played, nil if never
+	GetLatestMembership(userID int) (*LeagueMember, error)
+	GetMembership(userID int, week time.Time) (*LeagueMember, error)
+	// JoinLeague puts the user in the smallest cohort of the tier for the
+	// week, or a new one when they are all full. A user already in the league
+	// keeps their cohort. It returns nil for users left out of rankings.
+	JoinLeague(userID int, week time.Time, tier int, cohortSize int, at time.Time) (*LeagueMember, error)
+	// ListCohortStandings returns the members of the cohort by weekly XP
+	// earned between from and to, ties broken by who joined first. Placement
+	// and Outcome are left to the caller.
+	ListCohortStandings(cohortID int, from, to time.Time) ([]LeagueStanding, error)
+	// ListUnplacedUsers returns the users who earned XP between from and to
+	// and are not in the league of the week starting at from
+	ListUnplacedUsers(from, to time.Time) ([]int, error)
+	// ListWeeksToClose returns the weeks not rolled over starting before before
+	ListWeeksToClose(before time.Time) ([]time.Time, error)
+	// CloseWeek rolls the week over in one transaction: rollover gets the
+	// cohorts with their final standings, sets the placement and outcome of
+	// each member and returns the cohorts of the next week. It returns false
+	// when the week was already closed.
+	CloseWeek(week time.Time, at time.Time, rollover func([]LeagueCohort) []LeagueAssignment) (bool, error)
+}
+
+// LeagueTier is an entry of the league catalogue, 1 is the lowest
+type LeagueTier struct {
+	Tier int    `json:"tier"`
+	Name string `json:"name"`
+}
+
+type LeagueMember struct {
+	UserId    int       `json:"user_id"`
+	WeekStart time.Time `json:"week_start"`
+	CohortId  int       `json:"cohort_id"`
+	Tier      int       `json:"tier"`
+	JoinedAt  time.Time `json:"joined_at"`
+	// set when the week is rolled over
+	WeeklyXP  *int    `json:"weekly_xp"`
+	Placement *int    `json:"placement"`
+	Outcome   *string `json:"outcome"`
+}
+
+type LeagueStanding struct {
+	UserId    int    `json:"user_id"`
+	Username  string `json:"username"`
+	XP        int    `json:"xp"`
+	Placement int    `json:"placement"`
+	// promoted, relegated or stayed, if the week ended now
+	Outcome string `json:"outcome"`
+}
+
+type LeagueCohort struct {
+	Id        int
+	Tier      int
+	Standings []LeagueStanding
+}
+
+// LeagueAssignment is a cohort to create for the next week
+type LeagueAssignment struct {
+	Tier    int
+	UserIds []int
+}
+
+type LeagueResponse struct {
+	WeekStart time.Time  `json:"week_start"`
+	EndsAt    time.Time  `json:"ends_at"`
+	League    LeagueTier `json:"league"`
+	CohortId  int        `json:"cohort_id"`
+	// how many move up and down at the end of the week
+	Promoted  int              `json:"promoted"`
+	Relegated int              `json:"relegated"`
+	Standings []LeagueStanding `json:"standings"`
+	Me        *LeagueStanding  `json:"me"`
+}
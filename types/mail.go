@@ -0,0 +1,27 @@
+package types
+
+type Mailer interface {
+	Send(Email) error
+}
+
+// Email is a message ready to be delivered, the sender address comes from the mailer
+type Email struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	// plain-text alternative, sent as multipart/alternative when set
+	TextBody string
+	// extra headers, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type EmailTemplateList struct {
+	Templates []string `json:"templates"`
+	Locales   []string `json:"locales"`
+}
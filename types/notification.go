@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// notification categories, users can mute each of them
+const (
+	NotificationRank        = "rank"
+	NotificationStreak      = "streak"
+	NotificationAchievement = "achievement"
+	NotificationMilestone   = "milestone"
+	NotificationQuest       = "quest"
+	NotificationSeason      = "season"
+	NotificationLeague      = "league"
+	NotificationSocial      = "social"
+	NotificationSystem      = "system"
+)
+
+var NotificationCategories = []string{
+	NotificationRank,
+	NotificationStreak,
+	NotificationAchievement,
+	NotificationMilestone,
+	NotificationQuest,
+	NotificationSeason,
+	NotificationLeague,
+	NotificationSocial,
+	NotificationSystem,
+}
+
+func IsNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier is the internal API other services use to reach a user
+type Notifier interface {
+	// Notify stores the notification unless the user muted its category, in
+	// which case the Id stays 0
+	Notify(*Notification) error
+}
+
+type NotificationRepo interface {
+	Notifier
+	ListNotifications(userID int, unreadOnly bool, limit, offset int) ([]Notification, error)
+	CountUnread(userID int) (int, error)
+	// MarkRead reports whether the notification belongs to the user
+	MarkRead(userID int, id int64, at time.Time) (bool, error)
+	MarkAllRead(userID int, at time.Time) (int64, error)
+	GetPreferences(userID int) ([]NotificationPreference, error)
+	SetPreferences(userID int, preferences map[string]bool) error
+	PurgeReadNotifications(before time.Time) (int64, error)
+}
+
+type Notification struct {
+	Id        int64           `json:"id"`
+	UserId    int             `json:"user_id"`
+	Category  string          `json:"category"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int            `json:"unread_count"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+}
+
+type NotificationPreference struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type UpdateNotificationPreferencesPayload struct {
+	Preferences map[string]bool `json:"preferences"`
+}
+
+type MarkAllReadResponse struct {
+	Updated int64 `json:"updated"`
+}
@@ -6,6 +6,7 @@ import (
 
 type PomodoroRepo interface {
 	AddPomodoro(AddingPomodoroPayload) (*Pomodoro, error)
+	CountCompletedPomodoros(userID int) (int, error)
 }
 
 type Pomodoro struct {
@@ -17,8 +18,14 @@ type Pomodoro struct {
 	StartTime       time.Time `json:"start_time"`
 	EndTime         time.Time `json:"end_time"`
 	CreatedAt       time.Time `json:"created_at"`
+	// only set in the response of POST /pomodoro
+	XPAwarded            int                   `json:"xp_awarded,omitempty"`
+	AchievementsUnlocked []UnlockedAchievement `json:"achievements_unlocked,omitempty"`
 }
 
+// PomodoroTypes are the values of the type column
+var PomodoroTypes = []string{"pomodoro", "short break", "long break"}
+
 type AddingPomodoroPayload struct {
 	UserId          int       `json:"user_id"`
 	Type            string    `json:"type"`
@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// PrestigeBadge is shown next to the users who prestiged, it is never lost
+type PrestigeBadge struct {
+	Level int    `json:"level"`
+	Name  string `json:"name"`
+}
+
+// Prestige is a row of prestige_history
+type Prestige struct {
+	Id           int64  `json:"id"`
+	UserId       int    `json:"user_id"`
+	Level        int    `json:"level"`
+	FromRankId   int    `json:"from_rank_id"`
+	FromRankName string `json:"from_rank_name"`
+	// the XP reset and the lifetime XP at that moment
+	XP         int       `json:"xp"`
+	LifetimeXP int       `json:"lifetime_xp"`
+	CreatedAt  time.Time `json:"created_at"`
+	// only set in responses
+	Badge *PrestigeBadge `json:"badge,omitempty"`
+	// set by Prestige, not stored
+	Transaction *XPTransaction `json:"-"`
+	RankChange  *RankChange    `json:"-"`
+}
+
+// PrestigeStatus is where a user stands with prestige
+type PrestigeStatus struct {
+	Level      int            `json:"level"`
+	Badge      *PrestigeBadge `json:"badge"`
+	XP         int            `json:"xp"`
+	LifetimeXP int            `json:"lifetime_xp"`
+	RankId     int            `json:"rank_id"`
+	// true at the top rank, the user can prestige
+	Eligible bool       `json:"eligible"`
+	History  []Prestige `json:"history"`
+}
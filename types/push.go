@@ -0,0 +1,88 @@
+package types
+
+import "time"
+
+// scheduled push kinds
+const (
+	PushTimerFocus   = "focus_end"
+	PushTimerBreak   = "break_end"
+	PushStreakAtRisk = "streak_at_risk"
+)
+
+type PushRepo interface {
+	// SaveSubscription inserts the subscription or moves an existing endpoint to the user
+	SaveSubscription(*PushSubscription) error
+	ListUserSubscriptions(userID int) ([]PushSubscription, error)
+	// DeleteSubscription reports whether the subscription belongs to the user
+	DeleteSubscription(userID int, id int) (bool, error)
+	DeleteSubscriptionByEndpoint(endpoint string) error
+	MarkSubscriptionUsed(id int, at time.Time) error
+	PurgeExpiredSubscriptions(now time.Time) (int64, error)
+	// SchedulePush replaces the pending push of the same kind
+	SchedulePush(*ScheduledPush) error
+	CancelScheduledPush(userID int, kind string) (bool, error)
+	// TakeDuePushes deletes and returns the pushes due at now
+	TakeDuePushes(now time.Time) ([]ScheduledPush, error)
+	// ListStreakAtRisk returns the subscribed users with a running streak who did
+	// not complete a pomodoro since day and were not reminded on day yet
+	ListStreakAtRisk(day time.Time) ([]int, error)
+	RecordReminder(userID int, kind string, day time.Time) error
+}
+
+// Pusher delivers a message to every push subscription of a user
+type Pusher interface {
+	Push(userID int, message PushMessage) error
+}
+
+type PushSubscription struct {
+	Id            int        `json:"id"`
+	UserId        int        `json:"user_id"`
+	Endpoint      string     `json:"endpoint"`
+	P256dh        string     `json:"-"`
+	Auth          string     `json:"-"`
+	UserAgent     string     `json:"user_agent"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+}
+
+// PushMessage is the JSON payload the service worker receives
+type PushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Tag lets the browser replace an older notification of the same kind
+	Tag string `json:"tag,omitempty"`
+	URL string `json:"url,omitempty"`
+	// Urgency is very-low, low, normal (default) or high
+	Urgency string `json:"-"`
+	// TTL is how long the push service keeps the message for an offline device
+	TTL time.Duration `json:"-"`
+}
+
+type ScheduledPush struct {
+	UserId int       `json:"user_id"`
+	Kind   string    `json:"kind"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	SendAt time.Time `json:"send_at"`
+}
+
+// PushSubscriptionPayload is the PushSubscription.toJSON() of the browser
+type PushSubscriptionPayload struct {
+	Endpoint       string `json:"endpoint"`
+	ExpirationTime *int64 `json:"expirationTime"`
+	Keys           struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+type PushTimerPayload struct {
+	// focus_end or break_end
+	Kind   string    `json:"kind"`
+	EndsAt time.Time `json:"ends_at"`
+}
+
+type VAPIDPublicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
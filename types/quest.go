@@ -0,0 +1,81 @@
+package types
+
+import "time"
+
+// how often quests rotate
+const (
+	QuestDaily  = "daily"
+	QuestWeekly = "weekly"
+)
+
+// what a quest counts
+const (
+	QuestMeasureSessions = "sessions"
+	QuestMeasureMinutes  = "minutes"
+)
+
+// where a user quest stands, computed when it is read
+const (
+	QuestActive    = "active"
+	QuestCompleted = "completed"
+	QuestClaimed   = "claimed"
+	QuestExpired   = "expired"
+)
+
+type QuestRepo interface {
+	// AssignQuests inserts the quests, skipping the ones the user already has
+	// for the period
+	AssignQuests([]UserQuest) error
+	// ListQuests returns the quests of the user expiring after since
+	ListQuests(userID int, since time.Time) ([]UserQuest, error)
+	GetQuest(userID int, id int64) (*UserQuest, error)
+	// AddQuestProgress adds to the progress, capped at the target, and sets
+	// completed_at when the target is reached. It returns whether this call
+	// completed the quest.
+	AddQuestProgress(id int64, amount int, at time.Time) (bool, error)
+	// MarkQuestClaimed returns false when the quest was already claimed
+	MarkQuestClaimed(id int64, at time.Time) (bool, error)
+	PurgeExpiredQuests(before time.Time) (int64, error)
+}
+
+// QuestTracker moves quests forward on pomodoro events
+type QuestTracker interface {
+	TrackPomodoro(*Pomodoro) error
+}
+
+// Quest is an entry of the quest catalogue
+type Quest struct {
+	Code        string `json:"code"`
+	Period      string `json:"period"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// the pomodoro type counted, "pomodoro", "short break" or "long break"
+	Type    string `json:"type"`
+	Measure string `json:"measure"`
+	// only sessions ending before this hour count, 0 for any time
+	BeforeHour int `json:"before_hour,omitempty"`
+	Target     int `json:"target"`
+	XPReward   int `json:"xp_reward"`
+}
+
+type UserQuest struct {
+	Id          int64      `json:"id"`
+	UserId      int        `json:"user_id"`
+	Code        string     `json:"code"`
+	Period      string     `json:"period"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Progress    int        `json:"progress"`
+	Target      int        `json:"target"`
+	XPReward    int        `json:"xp_reward"`
+	Status      string     `json:"status"`
+	PeriodStart time.Time  `json:"period_start"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ClaimedAt   *time.Time `json:"claimed_at"`
+}
+
+type ClaimQuestResponse struct {
+	Quest     *UserQuest `json:"quest"`
+	XPAwarded int        `json:"xp_awarded"`
+}
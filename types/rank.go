@@ -0,0 +1,112 @@
+package types
+
+import "time"
+
+type RankRepo interface {
+	// ListRanks returns the catalogue ordered by min_xp
+	ListRanks() ([]Rank, error)
+	GetRank(id int) (*Rank, error)
+	// CreateRank, UpdateRank and DeleteRank move the users whose rank changed
+	// in the same transaction, record it in rank_history and return the
+	// changes for the caller to announce
+	CreateRank(*Rank) ([]RankChange, error)
+	UpdateRank(*Rank) ([]RankChange, error)
+	DeleteRank(id int) ([]RankChange, error)
+	// ListRankHistory returns the rank changes of a user, oldest first
+	ListRankHistory(userID int, limit, offset int) ([]RankChange, error)
+	// GetPrestigeStatus returns the prestige level, balances and rank of a
+	// user with their prestiges, oldest first. Badge and Eligible are left to
+	// the caller.
+	GetPrestigeStatus(userID int) (*PrestigeStatus, error)
+}
+
+type Rank struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Tier  string `json:"tier"`
+	MinXP int    `json:"min_xp"`
+}
+
+// RankTier groups the divisions of a tier, e.g. Wood I to Wood IX
+type RankTier struct {
+	Name  string `json:"name"`
+	MinXP int    `json:"min_xp"`
+	Ranks []Rank `json:"ranks"`
+}
+
+// RankProgress is where a user stands in the catalogue, Next is nil at the top rank
+type RankProgress struct {
+	Current      Rank  `json:"current"`
+	Next         *Rank `json:"next"`
+	XPToNextRank int   `json:"xp_to_next_rank"`
+	// 0 to 100 between the current and the next threshold, 100 at the top rank
+	Percent int `json:"percent"`
+}
+
+// Ranked is a user shown with their rank progress and prestige badge, see
+// ranks.FillProgress. RankStanding returns false for a nil user.
+type Ranked interface {
+	RankStanding() (xp int, prestige int, ok bool)
+	SetRankStanding(progress *RankProgress, badge *PrestigeBadge)
+}
+
+func (u *User) RankStanding() (int, int, bool) {
+	if u == nil {
+		return 0, 0, false
+	}
+	return u.XP, u.Prestige, true
+}
+
+func (u *User) SetRankStanding(progress *RankProgress, badge *PrestigeBadge) {
+	u.RankProgress, u.PrestigeBadge = progress, badge
+}
+
+func (u *UserSummary) RankStanding() (int, int, bool) {
+	if u == nil {
+		return 0, 0, false
+	}
+	return u.XP, u.Prestige, true
+}
+
+func (u *UserSummary) SetRankStanding(progress *RankProgress, badge *PrestigeBadge) {
+	u.RankProgress, u.PrestigeBadge = progress, badge
+}
+
+func (e *RankEntry) RankStanding() (int, int, bool) {
+	if e == nil {
+		return 0, 0, false
+	}
+	return e.XP, e.Prestige, true
+}
+
+func (e *RankEntry) SetRankStanding(progress *RankProgress, badge *PrestigeBadge) {
+	e.RankProgress, e.PrestigeBadge = progress, badge
+}
+
+type RankPayload struct {
+	Name  string `json:"name"`
+	Tier  string `json:"tier"`
+	MinXP int    `json:"min_xp"`
+}
+
+// which way a rank change went
+const (
+	RankChangeUp   = "up"
+	RankChangeDown = "down"
+	// back to the first rank by prestige
+	RankChangePrestige = "prestige"
+)
+
+// RankChange is a row of rank_history, From is nil for the starting rank
+type RankChange struct {
+	Id              int64     `json:"id"`
+	UserId          int       `json:"user_id"`
+	FromRankId      *int      `json:"from_rank_id"`
+	FromRankName    *string   `json:"from_rank_name"`
+	ToRankId        int       `json:"to_rank_id"`
+	ToRankName      string    `json:"to_rank_name"`
+	Direction       string    `json:"direction"`
+	XP              int       `json:"xp"`
+	XPTransactionId *int64    `json:"xp_transaction_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -6,6 +6,10 @@ type RankEntry struct {
     Rank     int    `json:"rank"`
     XP       int    `json:"xp"`
     RankId   int    `json:"rank_id"`
+    // prestige comes before XP in every ranking
+    Prestige int    `json:"prestige"`
+    PrestigeBadge *PrestigeBadge `json:"prestige_badge,omitempty"`
+    RankProgress *RankProgress `json:"rank_progress,omitempty"`
 }
 
 type RankingRepo interface {
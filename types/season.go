@@ -0,0 +1,81 @@
+package types
+
+import "time"
+
+// where a season stands
+const (
+	SeasonOpen   = "open"
+	SeasonClosed = "closed"
+)
+
+type SeasonRepo interface {
+	// EnsureSeason creates the season starting at start unless it exists and
+	// returns it
+	EnsureSeason(name string, start, end time.Time) (*Season, error)
+	GetSeason(id int) (*Season, error)
+	ListSeasons(limit, offset int) ([]Season, error)
+	// ListSeasonsToClose returns the open seasons ended before now
+	ListSeasonsToClose(now time.Time) ([]Season, error)
+	// CloseSeason saves the final placements of the season with the reward
+	// of each one and marks it closed, in one transaction. It returns false
+	// when the season was already closed.
+	CloseSeason(id int, rewards []SeasonReward, at time.Time) (bool, error)
+	// ListSeasonRanking returns the live ranking of an open season or the
+	// final one of a closed season, in a country when country is not empty
+	ListSeasonRanking(season *Season, country string, limit, offset int) ([]SeasonEntry, error)
+	// GetSeasonEntry returns nil when the user has no XP in the season
+	GetSeasonEntry(season *Season, userID int) (*SeasonEntry, error)
+	ListUserResults(userID int, limit, offset int) ([]SeasonResult, error)
+	// ListUnrewardedResults returns the results of closed seasons with a
+	// reward not credited yet
+	ListUnrewardedResults() ([]SeasonResult, error)
+	MarkResultRewarded(id int64, at time.Time) error
+}
+
+type Season struct {
+	Id       int        `json:"id"`
+	Name     string     `json:"name"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   time.Time  `json:"ends_at"`
+	ClosedAt *time.Time `json:"closed_at"`
+	// open or closed, computed when it is read
+	Status string `json:"status"`
+}
+
+// SeasonReward is the XP granted to the players placed Top or better
+type SeasonReward struct {
+	Top int `json:"top"`
+	XP  int `json:"xp"`
+}
+
+type SeasonEntry struct {
+	UserID   int     `json:"user_id"`
+	Username string  `json:"username"`
+	Country  *string `json:"country"`
+	XP       int     `json:"xp"`
+	// global or country placement, depending on the ranking
+	Rank     int `json:"rank"`
+	RewardXP int `json:"reward_xp"`
+}
+
+// SeasonResult is the final placement of a user in a closed season
+type SeasonResult struct {
+	Id               int64      `json:"id"`
+	SeasonId         int        `json:"season_id"`
+	SeasonName       string     `json:"season_name"`
+	UserId           int        `json:"user_id"`
+	Username         string     `json:"username"`
+	Country          *string    `json:"country"`
+	XP               int        `json:"xp"`
+	GlobalPlacement  int        `json:"global_placement"`
+	CountryPlacement *int       `json:"country_placement"`
+	RewardXP         int        `json:"reward_xp"`
+	RewardedAt       *time.Time `json:"rewarded_at"`
+}
+
+type CurrentSeasonResponse struct {
+	Season  Season         `json:"season"`
+	Rewards []SeasonReward `json:"rewards"`
+	// the standing of the authenticated user, null before their first XP
+	Me *SeasonEntry `json:"me"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type SessionRepo interface {
+	CreateSession(*Session) error
+	// GetSession returns the session with the current role of its user, nil
+	// when the user was anonymized
+	GetSession(id int) (*Session, error)
+	ListSessions(userID int) ([]Session, error)
+	RevokeSession(userID int, id int) error
+	TouchSession(id int) error
+	IsKnownDevice(userID int, deviceName, userAgent string) (bool, error)
+}
+
+// Session is created at each login, every JWT issued at that login points to it
+type Session struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"user_id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"`
+	// the current role of the user, only read by GetSession
+	Role string `json:"-"`
+}
@@ -4,11 +4,10 @@ import "time"
 
 type StatsRepo interface {
 	AddUserStats(*Stats) (*Stats, error)
-	UpdateUserStats(*Stats) (*Stats, error)
 	GetUserStats(int) (*ExtendedStats, error)
+	GetUserStatsRow(int, *Stats) error
 	// Heatmap operations
 	GetUserHeatmap(*HeatMapPayload) ([]HeatMapEntry, error)
-	UpsertUserHeatmapEntry(*HeatMap) error
 }
 
 type Stats struct {
@@ -18,11 +17,6 @@ type Stats struct {
 	LastUpdated   time.Time `json:"last_updated"`
 	CreatedAt     time.Time `json:"created_at"`
 }
-type HeatMap struct {
-	UserID int       `json:"user_id"`
-	Count  int       `json:"count"`
-	Date   time.Time `json:"date"`
-}
 type HeatMapEntry struct {
 	Count int       `json:"count"`
 	Date  time.Time `json:"date"`
@@ -38,10 +32,10 @@ type HeatMapResponse struct {
 	Data   []HeatMapEntry `json:"data"`
 }
 
+// StatsPayload creates the stats of a user, the streaks are kept by the
+// streak engine and cannot be written by clients
 type StatsPayload struct {
-	UserID        int `json:"user_id"`
-	LongestStreak int `json:"longest_streak"`
-	CurrentStreak int `json:"current_streak"`
+	UserID int `json:"user_id"`
 }
 
 type ExtendedStats struct {
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+// streak events
+const (
+	StreakFreezeEarned    = "freeze_earned"
+	StreakFreezePurchased = "freeze_purchased"
+	// a missed day covered by a freeze
+	StreakFrozen   = "frozen"
+	StreakBroken   = "broken"
+	StreakRepaired = "repaired"
+)
+
+type StreakRepo interface {
+	// UpdateStreak locks the streak of the user, lets change modify it and
+	// saves it with the events change returns, in one transaction. Events
+	// already recorded for their day are skipped.
+	UpdateStreak(userID int, change func(*StreakState) ([]StreakEvent, error)) error
+	// UpdateStreakTx is UpdateStreak within tx, for purchases charged in the
+	// same transaction
+	UpdateStreakTx(tx *sql.Tx, userID int, change func(*StreakState) ([]StreakEvent, error)) error
+	GetStreak(userID int) (*StreakState, error)
+	ListStreakEvents(userID int, limit, offset int) ([]StreakEvent, error)
+	// ListFrozenDays returns the days covered by a freeze or a repair since from
+	ListFrozenDays(userID int, from time.Time) ([]time.Time, error)
+	// ListStreaksToSettle returns the users with a running streak whose last
+	// day is before day
+	ListStreaksToSettle(day time.Time) ([]int, error)
+}
+
+// StreakTracker extends streaks on completed focus sessions, returning the
+// streak achievements it unlocked
+type StreakTracker interface {
+	RecordSession(userID int) ([]UnlockedAchievement, error)
+}
+
+// StreakState is the streak part of the stats row, with the user's XP for purchases
+type StreakState struct {
+	UserId        int
+	CurrentStreak int
+	LongestStreak int
+	// the last day kept by a session, a freeze or a repair, nil without streak
+	LastStreakDay *time.Time
+	Freezes       int
+	// the streak lost at BrokenDay, repairable until BrokenAt plus the window
+	BrokenStreak int
+	BrokenDay    *time.Time
+	BrokenAt     *time.Time
+	XP           int
+}
+
+type StreakEvent struct {
+	Id     int64      `json:"id"`
+	UserId int        `json:"user_id"`
+	Kind   string     `json:"kind"`
+	Day    *time.Time `json:"day"`
+	// the streak when the event happened
+	Streak    int       `json:"streak"`
+	XPCost    int       `json:"xp_cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StreakResponse struct {
+	CurrentStreak int         `json:"current_streak"`
+	LongestStreak int         `json:"longest_streak"`
+	Freezes       int         `json:"freezes"`
+	MaxFreezes    int         `json:"max_freezes"`
+	FreezeCost    int         `json:"freeze_cost"`
+	FrozenDays    []time.Time `json:"frozen_days"`
+	// nil when there is no broken streak to repair
+	Repair *StreakRepairOffer `json:"repair"`
+}
+
+type StreakRepairOffer struct {
+	Streak    int       `json:"streak"`
+	Cost      int       `json:"cost"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -6,28 +6,62 @@ import (
 
 type UserRepo interface {
 	GetUserByUsername(string) (*User, error)
+	GetUserById(int) (*User, error)
+	GetUserByEmail(string) (*User, error)
 	CreateUser(User) error
-	UpdateUserEmail(id int, newEmail string) error
-	VerifyEmailUpdate(token string) error
+	// stores the pending update and returns the verification token to email
+	UpdateUserEmail(id int, newEmail string) (string, error)
+	VerifyEmailUpdate(token string) (int, error)
 	UpdateUserCountry(id string, country string) (*User, error)
+	UpdateUserLanguage(id int, language string) error
 	RequestPasswordReset(id int, code string) error
 	ResetPasswordWithCode(id int, code string, newPassword string) error
+	UpdatePasswordHash(id int, hash string) error
+	ScheduleDeletion(id int, at time.Time) error
+	CancelDeletion(id int) error
+	PurgeDeletedUsers(now time.Time) (int64, error)
+	CreateMagicLink(*MagicLink) error
+	CountMagicLinksSince(email string, since time.Time) (int, error)
+	ConsumeMagicLink(tokenHash string, nonceHash string, now time.Time) (*MagicLink, error)
+	IsUsernameAvailable(username string, forUserID int, now time.Time) (bool, error)
+	ChangeUsername(id int, newUsername string, now time.Time, heldUntil time.Time) error
+	CreateGuestUser(user *User, tokenHash string) error
+	GetUserByGuestToken(tokenHash string) (*User, error)
+	CountGuestsCreatedFromIP(ip string, since time.Time) (int, error)
+	ClaimGuestUser(id int, username string, passwordHash string) error
+	PurgeInactiveGuests(before time.Time) (int64, error)
 }
 
 type User struct {
 	Id           int       `json:"id"`
 	Username     string    `json:"username"`
 	Email        *string   `json:"email"`
-	PasswordHash string    `json:"password_hash"`
+	PasswordHash string    `json:"-"`
 	Country      *string   `json:"country"`
 	XP           int       `json:"xp"`
 	RankId       int       `json:"rank_id"`
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
+	// prestiges go back to 0 XP, lifetime XP keeps counting
+	Prestige   int `json:"prestige"`
+	LifetimeXP int `json:"lifetime_xp"`
+	// set while the account waits for hard deletion
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	UsernameChangedAt   *time.Time `json:"username_changed_at"`
+	// guests have a generated name and no credentials until they claim the account
+	IsGuest bool `json:"is_guest"`
+	// locale of the emails sent to the user
+	Language string `json:"language"`
+	// only set in responses
+	RankProgress  *RankProgress  `json:"rank_progress,omitempty"`
+	PrestigeBadge *PrestigeBadge `json:"prestige_badge,omitempty"`
 }
 
 type AuthPayload struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// optional, shown in the list of active sessions after login
+	DeviceName string `json:"device_name"`
 }
 
 type UpdateEmailPayload struct {
@@ -52,6 +86,10 @@ type UserInfoUpdate struct {
 	Country string `json:"country"`
 }
 
+type UpdateLanguagePayload struct {
+	Language string `json:"language"`
+}
+
 type ForgotPasswordPayload struct {
 	Username string `json:"username"`
 }
@@ -68,3 +106,71 @@ type ResetPasswordPayload struct {
 	Code        string `json:"code"`
 	NewPassword string `json:"new_password"`
 }
+
+type DeleteAccountPayload struct {
+	Password string `json:"password"`
+}
+
+type DeletionResponse struct {
+	Message             string    `json:"message"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type MagicLinkPayload struct {
+	Email string `json:"email"`
+	// optional, shown in the list of active sessions after login
+	DeviceName string `json:"device_name"`
+}
+
+// MagicLink is a single-use sign-in link, only hashes of the token and of the
+// device nonce cookie are stored
+type MagicLink struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"user_id"`
+	Email      string     `json:"email"`
+	TokenHash  string     `json:"-"`
+	NonceHash  string     `json:"-"`
+	DeviceName string     `json:"device_name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+}
+
+type ChangeUsernamePayload struct {
+	Username string `json:"username"`
+}
+
+// the token is re-issued so its username claim matches the new name
+type ChangeUsernameResponse struct {
+	User  *User  `json:"user"`
+	Token string `json:"token"`
+}
+
+type GuestPayload struct {
+	// optional, shown in the list of active sessions
+	DeviceName string `json:"device_name"`
+}
+
+type GuestLoginPayload struct {
+	DeviceToken string `json:"device_token"`
+	DeviceName  string `json:"device_name"`
+}
+
+// the device token is only returned once, it signs the guest in again when the JWT expires
+type GuestResponse struct {
+	User        *User  `json:"user"`
+	DeviceToken string `json:"device_token"`
+	Token       string `json:"token"`
+}
+
+// at least a password or an email is needed so the account can be signed in to without the device token
+type ClaimAccountPayload struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+type ClaimAccountResponse struct {
+	User  *User  `json:"user"`
+	Token string `json:"token"`
+}
@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+// outgoing webhook events
+const (
+	WebhookRankChanged = "rank.changed"
+)
+
+// Webhook delivers events to the configured endpoint, Send only queues them
+type Webhook interface {
+	Send(event string, data any) error
+}
+
+// WebhookEvent is the JSON body posted to the endpoint
+type WebhookEvent struct {
+	Id        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
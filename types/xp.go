@@ -0,0 +1,129 @@
+package types
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// why XP was granted or taken
+const (
+	XPReasonOpeningBalance   = "opening_balance"
+	XPReasonFocusSession     = "focus_session"
+	XPReasonSessionReversed  = "session_reversed"
+	XPReasonSessionAbandoned = "session_abandoned"
+	XPReasonAdminAdjustment  = "admin_adjustment"
+	XPReasonAchievement      = "achievement_reward"
+	XPReasonQuest            = "quest_reward"
+	XPReasonStreakFreeze     = "streak_freeze_purchase"
+	XPReasonStreakRepair     = "streak_repair"
+	XPReasonSeasonReward     = "season_reward"
+	XPReasonPrestigeReset    = "prestige_reset"
+)
+
+// what an XP transaction refers to
+const (
+	XPSourcePomodoro    = "pomodoro"
+	XPSourceAdmin       = "admin"
+	XPSourceAchievement = "achievement"
+	XPSourceQuest       = "quest"
+	XPSourceStreak      = "streak"
+	XPSourceSeason      = "season"
+	XPSourcePrestige    = "prestige"
+)
+
+// EarnedXPReasons are the reasons counted as XP earned by seasons and
+// leagues. Opening balances and season rewards only change the balance and
+// the lifetime XP, spending and prestige resets only change the balance.
+var EarnedXPReasons = []string{
+	XPReasonFocusSession,
+	XPReasonSessionReversed,
+	XPReasonSessionAbandoned,
+	XPReasonAdminAdjustment,
+	XPReasonAchievement,
+	XPReasonQuest,
+}
+
+func IsEarnedXP(reason string) bool {
+	for _, r := range EarnedXPReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// LifetimeXPExcluded are the reasons left out of the lifetime XP: XP spent
+// was earned once already, and a prestige must not take the lifetime XP down
+var LifetimeXPExcluded = []string{
+	XPReasonStreakFreeze,
+	XPReasonStreakRepair,
+	XPReasonPrestigeReset,
+}
+
+func CountsForLifetimeXP(reason string) bool {
+	for _, r := range LifetimeXPExcluded {
+		if r == reason {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrNotEnoughXP is returned when a purchase costs more than the balance
+var ErrNotEnoughXP = errors.New("not enough XP")
+
+// XPLedger is the only way to change a user's XP
+type XPLedger interface {
+	// Record appends the transaction and updates users.xp, users.lifetime_xp
+	// and users.rank_id in the same database transaction, with the XP of the
+	// current season when the reason counts for it. A negative delta never
+	// takes the balance below zero: Delta and BalanceAfter are set to what was
+	// applied. When the rank changes, it is written to rank_history and set as
+	// RankChange. A source already recorded with the same reason is skipped
+	// and false is returned.
+	Record(*XPTransaction) (bool, error)
+	// Spend records the charge t of a purchase like Record, in the same
+	// database transaction as buy: buy runs once the balance is locked, with
+	// the transaction to write the purchase in, and sets the source of t. The
+	// purchase and its charge are committed together or not at all. A balance
+	// below the price is never clamped, ErrNotEnoughXP is returned instead.
+	Spend(t *XPTransaction, buy func(tx *sql.Tx) error) error
+	// Reverse takes back the XP a source granted with reason, or gives back
+	// the XP it took, returns nil when there is nothing to reverse
+	Reverse(sourceType string, sourceID int64, reason string, at time.Time) (*XPTransaction, error)
+	// Prestige takes a user at the top rank back to the first rank: the
+	// balance goes to zero with a prestige_reset transaction, users.prestige
+	// goes up and the move is written to prestige_history and rank_history,
+	// in one database transaction. Lifetime XP and season XP are kept. It
+	// returns nil when the user is not at the top rank.
+	Prestige(userID int, at time.Time) (*Prestige, error)
+}
+
+type XPRepo interface {
+	XPLedger
+	ListTransactions(userID int, limit, offset int) ([]XPTransaction, error)
+	// GetScoringDay describes the day of a focus session for the XP rules,
+	// leaving out the pomodoro excludeID
+	GetScoringDay(userID int, day time.Time, excludeID int) (*XPScoringDay, error)
+	// RecomputeAll rebuilds users.xp, users.lifetime_xp and users.rank_id from
+	// the ledger, writes the rank changes to rank_history, rebuilds the XP of
+	// the open seasons and returns the number of users whose XP changed with
+	// the rank changes to announce
+	RecomputeAll() (int64, []RankChange, error)
+}
+
+type XPTransaction struct {
+	Id           int64     `json:"id"`
+	UserId       int       `json:"user_id"`
+	Delta        int       `json:"delta"`
+	Reason       string    `json:"reason"`
+	SourceType   *string   `json:"source_type"`
+	SourceId     *int64    `json:"source_id"`
+	Multiplier   float64   `json:"multiplier"`
+	BalanceAfter int       `json:"balance_after"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+	// set by Record when the transaction changed the rank, not stored
+	RankChange *RankChange `json:"-"`
+}
@@ -0,0 +1,126 @@
+package types
+
+import "time"
+
+// bonuses a focus session can earn
+const (
+	XPBonusCycle     = "cycle"
+	XPBonusEarlyBird = "early_bird"
+	XPBonusGoal      = "goal"
+)
+
+// XPRules decide how many XP a focus session earns. Bonuses and penalties
+// with 0 XP are disabled, as are caps of 0.
+type XPRules struct {
+	BaseXPPerMinute float64          `json:"base_xp_per_minute"`
+	Streak          StreakMultiplier `json:"streak"`
+	Bonuses         XPBonusRules     `json:"bonuses"`
+	Penalties       XPPenaltyRules   `json:"penalties"`
+	// XP focus sessions can earn per day (UTC) of saving, bonuses included
+	DailyCap int `json:"daily_cap"`
+}
+
+// StreakMultiplier is Base + GrowthPerDay * (streak - 1), or the multiplier
+// of the last step reached when Steps is set, never above Cap which is
+// required
+type StreakMultiplier struct {
+	Base         float64      `json:"base"`
+	GrowthPerDay float64      `json:"growth_per_day"`
+	Cap          float64      `json:"cap"`
+	Steps        []StreakStep `json:"steps"`
+}
+
+type StreakStep struct {
+	MinStreak  int     `json:"min_streak"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type XPBonusRules struct {
+	Cycle     XPCycleBonus     `json:"cycle"`
+	EarlyBird XPEarlyBirdBonus `json:"early_bird"`
+	Goal      XPGoalBonus      `json:"goal"`
+}
+
+// XPCycleBonus rewards every Every-th completed focus session of the day
+type XPCycleBonus struct {
+	Every int `json:"every"`
+	XP    int `json:"xp"`
+}
+
+// XPEarlyBirdBonus rewards sessions saved before BeforeHour (UTC)
+type XPEarlyBirdBonus struct {
+	BeforeHour int `json:"before_hour"`
+	XP         int `json:"xp"`
+}
+
+// XPGoalBonus rewards the session reaching DailyMinutes of focus in the day
+type XPGoalBonus struct {
+	DailyMinutes int `json:"daily_minutes"`
+	XP           int `json:"xp"`
+}
+
+type XPPenaltyRules struct {
+	AbandonedSession XPAbandonedPenalty `json:"abandoned_session"`
+}
+
+// XPAbandonedPenalty takes XP for focus sessions saved as not completed,
+// sessions shorter than GraceMinutes are not penalized
+type XPAbandonedPenalty struct {
+	XP           int `json:"xp"`
+	GraceMinutes int `json:"grace_minutes"`
+}
+
+// XPScoringDay is what the rules need to know about the day of a session
+type XPScoringDay struct {
+	// completed focus sessions credited by the ledger this day (UTC) and
+	// their minutes, the scored one excluded
+	Sessions int
+	Minutes  int
+	// XP earned by the focus sessions credited this day
+	XP            int
+	Streak        int
+	LastStreakDay *time.Time
+}
+
+// SessionScorer records the XP of saved focus sessions
+type SessionScorer interface {
+	// Award records the score of a completed focus session or the penalty of
+	// an abandoned one, once, and returns nil when nothing was recorded.
+	// Failures are only logged, the session is saved anyway.
+	Award(*Pomodoro) *XPTransaction
+}
+
+type XPBonus struct {
+	Code string `json:"code"`
+	XP   int    `json:"xp"`
+}
+
+// XPScore is the detail of how a session scores
+type XPScore struct {
+	Completed  bool      `json:"completed"`
+	Minutes    int       `json:"minutes"`
+	Streak     int       `json:"streak"`
+	Multiplier float64   `json:"multiplier"`
+	BaseXP     int       `json:"base_xp"`
+	Bonuses    []XPBonus `json:"bonuses"`
+	Penalty    int       `json:"penalty"`
+	// XP above the daily cap, not granted
+	CappedXP int `json:"capped_xp"`
+	Total    int `json:"total"`
+}
+
+type XPDryRunPayload struct {
+	SessionDuration int  `json:"session_duration"`
+	Completed       bool `json:"completed"`
+	// when the session is saved, now by default
+	EndTime *time.Time `json:"end_time"`
+	// the streak to score with, the user's streak by default
+	Streak *int `json:"streak"`
+}
+
+type XPRulesResponse struct {
+	Rules XPRules `json:"rules"`
+	// the rules file, empty for the built-in defaults
+	Source   string    `json:"source"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
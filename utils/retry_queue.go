@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RetryJob is one delivery of a RetryQueue
+type RetryJob struct {
+	// what is delivered, for the logs, e.g. `push "Rank up" to subscription 3`
+	Name string
+	// Deliver makes one attempt. A retryAfter longer than the backoff delays
+	// the next attempt, a negative one means retrying is useless.
+	Deliver func() (retryAfter time.Duration, err error)
+	attempt int
+}
+
+// RetryQueue runs deliveries in the background, failed ones are retried with
+// an exponential backoff up to attempts times
+type RetryQueue struct {
+	name     string
+	queue    chan RetryJob
+	attempts int
+	Backoff  time.Duration
+	// deliveries queued or waiting for a retry
+	pending sync.WaitGroup
+}
+
+func NewRetryQueue(name string, attempts int, size int) *RetryQueue {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryQueue{
+		name:     name,
+		queue:    make(chan RetryJob, size),
+		attempts: attempts,
+		Backoff:  5 * time.Second,
+	}
+}
+
+// Push only queues the job, it fails when the queue is full
+func (q *RetryQueue) Push(job RetryJob) error {
+	job.attempt = 1
+	q.pending.Add(1)
+	if err := q.enqueue(job); err != nil {
+		q.pending.Done()
+		return err
+	}
+	return nil
+}
+
+func (q *RetryQueue) enqueue(job RetryJob) error {
+	select {
+	case q.queue <- job:
+		return nil
+	default:
+		return fmt.Errorf("%s queue is full, dropping %s", q.name, job.Name)
+	}
+}
+
+// Run delivers queued jobs until the process exits
+func (q *RetryQueue) Run() {
+	for job := range q.queue {
+		retryAfter, err := job.Deliver()
+		if err == nil {
+			q.pending.Done()
+			continue
+		}
+		if job.attempt >= q.attempts || retryAfter < 0 {
+			log.Printf("giving up on %s after %d attempts: %v", job.Name, job.attempt, err)
+			q.pending.Done()
+			continue
+		}
+		delay := max(q.Backoff<<(job.attempt-1), retryAfter)
+		log.Printf("failed to deliver %s (attempt %d), retrying in %s: %v", job.Name, job.attempt, delay, err)
+		retry := job
+		retry.attempt++
+		time.AfterFunc(delay, func() {
+			if err := q.enqueue(retry); err != nil {
+				log.Print(err)
+				q.pending.Done()
+			}
+		})
+	}
+}
+
+// Wait blocks until every job pushed so far is delivered or given up, for
+// commands that exit once their work is done
+func (q *RetryQueue) Wait() {
+	q.pending.Wait()
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryQueue(t *testing.T) {
+	q := NewRetryQueue("test", 3, 8)
+	q.Backoff = time.Millisecond
+	go q.Run()
+
+	var flaky, hopeless, failing atomic.Int32
+	push := func(name string, deliver func() (time.Duration, error)) {
+		if err := q.Push(RetryJob{Name: name, Deliver: deliver}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// delivered on the second attempt
+	push("flaky", func() (time.Duration, error) {
+		if flaky.Add(1) == 1 {
+			return 0, fmt.Errorf("unavailable")
+		}
+		return 0, nil
+	})
+	// a negative retryAfter gives up at once
+	push("hopeless", func() (time.Duration, error) {
+		hopeless.Add(1)
+		return -1, fmt.Errorf("gone")
+	})
+	// given up after the last attempt
+	push("failing", func() (time.Duration, error) {
+		failing.Add(1)
+		return 0, fmt.Errorf("unavailable")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait did not return")
+	}
+	if flaky.Load() != 2 || hopeless.Load() != 1 || failing.Load() != 3 {
+		t.Errorf("got %d, %d and %d attempts, want 2, 1 and 3", flaky.Load(), hopeless.Load(), failing.Load())
+	}
+}
+
+func TestRetryQueueFull(t *testing.T) {
+	// without Run nothing drains the queue
+	q := NewRetryQueue("test", 1, 1)
+	deliver := func() (time.Duration, error) { return 0, nil }
+	if err := q.Push(RetryJob{Name: "first", Deliver: deliver}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Push(RetryJob{Name: "second", Deliver: deliver}); err == nil {
+		t.Error("pushing to a full queue did not fail")
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"log"
+	"time"
+)
+
+// RunEvery calls job every interval until the process exits, errors are only logged.
+// Start it in its own goroutine.
+func RunEvery(interval time.Duration, name string, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := job(); err != nil {
+			log.Printf("job %s failed: %v", name, err)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package utils
 import (
 	"backend/types"
 	crand "crypto/rand"
+	"crypto/sha256"
 	mrand "math/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
+	"strings"
 )
 
 func ParseJson(r *http.Request, payload any) error {
@@ -40,6 +43,12 @@ func GenerateToken() string {
 	_, _ = crand.Read(b)
 	return hex.EncodeToString(b)
 }
+// HashToken returns the hex sha256 of a secret token, used to store tokens without keeping them
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GenerateRandomCode(length int) string {
 	code := make([]byte, length)
 	for i := 0; i < length; i++ {
@@ -47,3 +56,16 @@ func GenerateRandomCode(length int) string {
 	}
 	return string(code)
 }
+
+// ClientIP returns the address of the caller, honouring the first
+// X-Forwarded-For entry set by a reverse proxy
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}